@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MichelLacerda/nox/internal/dap"
+	"github.com/MichelLacerda/nox/internal/runtime"
+)
+
+const debugHelp = `Commands:
+  c, continue          run until the next breakpoint
+  s, step              step into the next statement
+  n, next              step over calls
+  o, out               run until the current function returns
+  b, break [file:]line set a breakpoint (default file: current one)
+  d, delete [file:]line remove a breakpoint
+  bl, breakpoints      list breakpoints
+  bt, where            print the call stack
+  f, frame N           select frame N of the call stack
+  l, locals            show the variables of the selected frame
+  g, globals           show global variables
+  p, print EXPR        evaluate an expression in the selected frame
+  list                 show the source around the current line
+  q, quit              stop the program
+  h, help              show this help
+An empty line repeats the previous command.`
+
+// runDebug implementa "nox debug <script> [args...]" e "nox debug --dap".
+func runDebug(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ContinueOnError)
+	useDAP := flags.Bool("dap", false, "speak the Debug Adapter Protocol on stdin/stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: nox debug <script.nox> [args...]")
+		fmt.Fprintln(os.Stderr, "       nox debug --dap")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+
+	if *useDAP {
+		if err := dap.NewServer().Run(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "nox debug:", err)
+			return 1
+		}
+		return 0
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 64
+	}
+
+	nox := runtime.NewNox()
+	nox.Args = flags.Args()
+	debugger := runtime.NewDebugger()
+	debugger.StopOnEntry = true
+	console := &debugConsole{debugger: debugger, in: bufio.NewReader(os.Stdin), sources: map[string][]string{}}
+	debugger.OnStop = console.stop
+	nox.Debugger = debugger
+
+	fmt.Println("Nox debugger. Type 'h' for help.")
+	return exitCode(nox.ExecFile(flags.Arg(0)))
+}
+
+// debugConsole é a interface de linha de comando do depurador.
+type debugConsole struct {
+	debugger *runtime.Debugger
+	in       *bufio.Reader
+	frames   []runtime.DebugFrame
+	frame    int // frame selecionado
+	last     string
+	sources  map[string][]string
+}
+
+func (c *debugConsole) stop(stop runtime.DebugStop) runtime.DebugAction {
+	c.frames = c.debugger.Frames()
+	c.frame = 0
+	fmt.Printf("Stopped (%s) at %s:%d\n", stop.Reason, stop.File, stop.Line)
+	c.showLine(stop.File, stop.Line)
+
+	for {
+		fmt.Print("(nox) ")
+		line, err := c.in.ReadString('\n')
+		if err == io.EOF && line == "" {
+			fmt.Println()
+			return runtime.DebugTerminate
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			line = c.last
+		}
+		c.last = line
+
+		command, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch command {
+		case "c", "continue":
+			return runtime.DebugContinue
+		case "s", "step":
+			return runtime.DebugStepIn
+		case "n", "next":
+			return runtime.DebugStepOver
+		case "o", "out", "finish":
+			return runtime.DebugStepOut
+		case "b", "break":
+			c.toggleBreakpoint(arg, true)
+		case "d", "delete":
+			c.toggleBreakpoint(arg, false)
+		case "bl", "breakpoints":
+			for file, lines := range c.debugger.Breakpoints() {
+				for _, l := range lines {
+					fmt.Printf("  %s:%d\n", file, l)
+				}
+			}
+		case "bt", "where":
+			for idx, frame := range c.frames {
+				marker := " "
+				if idx == c.frame {
+					marker = ">"
+				}
+				fmt.Printf("%s #%d %s at %s:%d\n", marker, idx, frame.Function, frame.File, frame.Line)
+			}
+		case "f", "frame":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 || n >= len(c.frames) {
+				fmt.Printf("No frame %q (0-%d).\n", arg, len(c.frames)-1)
+				continue
+			}
+			c.frame = n
+			frame := c.frames[n]
+			fmt.Printf("#%d %s at %s:%d\n", n, frame.Function, frame.File, frame.Line)
+			c.showLine(frame.File, frame.Line)
+		case "l", "locals":
+			c.printVariables(c.debugger.Locals(c.frames[c.frame]))
+		case "g", "globals":
+			c.printVariables(c.debugger.Globals())
+		case "p", "print":
+			value, err := c.debugger.Evaluate(c.frames[c.frame], arg)
+			if err != nil {
+				fmt.Println("Error:", err)
+				continue
+			}
+			fmt.Println(c.debugger.Format(value))
+		case "list":
+			frame := c.frames[c.frame]
+			for l := max(frame.Line-5, 1); l <= frame.Line+5; l++ {
+				c.showLine(frame.File, l)
+			}
+		case "q", "quit":
+			return runtime.DebugTerminate
+		case "h", "help":
+			fmt.Println(debugHelp)
+		default:
+			fmt.Printf("Unknown command %q. Type 'h' for help.\n", command)
+		}
+	}
+}
+
+func (c *debugConsole) printVariables(vars []runtime.DebugVariable) {
+	if len(vars) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, v := range vars {
+		fmt.Printf("  %s = %s\n", v.Name, c.debugger.Format(v.Value))
+	}
+}
+
+// toggleBreakpoint interpreta "[arquivo:]linha" e liga ou desliga o breakpoint.
+func (c *debugConsole) toggleBreakpoint(spec string, enable bool) {
+	file := c.frames[c.frame].File
+	lineText := spec
+	if idx := strings.LastIndex(spec, ":"); idx >= 0 {
+		file, lineText = spec[:idx], spec[idx+1:]
+	}
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line <= 0 {
+		fmt.Println("Usage: break [file:]line")
+		return
+	}
+
+	path := c.debugger.Path(file)
+	var lines []int
+	for _, l := range c.debugger.Breakpoints()[path] {
+		if l != line {
+			lines = append(lines, l)
+		}
+	}
+	if enable {
+		lines = append(lines, line)
+		fmt.Printf("Breakpoint at %s:%d\n", filepath.Base(path), line)
+	}
+	c.debugger.SetBreakpoints(path, lines)
+}
+
+func (c *debugConsole) showLine(file string, line int) {
+	path := c.debugger.Path(file)
+	lines, ok := c.sources[path]
+	if !ok {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		lines = strings.Split(string(source), "\n")
+		c.sources[path] = lines
+	}
+	if line >= 1 && line <= len(lines) {
+		fmt.Printf("%4d | %s\n", line, lines[line-1])
+	}
+}
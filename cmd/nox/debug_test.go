@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/MichelLacerda/nox/internal/runtime"
+)
+
+// O console encerra o programa, sem sair do processo, com "q" ou com o fim
+// da entrada.
+func TestDebugConsoleStops(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("main.nox", []byte("let a = 1;\nprint \"after\";\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []string{"q\n", "", "p a\n"} {
+		nox := runtime.NewNox()
+		var out strings.Builder
+		nox.Stdout, nox.Stderr = &out, io.Discard
+		debugger := runtime.NewDebugger()
+		debugger.StopOnEntry = true
+		console := &debugConsole{debugger: debugger, in: bufio.NewReader(strings.NewReader(input)), sources: map[string][]string{}}
+		debugger.OnStop = console.stop
+		nox.Debugger = debugger
+
+		err := nox.ExecFile("main.nox")
+		var exit *runtime.ExitError
+		if !errors.As(err, &exit) || exit.Code != 0 {
+			t.Errorf("input %q: err = %v, want exit code 0", input, err)
+		}
+		if out.String() != "" {
+			t.Errorf("input %q: the program kept running: %q", input, out.String())
+		}
+	}
+}
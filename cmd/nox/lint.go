@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+	"github.com/MichelLacerda/nox/internal/lint"
+	"github.com/MichelLacerda/nox/internal/util"
+)
+
+// runLint implementa "nox lint [--json] <arquivo|diretório>...". Sai com
+// código 1 quando algum problema é encontrado.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print diagnostics as a JSON array")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: nox lint [--json] <file|dir>...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := util.NoxFiles(paths, ".nox")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lint:", err)
+		return 1
+	}
+
+	all := diagnostic.List{}
+	for _, file := range files {
+		diags, err := lint.File(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "lint:", err)
+			return 1
+		}
+		all = append(all, diags...)
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(all, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, d := range all {
+			fmt.Println(d.String())
+		}
+	}
+
+	if len(all) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MichelLacerda/nox/internal/lsp"
+)
+
+// runLSP atende um editor pelo stdin/stdout até o fim da sessão.
+func runLSP() int {
+	if err := lsp.NewServer().Run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "nox lsp:", err)
+		return 1
+	}
+	return 0
+}
@@ -1,20 +1,48 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/MichelLacerda/nox/internal/runtime"
 )
 
+// subcommands são os comandos de "nox <comando> ...".
+var subcommands = map[string]func(args []string) int{
+	"lint":  runLint,
+	"lsp":   func([]string) int { return runLSP() },
+	"test":  runTests,
+	"run":   runScript,
+	"debug": runDebug,
+	"mod":   runMod,
+}
+
+// command devolve o subcomando pedido em args, os argumentos depois do
+// programa. Um script com o nome de um subcomando ("nox test" numa pasta
+// com um arquivo test) é executado como script; "nox run test" também.
+func command(args []string) (func(args []string) int, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return nil, false
+	}
+	if info, err := os.Stat(args[0]); err == nil && !info.IsDir() {
+		return nil, false
+	}
+	return run, true
+}
+
 func main() {
+	if run, ok := command(os.Args[1:]); ok {
+		os.Exit(run(os.Args[2:]))
+	}
+
 	nox := runtime.NewNox()
-	if len(os.Args) > 2 {
-		fmt.Println("Usage: nox <script>")
-		os.Exit(64) // Exit code 64 indicates a command line usage error.
-	} else if len(os.Args) == 2 {
-		nox.RunFile(os.Args[1])
-	} else {
-		nox.RunPrompt()
+	if len(os.Args) >= 2 {
+		// Tudo após o script é repassado ao programa via os.args
+		nox.Args = os.Args[1:]
+		os.Exit(exitCode(nox.RunFile(os.Args[1])))
 	}
+	os.Exit(exitCode(nox.RunPrompt()))
 }
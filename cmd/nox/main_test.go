@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCommandDispatch(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, name := range []string{"test", "script.nox"} {
+		if err := os.WriteFile(name, []byte("print 1;\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir("lint", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    []string
+		command bool
+	}{
+		{nil, false},                           // REPL
+		{[]string{"script.nox", "run"}, false}, // script com argumentos
+		{[]string{"run", "test"}, true},        // nox run executa qualquer arquivo
+		{[]string{"test"}, false},              // arquivo com o nome do subcomando
+		{[]string{"mod", "vendor"}, true},      // sem arquivo "mod"
+		{[]string{"lint", "script.nox"}, true}, // uma pasta não é um script
+		{[]string{"unknown.nox"}, false},
+	}
+	for _, tt := range tests {
+		if _, got := command(tt.args); got != tt.command {
+			t.Errorf("command(%q) = %v, want %v", tt.args, got, tt.command)
+		}
+	}
+}
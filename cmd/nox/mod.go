@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MichelLacerda/nox/internal/manifest"
+	"github.com/MichelLacerda/nox/internal/runtime"
+)
+
+const modUsage = `Usage: nox mod [command]
+
+Commands:
+  install          install the dependencies of nox.toml/nox.json into nox_modules,
+                   at the versions pinned in nox.lock (default)
+  update [name...] fetch the dependencies again, ignoring the commits in nox.lock
+  init [name]      create a nox.toml in the current directory`
+
+// runMod implementa "nox mod [install | update [nomes...] | init [nome]]".
+func runMod(args []string) int {
+	command := "install"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "init":
+		if len(args) > 1 {
+			fmt.Fprintln(os.Stderr, modUsage)
+			return 64
+		}
+		return modInit(args)
+	case "install":
+		if len(args) > 0 {
+			fmt.Fprintln(os.Stderr, modUsage)
+			return 64
+		}
+		return modVendor(manifest.VendorOptions{})
+	case "update":
+		return modVendor(manifest.VendorOptions{UpdateAll: len(args) == 0, Update: args})
+	case "-h", "--help", "help":
+		fmt.Println(modUsage)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "nox mod: unknown command %q\n\n%s\n", command, modUsage)
+	return 64
+}
+
+func modInit(args []string) int {
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nox mod:", err)
+		return 1
+	}
+	name := filepath.Base(dir)
+	if len(args) > 0 {
+		name = args[0]
+	}
+	path, err := manifest.Init(dir, name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nox mod:", err)
+		return 1
+	}
+	fmt.Println("created", filepath.Base(path))
+	return 0
+}
+
+// modVendor instala as dependências do projeto que contém a pasta atual.
+func modVendor(options manifest.VendorOptions) int {
+	root := runtime.ProjectRoot(".")
+	if root == "" {
+		fmt.Fprintln(os.Stderr, "nox mod: no nox.toml or nox.json found in this directory or its parents (create one with 'nox mod init')")
+		return 1
+	}
+	m, err := manifest.Find(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nox mod:", err)
+		return 1
+	}
+	for _, name := range options.Update {
+		if _, ok := m.Dependencies[name]; !ok {
+			fmt.Fprintf(os.Stderr, "nox mod: '%s' is not a dependency in %s\n", name, filepath.Base(m.File))
+			return 1
+		}
+	}
+
+	options.Log = os.Stdout
+	lock, err := manifest.Vendor(m, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nox mod:", err)
+		return 1
+	}
+	fmt.Printf("%d dependencies in %s, pinned in %s\n", len(lock.Dependencies), manifest.VendorDir, manifest.LockFile)
+	return 0
+}
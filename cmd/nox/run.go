@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+	"github.com/MichelLacerda/nox/internal/runtime"
+)
+
+// runScript implementa "nox run [--profile] [--cover] [--sandbox ...] <script> [args...]".
+func runScript(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	profile := flags.Bool("profile", false, "measure time per function and line, print a report and write a pprof profile")
+	profileOut := flags.String("profile-out", "nox.pprof", "`file` for the pprof profile (with --profile)")
+	profileTop := flags.Int("profile-top", 20, "entries per report table, 0 for all (with --profile)")
+	cover := flags.Bool("cover", false, "record executed lines and branches and write coverage reports")
+	coverOut := flags.String("cover-out", "coverage.lcov", "`file` for the LCOV report (with --cover)")
+	coverHTML := flags.String("cover-html", "coverage.html", "`file` for the HTML report, empty to skip (with --cover)")
+	sandbox := flags.Bool("sandbox", false, "deny file, process, network, environment and exit access unless allowed below")
+	var allowRead, allowWrite, allow, disable listFlag
+	flags.Var(&allowRead, "allow-read", "`dir` whose files may be read (with --sandbox; repeatable or comma-separated)")
+	flags.Var(&allowWrite, "allow-write", "`dir` whose files may be written (with --sandbox; repeatable or comma-separated)")
+	flags.Var(&allow, "allow", "grant `capabilities`: exec, net, env, exit (with --sandbox)")
+	flags.Var(&disable, "disable", "disable builtin `names` such as http or os.setenv (with --sandbox)")
+	timeout := flags.Duration("timeout", 0, "stop the script after `duration` (e.g. 5s, 2m)")
+	maxSteps := flags.Int64("max-steps", 0, "stop the script after `n` executed statements")
+	maxDepth := flags.Int("max-depth", runtime.DefaultMaxDepth, "maximum `n` of nested function calls")
+	var maxMemory sizeFlag
+	flags.Var(&maxMemory, "max-memory", "stop the script when it allocates about `size` more memory (e.g. 64MB)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: nox run [flags] <script.nox> [args...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 64
+	}
+
+	nox := runtime.NewNox()
+	nox.Args = flags.Args()
+	if *sandbox {
+		sb, err := newSandbox(allowRead, allowWrite, allow, disable)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nox run:", err)
+			return 64
+		}
+		nox.Sandbox = sb
+	} else if len(allowRead)+len(allowWrite)+len(allow)+len(disable) > 0 {
+		fmt.Fprintln(os.Stderr, "nox run: --allow-read, --allow-write, --allow and --disable need --sandbox")
+		return 64
+	}
+	nox.Limits = runtime.Limits{MaxSteps: *maxSteps, MaxDepth: *maxDepth, MaxMemory: int64(maxMemory)}
+	if *timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		nox.Context = ctx
+	}
+	if !*profile && !*cover {
+		return exitCode(nox.ExecFile(flags.Arg(0)))
+	}
+
+	if *profile {
+		nox.Profiler = runtime.NewProfiler()
+	}
+	if *cover {
+		nox.Coverage = runtime.NewCoverage()
+	}
+
+	var once sync.Once
+	finish := func() {
+		once.Do(func() {
+			if nox.Profiler != nil {
+				writeProfile(nox.Profiler, *profileOut, *profileTop)
+			}
+			if nox.Coverage != nil {
+				writeCoverage(nox.Coverage, *coverOut, *coverHTML)
+			}
+		})
+	}
+
+	// Ctrl+C encerra servidores e scripts longos sem perder os relatórios
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		finish()
+		os.Exit(130)
+	}()
+
+	err := nox.ExecFile(flags.Arg(0))
+	signal.Stop(interrupt)
+	finish()
+	return exitCode(err)
+}
+
+// listFlag acumula valores de uma flag repetida ou separados por vírgula.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// sizeFlag lê tamanhos como 512KB, 64MB ou 1GB (múltiplos de 1024); sem
+// sufixo o valor é em bytes.
+type sizeFlag int64
+
+func (s *sizeFlag) String() string {
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *sizeFlag) Set(value string) error {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(text, unit.suffix) {
+			text, multiplier = strings.TrimSuffix(text, unit.suffix), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q (use e.g. 64MB)", value)
+	}
+	*s = sizeFlag(n * multiplier)
+	return nil
+}
+
+func newSandbox(read, write, allow, disable []string) (*runtime.Sandbox, error) {
+	sb := &runtime.Sandbox{ReadDirs: read, WriteDirs: write, Disable: disable}
+	for _, capability := range allow {
+		switch capability {
+		case "exec":
+			sb.Exec = true
+		case "net":
+			sb.Network = true
+		case "env":
+			sb.Env = true
+		case "exit":
+			sb.Exit = true
+		default:
+			return nil, fmt.Errorf("unknown capability %q (use exec, net, env or exit)", capability)
+		}
+	}
+	return sb, nil
+}
+
+// writeProfile imprime o relatório em stderr, para não se misturar à saída
+// do programa, e grava o perfil do pprof.
+func writeProfile(profiler *runtime.Profiler, path string, top int) {
+	fmt.Fprintln(os.Stderr)
+	profiler.Report(os.Stderr, top)
+
+	if err := writeReport(path, profiler.WritePprof); err != nil {
+		fmt.Fprintln(os.Stderr, "nox run: writing profile:", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nProfile written to %s (view with: go tool pprof -http=: %s)\n", path, path)
+}
+
+// writeCoverage imprime o resumo em stderr e grava os relatórios LCOV e HTML.
+func writeCoverage(coverage *runtime.Coverage, lcovPath, htmlPath string) {
+	fmt.Fprintln(os.Stderr)
+	coverage.Summary(os.Stderr)
+
+	if err := writeReport(lcovPath, coverage.WriteLCOV); err != nil {
+		fmt.Fprintln(os.Stderr, "nox run: writing coverage:", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nCoverage written to %s", lcovPath)
+	if htmlPath != "" {
+		if err := writeReport(htmlPath, coverage.WriteHTML); err != nil {
+			fmt.Fprintln(os.Stderr, "\nnox run: writing coverage:", err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, " and %s", htmlPath)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// writeReport cria o arquivo e grava nele com write.
+func writeReport(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// exitCode converte o resultado de ExecFile no código de saída do processo.
+// Erros de sintaxe e de execução já foram exibidos; os.exit define o código.
+// Só o cmd/nox encerra o processo: o runtime apenas devolve o erro.
+func exitCode(err error) int {
+	switch err := err.(type) {
+	case nil:
+		return 0
+	case *runtime.ExitError:
+		return err.Code
+	case diagnostic.List:
+		return 65
+	case *runtime.RuntimeError:
+		return 70
+	default:
+		fmt.Fprintln(os.Stderr, "nox:", err)
+		return 1
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/MichelLacerda/nox/internal/runtime"
+	"github.com/MichelLacerda/nox/internal/util"
+)
+
+// runTests implementa "nox test [flags] [paths...]".
+func runTests(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	verbose := flags.Bool("v", false, "list every test, not only failures and skips")
+	run := flags.String("run", "", "run only tests whose name matches `regexp`")
+	junit := flags.String("junit", "", "write a JUnit XML report to `file`")
+	cover := flags.Bool("cover", false, "record coverage of the code run by the tests")
+	coverOut := flags.String("cover-out", "coverage.lcov", "`file` for the LCOV report (with --cover)")
+	coverHTML := flags.String("cover-html", "coverage.html", "`file` for the HTML report, empty to skip (with --cover)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: nox test [flags] [files or directories...]")
+		fmt.Fprintln(os.Stderr, "Runs the test_* functions of every *_test.nox file (default: current directory).")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+
+	options := runtime.TestOptions{}
+	if *run != "" {
+		filter, err := regexp.Compile(*run)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nox test: invalid -run pattern:", err)
+			return 64
+		}
+		options.Filter = filter
+	}
+	if *cover {
+		options.Coverage = runtime.NewCoverage()
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := util.NoxFiles(paths, "_test.nox")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nox test:", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Println("no test files")
+		return 0
+	}
+
+	start := time.Now()
+	var suites []testSuite
+	for _, file := range files {
+		suite := testSuite{file: file, start: time.Now()}
+		suite.results = runtime.RunTests(file, options)
+		suite.duration = time.Since(suite.start)
+		printSuite(suite, *verbose)
+		suites = append(suites, suite)
+	}
+
+	counts := map[runtime.TestStatus]int{}
+	for _, suite := range suites {
+		for _, result := range suite.results {
+			counts[result.Status]++
+		}
+	}
+	fmt.Printf("\n%d passed, %d failed, %d errors, %d skipped in %s\n",
+		counts[runtime.TestPassed], counts[runtime.TestFailed], counts[runtime.TestErrored], counts[runtime.TestSkipped],
+		time.Since(start).Round(time.Millisecond))
+
+	if *junit != "" {
+		if err := writeReport(*junit, func(w io.Writer) error { return writeJUnit(w, suites) }); err != nil {
+			fmt.Fprintln(os.Stderr, "nox test: writing JUnit report:", err)
+			return 1
+		}
+	}
+	if options.Coverage != nil {
+		writeCoverage(options.Coverage, *coverOut, *coverHTML)
+	}
+
+	if counts[runtime.TestFailed] > 0 || counts[runtime.TestErrored] > 0 {
+		fmt.Println("FAIL")
+		return 1
+	}
+	fmt.Println("PASS")
+	return 0
+}
+
+type testSuite struct {
+	file     string
+	start    time.Time
+	duration time.Duration
+	results  []runtime.TestResult
+}
+
+func printSuite(suite testSuite, verbose bool) {
+	failed := false
+	for _, result := range suite.results {
+		name := result.Name
+		if name == "" {
+			name = "(file)"
+		}
+		if result.Status == runtime.TestFailed || result.Status == runtime.TestErrored {
+			failed = true
+		}
+		if result.Status == runtime.TestPassed && !verbose {
+			continue
+		}
+
+		fmt.Printf("--- %s: %s (%s)\n", result.Status, name, formatDuration(result.Duration))
+		switch result.Status {
+		case runtime.TestFailed:
+			fmt.Printf("    %s: %s\n", result.Location, result.Message)
+		case runtime.TestErrored:
+			detail := result.Traceback
+			if detail == "" {
+				detail = result.Message
+			}
+			fmt.Println(indent(detail, "    "))
+		case runtime.TestSkipped:
+			fmt.Printf("    %s\n", result.Message)
+		}
+	}
+
+	status := "ok  "
+	if failed {
+		status = "FAIL"
+	}
+	fmt.Printf("%s %s (%d tests, %s)\n", status, suite.file, len(suite.results), formatDuration(suite.duration))
+}
+
+func formatDuration(d time.Duration) string {
+	if d >= time.Millisecond {
+		return d.Round(time.Millisecond / 10).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n"+prefix)
+}
+
+// Estrutura do relatório JUnit, no formato aceito pela maioria dos CIs.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, suites []testSuite) error {
+	report := junitSuites{}
+	var total time.Duration
+	for _, suite := range suites {
+		js := junitSuite{
+			Name:      filepath.ToSlash(suite.file),
+			Time:      seconds(suite.duration),
+			Timestamp: suite.start.Format("2006-01-02T15:04:05"),
+		}
+		for _, result := range suite.results {
+			name := result.Name
+			if name == "" {
+				name = "(file)"
+			}
+			jc := junitCase{Name: name, Classname: js.Name, Time: seconds(result.Duration)}
+			problem := &junitProblem{Message: result.Message, Body: result.Traceback}
+			switch result.Status {
+			case runtime.TestFailed:
+				jc.Failure = problem
+				js.Failures++
+			case runtime.TestErrored:
+				jc.Error = problem
+				js.Errors++
+			case runtime.TestSkipped:
+				jc.Skipped = &junitProblem{Message: result.Message}
+				js.Skipped++
+			}
+			js.Cases = append(js.Cases, jc)
+		}
+		js.Tests = len(js.Cases)
+
+		report.Tests += js.Tests
+		report.Failures += js.Failures
+		report.Errors += js.Errors
+		report.Skipped += js.Skipped
+		total += suite.duration
+		report.Suites = append(report.Suites, js)
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MichelLacerda/nox/internal/runtime"
+)
+
+func TestJUnitReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report_test.nox")
+	source := `import "test" as test;
+
+func test_pass() {
+    test.ok(true);
+}
+
+func test_fail() {
+    test.equal(1, 2);
+}
+
+func test_error() {
+    let x = nil;
+    return x.y;
+}
+
+func test_skip() {
+    test.skip("later");
+}
+`
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	suite := testSuite{file: path, start: time.Now()}
+	suite.results = runtime.RunTests(path, runtime.TestOptions{})
+
+	var out bytes.Buffer
+	if err := writeJUnit(&out, []testSuite{suite}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), xml.Header) {
+		t.Errorf("report does not start with the XML header:\n%s", out.String())
+	}
+	var report junitSuites
+	if err := xml.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Tests != 4 || report.Failures != 1 || report.Errors != 1 || report.Skipped != 1 {
+		t.Errorf("totals = %d tests, %d failures, %d errors, %d skipped", report.Tests, report.Failures, report.Errors, report.Skipped)
+	}
+	if len(report.Suites) != 1 || len(report.Suites[0].Cases) != 4 {
+		t.Fatalf("report = %s", out.String())
+	}
+	js := report.Suites[0]
+	if js.Name != filepath.ToSlash(path) || js.Tests != 4 {
+		t.Errorf("suite = %s with %d tests", js.Name, js.Tests)
+	}
+
+	cases := js.Cases
+	if c := cases[0]; c.Name != "test_pass" || c.Classname != js.Name || c.Failure != nil || c.Error != nil || c.Skipped != nil {
+		t.Errorf("test_pass = %+v", c)
+	}
+	if c := cases[1]; c.Name != "test_fail" || c.Failure == nil || c.Failure.Message != "expected 2, got 1" || !strings.Contains(c.Failure.Body, "Traceback") {
+		t.Errorf("test_fail = %+v", c)
+	}
+	if c := cases[2]; c.Name != "test_error" || c.Error == nil || !strings.Contains(c.Error.Body, "in test_error") {
+		t.Errorf("test_error = %+v", c)
+	}
+	if c := cases[3]; c.Name != "test_skip" || c.Skipped == nil || c.Skipped.Message != "later" {
+		t.Errorf("test_skip = %+v", c)
+	}
+}
@@ -20,7 +20,7 @@ type BinaryExpr struct {
 
 type CallExpr struct {
 	Callee      Expr
-	Parenthesis *token.Token // The opening parenthesis
+	Parenthesis *token.Token // The closing parenthesis
 	Arguments   []Expr
 }
 
@@ -35,6 +35,7 @@ type GroupingExpr struct {
 
 type LiteralExpr struct {
 	Value any
+	Token *token.Token // nil quando o literal não vem da fonte
 }
 
 type LogicalExpr struct {
@@ -69,22 +70,25 @@ type VariableExpr struct {
 
 type ListExpr struct {
 	Elements []Expr
-	Bracket  *token.Token
+	Bracket  *token.Token // The closing bracket
 }
 
 type IndexExpr struct {
-	Object Expr
-	Index  Expr
+	Object  Expr
+	Index   Expr
+	Bracket *token.Token // The opening bracket
 }
 
 type SetIndexExpr struct {
-	Object Expr
-	Index  Expr
-	Value  Expr
+	Object  Expr
+	Index   Expr
+	Value   Expr
+	Bracket *token.Token // The opening bracket
 }
 
 type DictExpr struct {
 	Pairs []DictPair
+	Brace *token.Token // The opening brace
 }
 
 type DictPair struct {
@@ -8,6 +8,7 @@ type Stmt interface {
 }
 
 type BlockStmt struct {
+	Brace      *token.Token // The opening brace
 	Statements []Stmt
 }
 
@@ -36,12 +37,14 @@ type FunctionStmt struct {
 }
 
 type IfStmt struct {
+	Keyword   *token.Token // The 'if' keyword
 	Condition Expr
 	Then      Stmt
 	Else      Stmt // Optional else statement
 }
 
 type PrintStmt struct {
+	Keyword     *token.Token // The 'print' keyword
 	Expressions []Expr
 }
 
@@ -61,6 +64,7 @@ type VarStmt struct {
 // }
 
 type ForInStmt struct {
+	Keyword  *token.Token // The 'for' keyword
 	IndexVar *token.Token // pode ser nil, para o `_`
 	ValueVar *token.Token
 	Iterable Expr
@@ -80,6 +84,7 @@ type ContinueStmt struct {
 }
 
 type WithStmt struct {
+	Keyword  *token.Token // The 'with' keyword
 	Resource Expr         // ex: open("file.txt", "r")
 	Alias    *token.Token // ex: f
 	Body     Stmt         // ex: block { ... }
@@ -87,8 +92,25 @@ type WithStmt struct {
 
 type ImportStmt struct {
 	// import "<path>"" [as <alias>]
-	Path  *token.Token // STRING Token, ex: "std/math"
-	Alias *token.Token // IDENTIFIER Token, ex: "math"
+	// import { <name> [as <alias>], ... } from "<path>"
+	Keyword *token.Token  // The 'import' keyword
+	Path    *token.Token  // STRING Token, ex: "std/math"
+	Alias   *token.Token  // IDENTIFIER Token, ex: "math"
+	Names   []*ImportName // nomes escolhidos, na forma com chaves
+}
+
+// ImportName é um nome de "import { nome as alias } from".
+type ImportName struct {
+	Name  *token.Token // nome exportado pelo módulo
+	Alias *token.Token // nome local, quando diferente
+}
+
+// Binding devolve o token do nome definido no escopo de quem importa.
+func (n *ImportName) Binding() *token.Token {
+	if n.Alias != nil {
+		return n.Alias
+	}
+	return n.Name
 }
 
 type ExportStmt struct {
@@ -1,5 +1,7 @@
 package ast
 
+import "strings"
+
 func (b *BlockStmt) String() string {
 	var result string
 	for _, stmt := range b.Statements {
@@ -95,6 +97,16 @@ func (w *WithStmt) String() string {
 }
 
 func (i *ImportStmt) String() string {
+	if i.Names != nil {
+		names := make([]string, len(i.Names))
+		for idx, name := range i.Names {
+			names[idx] = name.Name.Lexeme
+			if name.Alias != nil {
+				names[idx] += " as " + name.Alias.Lexeme
+			}
+		}
+		return "import { " + strings.Join(names, ", ") + " } from " + i.Path.Lexeme + ";"
+	}
 	result := "import " + i.Path.Lexeme
 	if i.Alias != nil {
 		result += " as " + i.Alias.Lexeme
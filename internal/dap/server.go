@@ -0,0 +1,483 @@
+// Package dap implementa um adaptador do Debug Adapter Protocol sobre o
+// depurador do runtime, para uso por editores como o VS Code.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+	"github.com/MichelLacerda/nox/internal/runtime"
+)
+
+// O interpretador tem uma única thread de execução
+const threadID = 1
+
+type message struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+type launchArguments struct {
+	Program     string   `json:"program"`
+	Args        []string `json:"args"`
+	Cwd         string   `json:"cwd"`
+	StopOnEntry bool     `json:"stopOnEntry"`
+}
+
+// handle é o alvo de um variablesReference: um escopo ou um valor composto.
+type handle struct {
+	frame   *runtime.DebugFrame // escopo local do frame
+	globals bool
+	value   any
+}
+
+// Server atende um cliente DAP em um par de streams.
+type Server struct {
+	reader *bufio.Reader
+	writer io.Writer
+
+	writeMu sync.Mutex
+	seq     int
+
+	debugger *runtime.Debugger
+	launch   *launchArguments
+	resume   chan runtime.DebugAction
+
+	// ctx é cancelado quando o cliente se desconecta: o programa para no
+	// próximo comando ou na espera em que estiver. exited é fechado quando
+	// o programa termina, e é nil enquanto ele não começou.
+	ctx    context.Context
+	cancel context.CancelFunc
+	exited chan struct{}
+
+	mu      sync.Mutex
+	paused  bool
+	frames  []runtime.DebugFrame
+	handles []handle
+}
+
+func NewServer() *Server {
+	s := &Server{
+		debugger: runtime.NewDebugger(),
+		resume:   make(chan runtime.DebugAction),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.debugger.OnStop = s.onStop
+	return s
+}
+
+// Run atende requisições até "disconnect" ou o fim da entrada. Nos dois
+// casos o programa, se estiver executando, é encerrado antes de Run voltar.
+func (s *Server) Run(in io.Reader, out io.Writer) error {
+	s.reader = bufio.NewReader(in)
+	s.writer = out
+	defer s.stopProgram()
+
+	for {
+		body, err := s.readMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req message
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		if req.Command == "disconnect" || req.Command == "terminate" {
+			s.stopProgram()
+			s.reply(&req, nil)
+			return nil
+		}
+		if err := s.handle(&req); err != nil {
+			s.fail(&req, err.Error())
+		}
+	}
+}
+
+func (s *Server) readMessage() ([]byte, error) {
+	headers, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) send(build func(seq int) any) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.seq++
+	body, err := json.Marshal(build(s.seq))
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *Server) reply(req *message, body any) {
+	s.send(func(seq int) any {
+		return response{Seq: seq, Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body}
+	})
+}
+
+func (s *Server) fail(req *message, text string) {
+	s.send(func(seq int) any {
+		return response{Seq: seq, Type: "response", RequestSeq: req.Seq, Command: req.Command, Message: text}
+	})
+}
+
+func (s *Server) event(name string, body any) {
+	s.send(func(seq int) any {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+func (s *Server) handle(req *message) error {
+	decode := func(target any) error {
+		if len(req.Arguments) == 0 {
+			return nil
+		}
+		return json.Unmarshal(req.Arguments, target)
+	}
+
+	switch req.Command {
+	case "initialize":
+		s.reply(req, map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+		})
+		s.event("initialized", nil)
+
+	case "launch":
+		var args launchArguments
+		if err := decode(&args); err != nil {
+			return err
+		}
+		if args.Program == "" {
+			return fmt.Errorf("missing 'program' in launch configuration")
+		}
+		s.launch = &args
+		s.debugger.StopOnEntry = args.StopOnEntry
+		s.reply(req, nil)
+
+	case "setBreakpoints":
+		var args struct {
+			Source      struct{ Path string } `json:"source"`
+			Breakpoints []struct{ Line int }  `json:"breakpoints"`
+		}
+		if err := decode(&args); err != nil {
+			return err
+		}
+		lines := make([]int, len(args.Breakpoints))
+		result := make([]map[string]any, len(args.Breakpoints))
+		for idx, bp := range args.Breakpoints {
+			lines[idx] = bp.Line
+			result[idx] = map[string]any{"verified": true, "line": bp.Line}
+		}
+		s.debugger.SetBreakpoints(args.Source.Path, lines)
+		s.reply(req, map[string]any{"breakpoints": result})
+
+	case "setExceptionBreakpoints":
+		s.reply(req, map[string]any{})
+
+	case "configurationDone":
+		if s.launch == nil {
+			return fmt.Errorf("configurationDone before launch")
+		}
+		if s.exited != nil {
+			return fmt.Errorf("the program is already running")
+		}
+		s.reply(req, nil)
+		s.exited = make(chan struct{})
+		go s.runProgram()
+
+	case "threads":
+		s.reply(req, map[string]any{
+			"threads": []map[string]any{{"id": threadID, "name": "main"}},
+		})
+
+	case "stackTrace":
+		s.mu.Lock()
+		frames := []map[string]any{}
+		for idx, frame := range s.frames {
+			path := s.debugger.Path(frame.File)
+			frames = append(frames, map[string]any{
+				"id":     idx,
+				"name":   frame.Function,
+				"source": map[string]any{"name": filepath.Base(path), "path": path},
+				"line":   frame.Line,
+				"column": 1,
+			})
+		}
+		s.mu.Unlock()
+		s.reply(req, map[string]any{"stackFrames": frames, "totalFrames": len(frames)})
+
+	case "scopes":
+		var args struct {
+			FrameID int `json:"frameId"`
+		}
+		if err := decode(&args); err != nil {
+			return err
+		}
+		frame, err := s.frame(args.FrameID)
+		if err != nil {
+			return err
+		}
+		s.reply(req, map[string]any{"scopes": []map[string]any{
+			{"name": "Locals", "variablesReference": s.newHandle(handle{frame: frame}), "expensive": false},
+			{"name": "Globals", "variablesReference": s.newHandle(handle{globals: true}), "expensive": false},
+		}})
+
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := decode(&args); err != nil {
+			return err
+		}
+		s.reply(req, map[string]any{"variables": s.variables(args.VariablesReference)})
+
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+			FrameID    *int   `json:"frameId"`
+		}
+		if err := decode(&args); err != nil {
+			return err
+		}
+		frameID := 0
+		if args.FrameID != nil {
+			frameID = *args.FrameID
+		}
+		frame, err := s.frame(frameID)
+		if err != nil {
+			return err
+		}
+		value, err := s.debugger.Evaluate(*frame, args.Expression)
+		if err != nil {
+			return err
+		}
+		s.reply(req, map[string]any{
+			"result":             s.debugger.Format(value),
+			"variablesReference": s.reference(value),
+		})
+
+	case "continue":
+		s.reply(req, map[string]any{"allThreadsContinued": true})
+		s.resumeWith(runtime.DebugContinue)
+	case "next":
+		s.reply(req, nil)
+		s.resumeWith(runtime.DebugStepOver)
+	case "stepIn":
+		s.reply(req, nil)
+		s.resumeWith(runtime.DebugStepIn)
+	case "stepOut":
+		s.reply(req, nil)
+		s.resumeWith(runtime.DebugStepOut)
+
+	case "pause":
+		s.debugger.Pause()
+		s.reply(req, nil)
+
+	default:
+		return fmt.Errorf("unsupported request: %s", req.Command)
+	}
+	return nil
+}
+
+// onStop roda na goroutine do programa: guarda o estado da parada, avisa o
+// cliente e espera um comando de execução.
+func (s *Server) onStop(stop runtime.DebugStop) runtime.DebugAction {
+	s.mu.Lock()
+	s.paused = true
+	s.frames = s.debugger.Frames()
+	s.handles = nil
+	s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return runtime.DebugTerminate
+	}
+	s.event("stopped", map[string]any{
+		"reason":            stop.Reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+	select {
+	case action := <-s.resume:
+		return action
+	case <-s.ctx.Done():
+		return runtime.DebugTerminate
+	}
+}
+
+// stopProgram encerra o programa e espera ele terminar. Um programa parado
+// recebe DebugTerminate; um em execução para no próximo comando, ou, se
+// estiver em uma espera (time.sleep, process), com o cancelamento de
+// Nox.Context.
+func (s *Server) stopProgram() {
+	s.cancel()
+	if s.exited == nil {
+		return
+	}
+	s.debugger.Pause()
+	<-s.exited
+}
+
+func (s *Server) resumeWith(action runtime.DebugAction) {
+	s.mu.Lock()
+	paused := s.paused
+	s.paused = false
+	s.frames = nil
+	s.handles = nil
+	s.mu.Unlock()
+
+	if paused {
+		s.resume <- action
+	}
+}
+
+func (s *Server) frame(id int) (*runtime.DebugFrame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		return nil, fmt.Errorf("the program is running")
+	}
+	if id < 0 || id >= len(s.frames) {
+		return nil, fmt.Errorf("invalid frame %d", id)
+	}
+	return &s.frames[id], nil
+}
+
+func (s *Server) newHandle(h handle) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handles = append(s.handles, h)
+	return len(s.handles) // 0 significa "sem filhos" no protocolo
+}
+
+func (s *Server) reference(value any) int {
+	if !s.debugger.HasChildren(value) {
+		return 0
+	}
+	return s.newHandle(handle{value: value})
+}
+
+func (s *Server) variables(ref int) []map[string]any {
+	s.mu.Lock()
+	if ref < 1 || ref > len(s.handles) {
+		s.mu.Unlock()
+		return []map[string]any{}
+	}
+	h := s.handles[ref-1]
+	s.mu.Unlock()
+
+	var vars []runtime.DebugVariable
+	switch {
+	case h.frame != nil:
+		vars = s.debugger.Locals(*h.frame)
+	case h.globals:
+		vars = s.debugger.Globals()
+	default:
+		vars = s.debugger.Children(h.value)
+	}
+
+	result := []map[string]any{}
+	for _, v := range vars {
+		result = append(result, map[string]any{
+			"name":               v.Name,
+			"value":              s.debugger.Format(v.Value),
+			"type":               runtime.TypeOf(v.Value),
+			"variablesReference": s.reference(v.Value),
+		})
+	}
+	return result
+}
+
+// runProgram executa o script com stdout e stderr enviados como eventos
+// "output", já que stdin e stdout são o canal do protocolo.
+func (s *Server) runProgram() {
+	defer close(s.exited)
+	if s.launch.Cwd != "" {
+		if err := os.Chdir(s.launch.Cwd); err != nil {
+			s.event("output", map[string]any{"category": "stderr", "output": err.Error() + "\n"})
+		}
+	}
+
+	nox := runtime.NewNox()
+	nox.Args = append([]string{s.launch.Program}, s.launch.Args...)
+	nox.Debugger = s.debugger
+	nox.Context = s.ctx
+	nox.Stdout = outputWriter{s, "stdout"}
+	nox.Stderr = outputWriter{s, "stderr"}
+	nox.Stdin = strings.NewReader("")
+
+	exitCode := 0
+	switch err := nox.ExecFile(s.launch.Program).(type) {
+	case nil:
+	case diagnostic.List:
+		exitCode = 65
+	case *runtime.RuntimeError:
+		exitCode = 70
+	case *runtime.ExitError:
+		exitCode = err.Code
+	default:
+		fmt.Fprintln(nox.Stderr, err)
+		exitCode = 1
+	}
+
+	s.event("exited", map[string]any{"exitCode": exitCode})
+	s.event("terminated", nil)
+}
+
+// outputWriter envia o que o script escreve como eventos "output".
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.server.event("output", map[string]any{"category": w.category, "output": string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,364 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testScript = `func double(n) {
+    let result = n * 2;
+    return result;
+}
+let items = [1, 2];
+let x = double(21);
+print "x is", x;
+`
+
+// reply é uma resposta ou um evento recebido do servidor.
+type reply struct {
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Command    string          `json:"command"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client conversa com um Server que executa em outra goroutine.
+type client struct {
+	t        *testing.T
+	program  string
+	input    *io.PipeWriter
+	messages chan reply
+	done     chan error // recebe o retorno de Run
+	seq      int
+	output   strings.Builder // eventos "output" já vistos
+	events   []string        // nomes dos eventos já vistos
+}
+
+// start grava source como main.nox e inicia o servidor até o launch.
+func start(t *testing.T, source string, stopOnEntry bool) *client {
+	t.Helper()
+	program := filepath.Join(t.TempDir(), "main.nox")
+	if err := os.WriteFile(program, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	c := &client{t: t, program: program, input: inWriter, messages: make(chan reply, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer().Run(inReader, outWriter)
+		outWriter.Close()
+	}()
+	go c.read(bufio.NewReader(outReader))
+	t.Cleanup(func() { inWriter.Close() })
+
+	c.call("initialize", nil)
+	c.call("launch", map[string]any{"program": program, "stopOnEntry": stopOnEntry})
+	return c
+}
+
+func (c *client) read(reader *bufio.Reader) {
+	defer close(c.messages)
+	for {
+		headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		length, _ := strconv.Atoi(headers.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return
+		}
+		var msg reply
+		if json.Unmarshal(body, &msg) == nil {
+			c.messages <- msg
+		}
+	}
+}
+
+// next devolve a próxima mensagem do servidor.
+func (c *client) next() reply {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the server closed the connection")
+		}
+		if msg.Type == "event" {
+			c.events = append(c.events, msg.Event)
+		}
+		if msg.Event == "output" {
+			var body struct{ Output string }
+			json.Unmarshal(msg.Body, &body)
+			c.output.WriteString(body.Output)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+		return reply{}
+	}
+}
+
+// call envia uma requisição e espera a resposta, que precisa ter sucesso.
+// O corpo da resposta é decodificado em target, se não for nil.
+func (c *client) call(command string, args any, target ...any) {
+	c.t.Helper()
+	msg := c.request(command, args)
+	if !msg.Success {
+		c.t.Fatalf("%s failed: %s", command, msg.Message)
+	}
+	for _, t := range target {
+		if err := json.Unmarshal(msg.Body, t); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+}
+
+// request envia uma requisição e devolve a resposta, sucesso ou não.
+func (c *client) request(command string, args any) reply {
+	c.t.Helper()
+	c.seq++
+	body, _ := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	fmt.Fprintf(c.input, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	for {
+		msg := c.next()
+		if msg.Type == "response" && msg.RequestSeq == c.seq {
+			return msg
+		}
+	}
+}
+
+// wait espera o evento name e devolve o seu corpo.
+func (c *client) wait(name string) json.RawMessage {
+	c.t.Helper()
+	for {
+		if msg := c.next(); msg.Type == "event" && msg.Event == name {
+			return msg.Body
+		}
+	}
+}
+
+// stopped espera uma parada e devolve o motivo e a linha do frame do topo.
+func (c *client) stopped() (string, int) {
+	c.t.Helper()
+	var stop struct{ Reason string }
+	json.Unmarshal(c.wait("stopped"), &stop)
+	var trace struct {
+		StackFrames []struct {
+			Name string
+			Line int
+		}
+	}
+	c.call("stackTrace", map[string]any{"threadId": threadID}, &trace)
+	return stop.Reason, trace.StackFrames[0].Line
+}
+
+// finished espera o fim de Run.
+func (c *client) finished() {
+	c.t.Helper()
+	select {
+	case err := <-c.done:
+		if err != nil {
+			c.t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("Run did not return")
+	}
+}
+
+func TestBreakpointVariablesAndEvaluate(t *testing.T) {
+	c := start(t, testScript, false)
+	c.call("setBreakpoints", map[string]any{"source": map[string]any{"path": c.program}, "breakpoints": []map[string]any{{"line": 3}}})
+	c.call("configurationDone", nil)
+
+	if reason, line := c.stopped(); reason != "breakpoint" || line != 3 {
+		t.Fatalf("stopped (%s) at line %d, want the breakpoint at line 3", reason, line)
+	}
+
+	var trace struct {
+		StackFrames []struct{ Name string }
+	}
+	c.call("stackTrace", map[string]any{"threadId": threadID}, &trace)
+	if len(trace.StackFrames) != 2 || trace.StackFrames[0].Name != "double" {
+		t.Errorf("stack = %+v, want double called from the script", trace.StackFrames)
+	}
+
+	var scopes struct {
+		Scopes []struct {
+			Name               string
+			VariablesReference int
+		}
+	}
+	c.call("scopes", map[string]any{"frameId": 0}, &scopes)
+	var locals struct {
+		Variables []struct{ Name, Value string }
+	}
+	c.call("variables", map[string]any{"variablesReference": scopes.Scopes[0].VariablesReference}, &locals)
+	got := map[string]string{}
+	for _, v := range locals.Variables {
+		got[v.Name] = v.Value
+	}
+	if got["n"] != "21" || got["result"] != "42" {
+		t.Errorf("locals = %v, want n = 21 and result = 42", got)
+	}
+
+	var evaluated struct{ Result string }
+	c.call("evaluate", map[string]any{"expression": "result + n", "frameId": 0}, &evaluated)
+	if evaluated.Result != "63" {
+		t.Errorf("evaluate = %q, want 63", evaluated.Result)
+	}
+	if msg := c.request("evaluate", map[string]any{"expression": "missing", "frameId": 0}); msg.Success {
+		t.Error("evaluating an undefined name succeeded")
+	}
+
+	c.call("continue", map[string]any{"threadId": threadID})
+	var exited struct{ ExitCode int }
+	json.Unmarshal(c.wait("exited"), &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("exit code = %d", exited.ExitCode)
+	}
+	if !strings.Contains(c.output.String(), "x is 42") {
+		t.Errorf("output = %q", c.output.String())
+	}
+	c.call("disconnect", nil)
+	c.finished()
+}
+
+// Num breakpoint dentro de um módulo importado, os locais são só os da
+// função: nem as globais do módulo nem os builtins aparecem.
+func TestBreakpointInImportedModule(t *testing.T) {
+	c := start(t, `import "lib.nox" as lib;
+print lib.scale(3);
+`, false)
+	module := filepath.Join(filepath.Dir(c.program), "lib.nox")
+	source := `let counter = 10;
+export func scale(n) {
+    let factor = 3;
+    return n * factor + counter;
+}
+`
+	if err := os.WriteFile(module, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c.call("setBreakpoints", map[string]any{"source": map[string]any{"path": module}, "breakpoints": []map[string]any{{"line": 4}}})
+	c.call("configurationDone", nil)
+
+	if reason, line := c.stopped(); reason != "breakpoint" || line != 4 {
+		t.Fatalf("stopped (%s) at line %d, want the breakpoint at line 4", reason, line)
+	}
+
+	var scopes struct {
+		Scopes []struct {
+			Name               string
+			VariablesReference int
+		}
+	}
+	c.call("scopes", map[string]any{"frameId": 0}, &scopes)
+	var locals struct {
+		Variables []struct{ Name, Value string }
+	}
+	c.call("variables", map[string]any{"variablesReference": scopes.Scopes[0].VariablesReference}, &locals)
+	var names []string
+	for _, v := range locals.Variables {
+		names = append(names, v.Name)
+	}
+	if !slices.Equal(names, []string{"factor", "n"}) {
+		t.Errorf("locals = %v, want only factor and n", names)
+	}
+
+	var evaluated struct{ Result string }
+	c.call("evaluate", map[string]any{"expression": "n * factor + counter", "frameId": 0}, &evaluated)
+	if evaluated.Result != "19" {
+		t.Errorf("evaluate = %q, want 19", evaluated.Result)
+	}
+
+	c.call("continue", map[string]any{"threadId": threadID})
+	c.wait("exited")
+	if !strings.Contains(c.output.String(), "19") {
+		t.Errorf("output = %q", c.output.String())
+	}
+	c.call("disconnect", nil)
+	c.finished()
+}
+
+func TestStepping(t *testing.T) {
+	c := start(t, testScript, true)
+	c.call("configurationDone", nil)
+
+	steps := []struct {
+		command string
+		reason  string
+		line    int
+	}{
+		{"", "entry", 1},
+		{"next", "step", 5},
+		{"next", "step", 6},
+		{"stepIn", "step", 2},
+		{"next", "step", 3},
+		{"stepOut", "step", 7},
+	}
+	for _, step := range steps {
+		if step.command != "" {
+			c.call(step.command, map[string]any{"threadId": threadID})
+		}
+		if reason, line := c.stopped(); reason != step.reason || line != step.line {
+			t.Fatalf("after %q: stopped (%s) at line %d, want (%s) at line %d", step.command, reason, line, step.reason, step.line)
+		}
+	}
+	c.call("continue", map[string]any{"threadId": threadID})
+	c.wait("terminated")
+	c.call("disconnect", nil)
+	c.finished()
+}
+
+// Desconectar encerra o programa, parado ou em execução, antes de responder:
+// o evento "terminated" chega antes da resposta ao disconnect.
+func TestDisconnectStopsProgram(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		stop   bool // espera a parada no breakpoint da linha 2
+	}{
+		{"stopped at a breakpoint", "let n = 0;\nn = 1;\nprint \"after\";\n", true},
+		{"running a loop", "let n = 0;\nfor {\n    n = n + 1;\n}\n", false},
+		{"sleeping", "time.sleep(60);\nprint \"after\";\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := start(t, tt.source, false)
+			c.call("setBreakpoints", map[string]any{"source": map[string]any{"path": c.program}, "breakpoints": []map[string]any{{"line": 2}}})
+			c.call("configurationDone", nil)
+			if tt.stop {
+				c.stopped()
+			} else {
+				time.Sleep(50 * time.Millisecond) // o programa já está executando
+			}
+
+			start := time.Now()
+			c.call("disconnect", nil)
+			c.finished()
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("disconnect took %v", elapsed)
+			}
+			if !slices.Contains(c.events, "terminated") {
+				t.Errorf("events before the disconnect response = %q, want the program terminated", c.events)
+			}
+			if strings.Contains(c.output.String(), "after") {
+				t.Errorf("the program kept running after disconnect: %q", c.output.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package diagnostic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic descreve um erro ou aviso em uma posição do código-fonte.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`           // a partir de 1
+	Column   int    `json:"column"`         // a partir de 1, em runas; 0 quando desconhecida
+	Length   int    `json:"length"`         // quantidade de runas sublinhadas
+	Severity string `json:"severity"`       // SeverityError quando vazio
+	Code     string `json:"code,omitempty"` // regra que gerou o diagnóstico (lint)
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) severity() string {
+	if d.Severity == "" {
+		return SeverityError
+	}
+	return d.Severity
+}
+
+func (d Diagnostic) describe() string {
+	if d.Code != "" {
+		return fmt.Sprintf("%s: %s [%s]", d.severity(), d.Message, d.Code)
+	}
+	return d.severity() + ": " + d.Message
+}
+
+// String devolve o formato compacto file:line:col: message.
+func (d Diagnostic) String() string {
+	file := d.File
+	if file == "" {
+		file = "<unknown>"
+	}
+	if d.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", file, d.Line, d.Column, d.describe())
+	}
+	return fmt.Sprintf("%s:%d: %s", file, d.Line, d.describe())
+}
+
+// Render formata o diagnóstico com a linha de origem e um sublinhado:
+//
+//	error: Expect ')' after arguments.
+//	 --> main.nox:3:12
+//	  |
+//	3 | print len(x
+//	  |            ^
+func (d Diagnostic) Render(source string) string {
+	var sb strings.Builder
+	sb.WriteString(d.describe() + "\n")
+
+	location := d.File
+	if location == "" {
+		location = "<unknown>"
+	}
+	location += fmt.Sprintf(":%d", d.Line)
+	if d.Column > 0 {
+		location += fmt.Sprintf(":%d", d.Column)
+	}
+
+	text, ok := sourceLine(source, d.Line)
+	gutter := strings.Repeat(" ", len(fmt.Sprint(d.Line)))
+	sb.WriteString(gutter + "--> " + location)
+	if !ok {
+		return sb.String()
+	}
+
+	text = strings.ReplaceAll(text, "\t", "    ")
+	sb.WriteString("\n" + gutter + " |\n")
+	sb.WriteString(fmt.Sprintf("%d | %s\n", d.Line, text))
+	if d.Column > 0 {
+		length := max(d.Length, 1)
+		// A coluna conta runas; tabs antes dela ocupam quatro espaços
+		prefix, _ := sourceLine(source, d.Line)
+		pad := 0
+		for idx, r := range []rune(prefix) {
+			if idx >= d.Column-1 {
+				break
+			}
+			if r == '\t' {
+				pad += 4
+			} else {
+				pad++
+			}
+		}
+		if rest := utf8.RuneCountInString(text) - pad; rest > 0 && length > rest {
+			length = rest
+		}
+		sb.WriteString(gutter + " | " + strings.Repeat(" ", pad) + strings.Repeat("^", length))
+	} else {
+		sb.WriteString(gutter + " |")
+	}
+	return sb.String()
+}
+
+func sourceLine(source string, line int) (string, bool) {
+	if line < 1 {
+		return "", false
+	}
+	lines := strings.Split(source, "\n")
+	if line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// List é uma lista de diagnósticos que também pode ser usada como error.
+type List []Diagnostic
+
+func (l List) Error() string {
+	messages := make([]string, len(l))
+	for idx, d := range l {
+		messages[idx] = d.String()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Render formata todos os diagnósticos com seus trechos de código.
+func (l List) Render(source string) string {
+	parts := make([]string, len(l))
+	for idx, d := range l {
+		parts[idx] = d.Render(source)
+	}
+	out := strings.Join(parts, "\n\n")
+	if len(l) > 1 {
+		out += fmt.Sprintf("\n\n%d errors found.", len(l))
+	}
+	return out
+}
+
+// Sort ordena os diagnósticos por linha e coluna, mantendo a ordem dos que
+// estão na mesma posição.
+func (l List) Sort() {
+	sort.SliceStable(l, func(a, b int) bool {
+		if l[a].Line != l[b].Line {
+			return l[a].Line < l[b].Line
+		}
+		return l[a].Column < l[b].Column
+	})
+}
+
+// HasErrors indica se algum diagnóstico da lista é um erro (e não aviso).
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.severity() == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,643 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/parser"
+	"github.com/MichelLacerda/nox/internal/runtime"
+	"github.com/MichelLacerda/nox/internal/scanner"
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+const indentStr = "    "
+
+// Source formata um programa Nox. A fonte é validada pelo parser antes: com
+// erros de sintaxe nada é formatado e os diagnósticos são devolvidos como
+// erro (uma diagnostic.List).
+//
+// A saída é impressa a partir do AST: cada comando em uma linha, terminado
+// por ';', com quatro espaços por nível de bloco e espaços fixos em volta de
+// operadores e depois de vírgulas. A condição do if só fica entre
+// parênteses quando o corpo é um comando solto, como em "if (n < 2) return
+// n;". Da fonte vêm só os comentários, que o scanner entrega como trivia e
+// são encaixados pela posição, e as escolhas de layout do autor: blocos e
+// coleções que cabiam em uma linha continuam em uma linha, coleções com o
+// primeiro elemento na linha seguinte ao bracket ficam com um elemento por
+// linha, expressões quebradas depois de um operador ou antes de um '.'
+// continuam quebradas e linhas em branco entre comandos viram uma só. Isso
+// torna a formatação idempotente.
+//
+// Source nunca entra em pânico: um nó que o printer não conhece vira erro.
+func Source(file, source string) (formatted string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			formatted, err = "", fmt.Errorf("%s: %v", file, r)
+		}
+	}()
+
+	if _, diags := runtime.ParseSource(file, source); len(diags) > 0 {
+		return "", diags
+	}
+
+	p := newPrinter(source)
+	statements, err := parser.NewParser(p.tokens).Parse()
+	if err != nil {
+		return "", err
+	}
+	for _, stmt := range statements {
+		p.stmt(stmt)
+	}
+	p.flush(len(source) + 1)
+	return p.finish(), nil
+}
+
+type printer struct {
+	tokens   []*token.Token // tokens do programa, os mesmos do AST
+	index    map[*token.Token]int
+	pair     []int          // para cada bracket, o índice do bracket que o fecha ou abre
+	comments []*token.Token // comentários ainda não escritos, na ordem da fonte
+
+	lines   []string
+	current strings.Builder
+	indent  int
+
+	started   bool // já há algo na saída
+	afterOpen bool // nada foi escrito desde a abertura de um bloco ou coleção
+	broken    bool // um comentário "//" terminou a linha no meio de um comando
+	lastLine  int  // linha da fonte onde termina o último token ou comentário escrito
+}
+
+func newPrinter(source string) *printer {
+	sc := scanner.NewScanner([]rune(source))
+	sc.KeepComments = true
+	all, _ := sc.ScanTokens()
+
+	p := &printer{index: map[*token.Token]int{}}
+	var open []int
+	for _, t := range all {
+		if t.Type == token.TokenType_COMMENT {
+			p.comments = append(p.comments, t)
+			continue
+		}
+		idx := len(p.tokens)
+		p.index[t] = idx
+		p.tokens = append(p.tokens, t)
+		p.pair = append(p.pair, -1)
+		switch t.Type {
+		case token.TokenType_LEFT_PAREN, token.TokenType_LEFT_BRACKET, token.TokenType_LEFT_BRACE:
+			open = append(open, idx)
+		case token.TokenType_RIGHT_PAREN, token.TokenType_RIGHT_BRACKET, token.TokenType_RIGHT_BRACE:
+			if len(open) > 0 {
+				p.pair[idx], p.pair[open[len(open)-1]] = open[len(open)-1], idx
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	return p
+}
+
+// at devolve o token a delta posições de t.
+func (p *printer) at(t *token.Token, delta int) *token.Token {
+	return p.tokens[p.index[t]+delta]
+}
+
+// match devolve o bracket que fecha ou abre t.
+func (p *printer) match(t *token.Token) *token.Token {
+	return p.tokens[p.pair[p.index[t]]]
+}
+
+// brace devolve o '{' que abre o corpo de uma função ou classe, o primeiro
+// depois do nome.
+func (p *printer) brace(name *token.Token) *token.Token {
+	for idx := p.index[name]; ; idx++ {
+		if p.tokens[idx].Type == token.TokenType_LEFT_BRACE {
+			return p.tokens[idx]
+		}
+	}
+}
+
+// first devolve o primeiro token do comando.
+func (p *printer) first(stmt ast.Stmt) *token.Token {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		return s.Brace
+	case *ast.ClassStmt:
+		return p.at(s.Name, -1)
+	case *ast.ExpressionStmt:
+		return p.firstExpr(s.Expression)
+	case *ast.FunctionStmt:
+		if before := p.at(s.Name, -1); before.Type == token.TokenType_FUNC {
+			return before
+		}
+		return s.Name
+	case *ast.IfStmt:
+		return s.Keyword
+	case *ast.PrintStmt:
+		return s.Keyword
+	case *ast.ReturnStmt:
+		return s.Keyword
+	case *ast.VarStmt:
+		return p.at(s.Name, -1)
+	case *ast.ForInStmt:
+		return s.Keyword
+	case *ast.BreakStmt:
+		return s.Keyword
+	case *ast.ContinueStmt:
+		return s.Keyword
+	case *ast.WithStmt:
+		return s.Keyword
+	case *ast.ImportStmt:
+		return s.Keyword
+	case *ast.ExportStmt:
+		return p.at(p.first(s.Declaration), -1)
+	}
+	panic(fmt.Sprintf("format: unexpected statement %T", stmt))
+}
+
+// firstExpr devolve o primeiro token da expressão.
+func (p *printer) firstExpr(expr ast.Expr) *token.Token {
+	switch e := expr.(type) {
+	case *ast.AssignExpr:
+		return e.Name
+	case *ast.BinaryExpr:
+		return p.firstExpr(e.Left)
+	case *ast.CallExpr:
+		return p.firstExpr(e.Callee)
+	case *ast.GetExpr:
+		return p.firstExpr(e.Object)
+	case *ast.GroupingExpr:
+		return p.at(p.firstExpr(e.Expression), -1)
+	case *ast.LiteralExpr:
+		return e.Token
+	case *ast.LogicalExpr:
+		return p.firstExpr(e.Left)
+	case *ast.SetExpr:
+		return p.firstExpr(e.Object)
+	case *ast.SuperExpr:
+		return e.Keyword
+	case *ast.SelfExpr:
+		return e.Keyword
+	case *ast.UnaryExpr:
+		return e.Operator
+	case *ast.VariableExpr:
+		return e.Name
+	case *ast.ListExpr:
+		return p.match(e.Bracket)
+	case *ast.IndexExpr:
+		return p.firstExpr(e.Object)
+	case *ast.SetIndexExpr:
+		return p.firstExpr(e.Object)
+	case *ast.DictExpr:
+		return e.Brace
+	case *ast.SafeExpr:
+		return e.Name
+	}
+	panic(fmt.Sprintf("format: unexpected expression %T", expr))
+}
+
+// lastExpr devolve o último token da expressão.
+func (p *printer) lastExpr(expr ast.Expr) *token.Token {
+	switch e := expr.(type) {
+	case *ast.AssignExpr:
+		return p.lastExpr(e.Value)
+	case *ast.BinaryExpr:
+		return p.lastExpr(e.Right)
+	case *ast.CallExpr:
+		return e.Parenthesis
+	case *ast.GetExpr:
+		return e.Name
+	case *ast.GroupingExpr:
+		return p.at(p.lastExpr(e.Expression), 1)
+	case *ast.LiteralExpr:
+		return e.Token
+	case *ast.LogicalExpr:
+		return p.lastExpr(e.Right)
+	case *ast.SetExpr:
+		return p.lastExpr(e.Value)
+	case *ast.SuperExpr:
+		return e.Method
+	case *ast.SelfExpr:
+		return e.Keyword
+	case *ast.UnaryExpr:
+		return p.lastExpr(e.Right)
+	case *ast.VariableExpr:
+		return e.Name
+	case *ast.ListExpr:
+		return e.Bracket
+	case *ast.IndexExpr:
+		return p.match(e.Bracket)
+	case *ast.SetIndexExpr:
+		return p.lastExpr(e.Value)
+	case *ast.DictExpr:
+		return p.match(e.Brace)
+	case *ast.SafeExpr:
+		return p.lastExpr(e.Expr)
+	}
+	panic(fmt.Sprintf("format: unexpected expression %T", expr))
+}
+
+// line começa uma nova linha para o que começa em t, depois dos comentários
+// que vêm antes dele. Uma linha em branco antes de t na fonte é mantida.
+func (p *printer) line(t *token.Token) {
+	p.flush(t.Offset)
+	p.newline(t.Line > p.lastLine+1)
+}
+
+func (p *printer) newline(blank bool) {
+	if p.started {
+		p.lines = append(p.lines, strings.TrimRight(p.current.String(), " "))
+		if blank && !p.afterOpen {
+			p.lines = append(p.lines, "")
+		}
+	}
+	p.current.Reset()
+	p.current.WriteString(strings.Repeat(indentStr, p.indent))
+	p.started = true
+	p.broken = false
+}
+
+// write escreve texto que não vem de um token da fonte.
+func (p *printer) write(text string) {
+	if p.broken {
+		p.indent++
+		p.newline(false)
+		p.indent--
+	}
+	if !p.started {
+		p.newline(false)
+	}
+	p.current.WriteString(text)
+	p.afterOpen = false
+}
+
+// token escreve t como está na fonte.
+func (p *printer) token(t *token.Token) {
+	p.write(t.Lexeme)
+	p.lastLine = t.Line + strings.Count(t.Lexeme, "\n")
+}
+
+// flush escreve os comentários que começam antes de offset. Um comentário na
+// linha do último token fica no fim dela; os outros ganham uma linha própria.
+func (p *printer) flush(offset int) {
+	for len(p.comments) > 0 && p.comments[0].Offset < offset {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		if p.started && c.Line == p.lastLine && !p.broken {
+			current := p.current.String()
+			hugs := strings.HasPrefix(c.Lexeme, "/*") && (strings.HasSuffix(current, "(") || strings.HasSuffix(current, "["))
+			if !strings.HasSuffix(current, " ") && !hugs {
+				p.current.WriteByte(' ')
+			}
+		} else {
+			p.newline(c.Line > p.lastLine+1)
+		}
+		if strings.HasPrefix(c.Lexeme, "//") {
+			p.current.WriteString(strings.TrimRight(c.Lexeme, " \t\r"))
+			p.broken = true
+		} else {
+			p.current.WriteString(c.Lexeme + " ")
+		}
+		p.afterOpen = false
+		p.lastLine = c.Line + strings.Count(c.Lexeme, "\n")
+	}
+}
+
+func (p *printer) finish() string {
+	if !p.started {
+		return ""
+	}
+	p.newline(false)
+	return strings.Join(p.lines, "\n") + "\n"
+}
+
+// stmt escreve um comando em uma linha nova.
+func (p *printer) stmt(stmt ast.Stmt) {
+	p.line(p.first(stmt))
+	p.stmtBody(stmt)
+}
+
+// stmtBody escreve um comando a partir da posição atual da linha.
+func (p *printer) stmtBody(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		p.block(s.Brace, s.Statements)
+	case *ast.ClassStmt:
+		p.write("class ")
+		p.token(s.Name)
+		if s.Superclass != nil {
+			p.write(" < ")
+			p.expr(s.Superclass)
+		}
+		p.write(" ")
+		methods := make([]ast.Stmt, len(s.Methods))
+		for i, method := range s.Methods {
+			methods[i] = method
+		}
+		p.block(p.brace(s.Name), methods)
+	case *ast.ExpressionStmt:
+		p.expr(s.Expression)
+		p.write(";")
+	case *ast.FunctionStmt:
+		if p.at(s.Name, -1).Type == token.TokenType_FUNC {
+			p.write("func ")
+		}
+		p.token(s.Name)
+		p.write("(")
+		for i, param := range s.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.token(param)
+		}
+		p.write(") ")
+		p.block(p.brace(s.Name), s.Body)
+	case *ast.IfStmt:
+		p.token(s.Keyword)
+		if _, ok := s.Then.(*ast.BlockStmt); ok {
+			p.write(" ")
+			p.expr(s.Condition)
+			p.write(" ")
+		} else {
+			p.write(" (")
+			p.expr(s.Condition)
+			p.write(") ")
+		}
+		p.stmtBody(s.Then)
+		if s.Else != nil {
+			p.write(" else ")
+			p.stmtBody(s.Else)
+		}
+	case *ast.PrintStmt:
+		p.token(s.Keyword)
+		p.write(" ")
+		for i, expr := range s.Expressions {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expr(expr)
+		}
+		p.write(";")
+	case *ast.ReturnStmt:
+		p.token(s.Keyword)
+		if s.Value != nil {
+			p.write(" ")
+			p.expr(s.Value)
+		}
+		p.write(";")
+	case *ast.VarStmt:
+		p.write("let ")
+		p.token(s.Name)
+		if s.Initializer != nil {
+			p.write(" = ")
+			p.expr(s.Initializer)
+		}
+		p.write(";")
+	case *ast.ForInStmt:
+		p.token(s.Keyword)
+		p.write(" ")
+		if s.ValueVar != nil {
+			if s.IndexVar != nil {
+				p.token(s.IndexVar)
+				p.write(", ")
+			}
+			p.token(s.ValueVar)
+			p.write(" in ")
+			p.expr(s.Iterable)
+			p.write(" ")
+		}
+		p.stmtBody(s.Body)
+	case *ast.BreakStmt:
+		p.token(s.Keyword)
+		p.write(";")
+	case *ast.ContinueStmt:
+		p.token(s.Keyword)
+		p.write(";")
+	case *ast.WithStmt:
+		p.token(s.Keyword)
+		p.write(" ")
+		p.expr(s.Resource)
+		p.write(" as ")
+		p.token(s.Alias)
+		p.write(" ")
+		p.stmtBody(s.Body)
+	case *ast.ImportStmt:
+		p.token(s.Keyword)
+		p.write(" ")
+		if s.Alias == nil && s.Names != nil {
+			p.write("{")
+			for i, name := range s.Names {
+				if i == 0 {
+					p.write(" ")
+				} else {
+					p.write(", ")
+				}
+				p.token(name.Name)
+				if name.Alias != nil {
+					p.write(" as ")
+					p.token(name.Alias)
+				}
+			}
+			if len(s.Names) > 0 {
+				p.write(" ")
+			}
+			p.write("} from ")
+		}
+		p.token(s.Path)
+		if s.Alias != nil {
+			p.write(" as ")
+			p.token(s.Alias)
+		}
+		p.write(";")
+	case *ast.ExportStmt:
+		p.write("export ")
+		p.stmtBody(s.Declaration)
+	default:
+		panic(fmt.Sprintf("format: unexpected statement %T", stmt))
+	}
+}
+
+// block escreve as chaves que começam em open e os comandos entre elas. Um
+// bloco que estava em uma linha, sem comentários, continua em uma linha.
+func (p *printer) block(open *token.Token, statements []ast.Stmt) {
+	close := p.match(open)
+	p.flush(open.Offset)
+	p.token(open)
+
+	if open.Line == close.Line && !p.hasComments(close.Offset) {
+		for _, stmt := range statements {
+			p.write(" ")
+			p.stmtBody(stmt)
+		}
+		if len(statements) > 0 {
+			p.write(" ")
+		}
+		p.token(close)
+		return
+	}
+
+	p.indent++
+	p.afterOpen = true
+	for _, stmt := range statements {
+		p.stmt(stmt)
+	}
+	p.flush(close.Offset)
+	p.indent--
+	p.newline(false)
+	p.token(close)
+}
+
+// hasComments diz se há comentários antes de offset.
+func (p *printer) hasComments(offset int) bool {
+	return len(p.comments) > 0 && p.comments[0].Offset < offset
+}
+
+// elements escreve os n elementos de uma coleção ou chamada entre open e
+// close. Quando o primeiro elemento está na linha seguinte à de open, cada
+// elemento ganha sua própria linha; pad é o espaço dentro dos brackets na
+// forma de uma linha.
+func (p *printer) elements(open, close *token.Token, n int, first func(i int) *token.Token, element func(i int), pad string) {
+	p.token(open)
+	if n == 0 {
+		p.token(close)
+		return
+	}
+
+	if first(0).Line == open.Line {
+		p.write(pad)
+		for i := range n {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.flush(first(i).Offset)
+			element(i)
+		}
+		p.write(pad)
+		p.token(close)
+		return
+	}
+
+	p.indent++
+	p.afterOpen = true
+	for i := range n {
+		p.line(first(i))
+		element(i)
+		if i < n-1 {
+			p.write(",")
+		}
+	}
+	p.flush(close.Offset)
+	p.indent--
+	p.newline(false)
+	p.token(close)
+}
+
+func (p *printer) expr(expr ast.Expr) {
+	p.flush(p.firstExpr(expr).Offset)
+
+	switch e := expr.(type) {
+	case *ast.AssignExpr:
+		p.token(e.Name)
+		p.write(" = ")
+		p.expr(e.Value)
+	case *ast.BinaryExpr:
+		p.binary(e.Left, e.Operator, e.Right)
+	case *ast.LogicalExpr:
+		p.binary(e.Left, e.Operator, e.Right)
+	case *ast.CallExpr:
+		p.expr(e.Callee)
+		p.elements(p.match(e.Parenthesis), e.Parenthesis, len(e.Arguments),
+			func(i int) *token.Token { return p.firstExpr(e.Arguments[i]) },
+			func(i int) { p.expr(e.Arguments[i]) }, "")
+	case *ast.GetExpr:
+		p.expr(e.Object)
+		p.member(e.Object, e.Name)
+	case *ast.SetExpr:
+		p.expr(e.Object)
+		p.member(e.Object, e.Name)
+		p.write(" = ")
+		p.expr(e.Value)
+	case *ast.GroupingExpr:
+		p.write("(")
+		p.expr(e.Expression)
+		p.write(")")
+	case *ast.LiteralExpr:
+		p.token(e.Token)
+	case *ast.SuperExpr:
+		p.token(e.Keyword)
+		p.write(".")
+		p.token(e.Method)
+	case *ast.SelfExpr:
+		p.token(e.Keyword)
+	case *ast.UnaryExpr:
+		p.token(e.Operator)
+		if e.Operator.Type == token.TokenType_NOT {
+			p.write(" ")
+		}
+		p.expr(e.Right)
+	case *ast.SafeExpr:
+		p.token(e.Name)
+		p.expr(e.Expr)
+	case *ast.VariableExpr:
+		p.token(e.Name)
+	case *ast.ListExpr:
+		p.elements(p.match(e.Bracket), e.Bracket, len(e.Elements),
+			func(i int) *token.Token { return p.firstExpr(e.Elements[i]) },
+			func(i int) { p.expr(e.Elements[i]) }, "")
+	case *ast.IndexExpr:
+		p.expr(e.Object)
+		p.write("[")
+		p.expr(e.Index)
+		p.write("]")
+	case *ast.SetIndexExpr:
+		p.expr(e.Object)
+		p.write("[")
+		p.expr(e.Index)
+		p.write("] = ")
+		p.expr(e.Value)
+	case *ast.DictExpr:
+		p.elements(e.Brace, p.match(e.Brace), len(e.Pairs),
+			func(i int) *token.Token { return p.firstExpr(e.Pairs[i].Key) },
+			func(i int) {
+				p.expr(e.Pairs[i].Key)
+				p.write(": ")
+				p.expr(e.Pairs[i].Value)
+			}, " ")
+	default:
+		panic(fmt.Sprintf("format: unexpected expression %T", expr))
+	}
+}
+
+// binary escreve uma operação binária. Se o autor quebrou a linha em volta
+// do operador, a quebra fica depois dele e o operando da direita ganha um
+// nível de indentação.
+func (p *printer) binary(left ast.Expr, operator *token.Token, right ast.Expr) {
+	p.expr(left)
+	p.write(" ")
+	p.token(operator)
+	if p.firstExpr(right).Line > operator.Line || operator.Line > p.lastExpr(left).Line {
+		p.indent++
+		p.flush(p.firstExpr(right).Offset)
+		p.newline(false)
+		p.expr(right)
+		p.indent--
+		return
+	}
+	p.write(" ")
+	p.expr(right)
+}
+
+// member escreve ".name". Se name estava em outra linha que o objeto, como
+// em cadeias de métodos, a quebra fica antes do '.'.
+func (p *printer) member(object ast.Expr, name *token.Token) {
+	if name.Line > p.lastExpr(object).Line {
+		p.indent++
+		p.flush(name.Offset)
+		p.newline(false)
+		p.indent--
+	}
+	p.write(".")
+	p.token(name)
+}
@@ -0,0 +1,140 @@
+package format
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MichelLacerda/nox/internal/parser"
+	"github.com/MichelLacerda/nox/internal/util"
+)
+
+var update = flag.Bool("update", false, "rewrite the .golden files in testdata")
+
+// Cada testdata/<nome>.input formatado deve ser igual a <nome>.golden.
+// "go test ./internal/format -update" regrava os .golden.
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata/*.input files")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input")
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Source(input, string(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(input, ".input") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("formatted output differs from %s:\n%s", golden, Diff(golden, string(want), got))
+			}
+		})
+	}
+}
+
+// Formatar uma fonte já formatada não muda nada.
+func TestIdempotent(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			once, err := Source(file, string(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			twice, err := Source(file, once)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if once != twice {
+				t.Errorf("formatting is not idempotent:\n%s", Diff(file, once, twice))
+			}
+		})
+	}
+}
+
+// Fontes com erros não são formatadas: Source devolve os diagnósticos.
+func TestSyntaxErrors(t *testing.T) {
+	for _, source := range []string{
+		"let d = {\"a\": };\n",
+		"print (1 + 2;\n",
+		"let l = [1, 2;\n",
+		"print l[0;\n",
+		"let d = {\"a\" 1};\n",
+		"let config = {\"name\": ",
+	} {
+		formatted, err := Source("test.nox", source)
+		if err == nil || formatted != "" {
+			t.Errorf("Source(%q) = %q, %v; want an error", source, formatted, err)
+		}
+	}
+}
+
+// Formatar os exemplos não muda o programa nem perde comentários: a fonte
+// formatada tem o mesmo AST e os mesmos comentários, na mesma ordem.
+func TestPreservesProgram(t *testing.T) {
+	files, err := util.NoxFiles([]string{filepath.Join("..", "..", "examples")}, ".nox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted, err := Source(file, string(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if before, after := program(t, string(source)), program(t, formatted); before != after {
+				t.Errorf("formatting changed the program:\n%s", Diff(file, before, after))
+			}
+		})
+	}
+}
+
+// program descreve o AST e os comentários de uma fonte.
+func program(t *testing.T, source string) string {
+	t.Helper()
+	p := newPrinter(source)
+	statements, err := parser.NewParser(p.tokens).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, stmt := range statements {
+		b.WriteString(stmt.String() + "\n")
+	}
+	for _, comment := range p.comments {
+		b.WriteString(comment.Lexeme + "\n")
+	}
+	return b.String()
+}
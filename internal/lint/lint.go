@@ -0,0 +1,587 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+	"github.com/MichelLacerda/nox/internal/runtime"
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// Códigos das regras, exibidos entre colchetes e no campo "code" do JSON.
+const (
+	CodeUnusedVariable = "unused-variable"
+	CodeUnusedImport   = "unused-import"
+	CodeShadow         = "shadow"
+	CodeUnreachable    = "unreachable"
+	CodeArgCount       = "arg-count"
+	CodeUnknownMember  = "unknown-member"
+	CodeSelfOutside    = "self-outside-method"
+	CodeReturnOutside  = "return-outside-function"
+	CodeLoopControl    = "loop-control-outside-loop"
+	CodeResolve        = "resolve"
+)
+
+type SymbolKind int
+
+const (
+	SymbolVariable SymbolKind = iota
+	SymbolParameter
+	SymbolLoopVariable
+	SymbolFunction
+	SymbolClass
+	SymbolMethod
+	SymbolImport
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case SymbolParameter:
+		return "parameter"
+	case SymbolLoopVariable:
+		return "loop variable"
+	case SymbolFunction:
+		return "function"
+	case SymbolClass:
+		return "class"
+	case SymbolMethod:
+		return "method"
+	case SymbolImport:
+		return "import"
+	}
+	return "variable"
+}
+
+// Symbol é uma declaração encontrada durante a análise, com todos os
+// pontos do arquivo que se referem a ela.
+type Symbol struct {
+	Name      *token.Token
+	Kind      SymbolKind
+	Detail    string         // assinatura legível, ex: "func add(a, b)"
+	Container string         // classe que contém um método
+	Global    bool           // declarado no topo do arquivo
+	Exported  bool           // declarado com "export"
+	Import    string         // caminho do módulo, para SymbolImport
+	Member    string         // nome importado com "import { nome } from", para SymbolImport
+	Refs      []*token.Token // usos do nome (sem a declaração)
+
+	arity int // -1 quando desconhecida
+	used  bool
+}
+
+type scope struct {
+	bindings map[string]*Symbol
+	order    []*Symbol
+}
+
+func newScope() *scope {
+	return &scope{bindings: map[string]*Symbol{}}
+}
+
+// Analysis reúne o resultado da análise de um arquivo, para o lint e para
+// ferramentas de editor.
+type Analysis struct {
+	Statements  []ast.Stmt
+	Diagnostics diagnostic.List
+	Symbols     []*Symbol
+	Imports     []*ast.ImportStmt
+}
+
+// Linter percorre a AST de um arquivo acompanhando os escopos, de forma
+// semelhante ao Resolver, e acumula avisos.
+type Linter struct {
+	file      string
+	builtins  map[string]any
+	scopes    []*scope
+	diags     diagnostic.List
+	symbols   []*Symbol
+	imports   []*ast.ImportStmt
+	exporting bool // dentro de um "export", as declarações não são reportadas como não usadas
+}
+
+// Builtins devolve as funções e módulos builtin registrados pelo
+// interpretador.
+func Builtins() map[string]any {
+	return runtime.NewInterpreter(runtime.NewNox(), false).Builtins()
+}
+
+// Source analisa uma fonte e devolve todos os diagnósticos: erros de
+// sintaxe, erros do resolver e avisos das regras de lint.
+func Source(file, source string) diagnostic.List {
+	return Analyze(file, source).Diagnostics
+}
+
+// Analyze analisa uma fonte e devolve diagnósticos e símbolos. Com erros de
+// sintaxe, o resolver não roda e a análise de escopo é feita sobre as
+// declarações que puderam ser lidas, mas só os erros de sintaxe são
+// reportados.
+func Analyze(file, source string) *Analysis {
+	statements, syntaxErrors := runtime.ParseSource(file, source)
+
+	interpreter := runtime.NewInterpreter(runtime.NewNox(), false)
+	l := &Linter{file: file, builtins: interpreter.Builtins()}
+	if len(syntaxErrors) == 0 {
+		resolver := runtime.NewResolver(interpreter)
+		resolver.ResolveStatements(statements)
+		for _, err := range resolver.Errors {
+			l.report(err.Token, diagnostic.SeverityError, resolverCode(err.Message), err.Message)
+		}
+	}
+
+	l.beginScope()
+	l.hoist(statements)
+	l.block(statements)
+	l.endScope()
+
+	diags := l.diags
+	if len(syntaxErrors) > 0 {
+		diags = syntaxErrors
+	}
+	diags.Sort()
+
+	return &Analysis{
+		Statements:  statements,
+		Diagnostics: diags,
+		Symbols:     l.symbols,
+		Imports:     l.imports,
+	}
+}
+
+// File lê e analisa um arquivo.
+func File(path string) (diagnostic.List, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Source(path, string(source)), nil
+}
+
+func resolverCode(message string) string {
+	switch {
+	case strings.Contains(message, "'self'"):
+		return CodeSelfOutside
+	case strings.Contains(message, "return from top-level"):
+		return CodeReturnOutside
+	case strings.Contains(message, "'break'"), strings.Contains(message, "'continue'"):
+		return CodeLoopControl
+	}
+	return CodeResolve
+}
+
+func (l *Linter) report(t *token.Token, severity, code, message string) {
+	d := diagnostic.Diagnostic{
+		File:     l.file,
+		Severity: severity,
+		Code:     code,
+		Message:  message,
+		Length:   1,
+	}
+	if t != nil {
+		d.Line = t.Line
+		d.Column = t.Column
+		d.Length = max(utf8.RuneCountInString(t.Lexeme), 1)
+	}
+	l.diags = append(l.diags, d)
+}
+
+func (l *Linter) warn(t *token.Token, code, format string, args ...any) {
+	l.report(t, diagnostic.SeverityWarning, code, fmt.Sprintf(format, args...))
+}
+
+// ===== Escopos =====
+
+func (l *Linter) beginScope() {
+	l.scopes = append(l.scopes, newScope())
+}
+
+func (l *Linter) endScope() {
+	current := l.scopes[len(l.scopes)-1]
+	l.scopes = l.scopes[:len(l.scopes)-1]
+
+	for _, b := range current.order {
+		if b.used || b.Exported || strings.HasPrefix(b.Name.Lexeme, "_") {
+			continue
+		}
+		switch b.Kind {
+		case SymbolVariable:
+			l.warn(b.Name, CodeUnusedVariable, "variable '%s' is declared but never used", b.Name.Lexeme)
+		case SymbolImport:
+			l.warn(b.Name, CodeUnusedImport, "import '%s' is never used", b.Name.Lexeme)
+		}
+	}
+}
+
+// hoist declara antecipadamente os nomes de topo, que podem ser usados por
+// funções declaradas antes deles.
+func (l *Linter) hoist(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		exported := false
+		if export, ok := stmt.(*ast.ExportStmt); ok {
+			stmt = export.Declaration
+			exported = true
+		}
+		var b *Symbol
+		if s, ok := stmt.(*ast.ImportStmt); ok && s.Names != nil {
+			l.imports = append(l.imports, s)
+			for _, name := range s.Names {
+				l.add(importedName(s, name))
+			}
+			continue
+		}
+		switch s := stmt.(type) {
+		case *ast.FunctionStmt:
+			b = &Symbol{Name: s.Name, Kind: SymbolFunction, arity: len(s.Parameters), used: true}
+		case *ast.ClassStmt:
+			b = &Symbol{Name: s.Name, Kind: SymbolClass, arity: classArity(s), used: true}
+		case *ast.VarStmt:
+			b = &Symbol{Name: s.Name, Kind: SymbolVariable, arity: -1}
+		case *ast.ImportStmt:
+			l.imports = append(l.imports, s)
+			if s.Alias != nil {
+				b = &Symbol{Name: s.Alias, Kind: SymbolImport, arity: -1, Import: importPath(s)}
+			}
+		}
+		if b != nil {
+			if _, ok := l.builtins[b.Name.Lexeme]; ok {
+				l.warn(b.Name, CodeShadow, "declaration of '%s' shadows a builtin", b.Name.Lexeme)
+			}
+			b.Exported = exported
+			b.Global = true
+			b.Detail = describe(stmt)
+			l.add(b)
+		}
+	}
+}
+
+func (l *Linter) isGlobalScope() bool {
+	return len(l.scopes) == 1
+}
+
+// declare registra um nome no escopo atual, avisando quando ele esconde
+// uma declaração de um escopo externo ou um builtin.
+func (l *Linter) declare(name *token.Token, kind SymbolKind, arity int) *Symbol {
+	if name == nil {
+		return nil
+	}
+	if l.isGlobalScope() {
+		// Já registrado por hoist; só atualiza a aridade.
+		if b, ok := l.scopes[0].bindings[name.Lexeme]; ok {
+			if arity >= 0 {
+				b.arity = arity
+			}
+			return b
+		}
+	}
+
+	if name.Lexeme != "_" {
+		if outer := l.lookupOuter(name.Lexeme); outer != nil {
+			l.warn(name, CodeShadow, "declaration of '%s' shadows the one at line %d", name.Lexeme, outer.Name.Line)
+		} else if _, ok := l.builtins[name.Lexeme]; ok && kind != SymbolParameter && kind != SymbolLoopVariable {
+			l.warn(name, CodeShadow, "declaration of '%s' shadows a builtin", name.Lexeme)
+		}
+	}
+
+	b := &Symbol{Name: name, Kind: kind, arity: arity}
+	b.Exported = l.exporting
+	l.add(b)
+	return b
+}
+
+// reference registra um uso do nome, devolvendo o símbolo correspondente.
+func (l *Linter) reference(name *token.Token) *Symbol {
+	b := l.lookup(name.Lexeme)
+	if b != nil {
+		b.Refs = append(b.Refs, name)
+	}
+	return b
+}
+
+func (l *Linter) add(b *Symbol) {
+	current := l.scopes[len(l.scopes)-1]
+	if _, exists := current.bindings[b.Name.Lexeme]; exists {
+		return // redeclaração já é reportada pelo resolver
+	}
+	current.bindings[b.Name.Lexeme] = b
+	current.order = append(current.order, b)
+	l.symbols = append(l.symbols, b)
+}
+
+// lookupOuter procura o nome nos escopos que envolvem o atual.
+func (l *Linter) lookupOuter(name string) *Symbol {
+	for idx := len(l.scopes) - 2; idx >= 0; idx-- {
+		if b, ok := l.scopes[idx].bindings[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+func (l *Linter) lookup(name string) *Symbol {
+	for idx := len(l.scopes) - 1; idx >= 0; idx-- {
+		if b, ok := l.scopes[idx].bindings[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// builtinModule devolve o módulo builtin referenciado por expr, se o nome
+// não tiver sido redeclarado pelo programa.
+func (l *Linter) builtinModule(expr ast.Expr) (string, *runtime.MapInstance) {
+	variable, ok := expr.(*ast.VariableExpr)
+	if !ok || l.lookup(variable.Name.Lexeme) != nil {
+		return "", nil
+	}
+	module, _ := l.builtins[variable.Name.Lexeme].(*runtime.MapInstance)
+	return variable.Name.Lexeme, module
+}
+
+// ===== Percurso =====
+
+func (l *Linter) block(statements []ast.Stmt) {
+	reported := false
+	terminated := false
+	for _, stmt := range statements {
+		if terminated && !reported {
+			l.warn(stmtToken(stmt), CodeUnreachable, "unreachable code")
+			reported = true
+		}
+		l.stmt(stmt)
+		if terminates(stmt) {
+			terminated = true
+		}
+	}
+}
+
+func (l *Linter) stmt(stmt ast.Stmt) {
+	if stmt != nil {
+		stmt.Accept(l)
+	}
+}
+
+func (l *Linter) expr(expr ast.Expr) {
+	if expr != nil {
+		expr.Accept(l)
+	}
+}
+
+func (l *Linter) function(stmt *ast.FunctionStmt) {
+	l.beginScope()
+	for _, param := range stmt.Parameters {
+		if b := l.declare(param, SymbolParameter, -1); b != nil {
+			b.Detail = "(parameter) " + param.Lexeme
+		}
+	}
+	l.block(stmt.Body)
+	l.endScope()
+}
+
+func (l *Linter) checkArity(callee string, site *token.Token, expected, got int) {
+	if expected >= 0 && expected != got {
+		l.warn(site, CodeArgCount, "'%s' expects %d argument(s) but got %d", callee, expected, got)
+	}
+}
+
+// importedName cria o símbolo de um nome de "import { nome as alias } from".
+func importedName(stmt *ast.ImportStmt, name *ast.ImportName) *Symbol {
+	detail := fmt.Sprintf("import { %s } from %q", name.Name.Lexeme, importPath(stmt))
+	if name.Alias != nil {
+		detail = fmt.Sprintf("import { %s as %s } from %q", name.Name.Lexeme, name.Alias.Lexeme, importPath(stmt))
+	}
+	return &Symbol{
+		Name:   name.Binding(),
+		Kind:   SymbolImport,
+		Detail: detail,
+		Global: true,
+		Import: importPath(stmt),
+		Member: name.Name.Lexeme,
+		arity:  -1,
+	}
+}
+
+func importPath(stmt *ast.ImportStmt) string {
+	path, _ := stmt.Path.Literal.(string)
+	return path
+}
+
+func parameterList(params []*token.Token) string {
+	names := make([]string, len(params))
+	for idx, param := range params {
+		names[idx] = param.Lexeme
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+// describe monta a assinatura exibida para uma declaração.
+func describe(stmt ast.Stmt) string {
+	switch s := stmt.(type) {
+	case *ast.FunctionStmt:
+		return "func " + s.Name.Lexeme + parameterList(s.Parameters)
+	case *ast.ClassStmt:
+		for _, method := range s.Methods {
+			if method.Name.Lexeme == "init" {
+				return "class " + s.Name.Lexeme + parameterList(method.Parameters)
+			}
+		}
+		return "class " + s.Name.Lexeme + "()"
+	case *ast.VarStmt:
+		return "let " + s.Name.Lexeme
+	case *ast.ImportStmt:
+		if s.Alias != nil {
+			return fmt.Sprintf("import %q as %s", importPath(s), s.Alias.Lexeme)
+		}
+		if s.Names != nil {
+			return strings.TrimSuffix(s.String(), ";")
+		}
+		return fmt.Sprintf("import %q", importPath(s))
+	case *ast.ExportStmt:
+		return "export " + describe(s.Declaration)
+	}
+	return ""
+}
+
+func classArity(stmt *ast.ClassStmt) int {
+	for _, method := range stmt.Methods {
+		if method.Name.Lexeme == "init" {
+			return len(method.Parameters)
+		}
+	}
+	return 0
+}
+
+// terminates indica se, após executar stmt, o fluxo nunca segue para a
+// próxima instrução do mesmo bloco.
+func terminates(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt, *ast.BreakStmt, *ast.ContinueStmt:
+		return true
+	case *ast.BlockStmt:
+		return len(s.Statements) > 0 && terminates(s.Statements[len(s.Statements)-1])
+	case *ast.IfStmt:
+		return s.Else != nil && terminates(s.Then) && terminates(s.Else)
+	}
+	return false
+}
+
+// stmtToken devolve um token representativo da posição de stmt.
+func stmtToken(stmt ast.Stmt) *token.Token {
+	switch s := stmt.(type) {
+	case *ast.VarStmt:
+		return s.Name
+	case *ast.FunctionStmt:
+		return s.Name
+	case *ast.ClassStmt:
+		return s.Name
+	case *ast.ReturnStmt:
+		return s.Keyword
+	case *ast.BreakStmt:
+		return s.Keyword
+	case *ast.ContinueStmt:
+		return s.Keyword
+	case *ast.ExpressionStmt:
+		return exprToken(s.Expression)
+	case *ast.PrintStmt:
+		return s.Keyword
+	case *ast.IfStmt:
+		return exprToken(s.Condition)
+	case *ast.ForInStmt:
+		if s.ValueVar != nil {
+			return s.ValueVar
+		}
+		return stmtToken(s.Body)
+	case *ast.WithStmt:
+		return s.Alias
+	case *ast.BlockStmt:
+		if len(s.Statements) > 0 {
+			return stmtToken(s.Statements[0])
+		}
+	case *ast.ImportStmt:
+		return s.Path
+	case *ast.ExportStmt:
+		return stmtToken(s.Declaration)
+	}
+	return nil
+}
+
+func exprToken(expr ast.Expr) *token.Token {
+	switch e := expr.(type) {
+	case *ast.VariableExpr:
+		return e.Name
+	case *ast.AssignExpr:
+		return e.Name
+	case *ast.BinaryExpr:
+		return exprToken(e.Left)
+	case *ast.LogicalExpr:
+		return exprToken(e.Left)
+	case *ast.CallExpr:
+		return exprToken(e.Callee)
+	case *ast.GetExpr:
+		return exprToken(e.Object)
+	case *ast.SetExpr:
+		return exprToken(e.Object)
+	case *ast.GroupingExpr:
+		return exprToken(e.Expression)
+	case *ast.SuperExpr:
+		return e.Keyword
+	case *ast.SelfExpr:
+		return e.Keyword
+	case *ast.UnaryExpr:
+		return e.Operator
+	case *ast.ListExpr:
+		return e.Bracket
+	case *ast.IndexExpr:
+		return exprToken(e.Object)
+	case *ast.SetIndexExpr:
+		return exprToken(e.Object)
+	case *ast.DictExpr:
+		if e.Brace != nil {
+			return e.Brace
+		}
+		if len(e.Pairs) > 0 {
+			return exprToken(e.Pairs[0].Key)
+		}
+	case *ast.SafeExpr:
+		return exprToken(e.Expr)
+	}
+	return nil
+}
+
+// closest devolve o nome mais parecido com name entre candidates, se a
+// distância de edição for pequena o bastante para ser um erro de digitação.
+func closest(name string, candidates map[string]any) string {
+	best, bestDist := "", 3
+	for candidate := range candidates {
+		if d := editDistance(name, candidate); d < bestDist || (d == bestDist && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+	if bestDist > 2 {
+		return ""
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,213 @@
+package lint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+)
+
+// has diz se diags tem um diagnóstico com code na linha line.
+func has(diags diagnostic.List, code string, line int) bool {
+	for _, d := range diags {
+		if d.Code == code && d.Line == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		source string
+		line   int // 0: a regra não pode aparecer em nenhuma linha
+	}{
+		{"unused local", CodeUnusedVariable, `func f() {
+    let x = 1;
+}
+f();`, 2},
+		{"used local", CodeUnusedVariable, `func f() {
+    let x = 1;
+    return x;
+}
+f();`, 0},
+		{"underscore local", CodeUnusedVariable, `func f() {
+    let _x = 1;
+}
+f();`, 0},
+		{"exported global", CodeUnusedVariable, `export let x = 1;`, 0},
+
+		{"unused import", CodeUnusedImport, `import "lib.nox" as lib;`, 1},
+		{"used import", CodeUnusedImport, `import "lib.nox" as lib;
+print lib.value;`, 0},
+
+		{"shadowed global", CodeShadow, `let x = 1;
+func f() {
+    let x = 2;
+    return x;
+}
+print x;
+f();`, 3},
+		{"shadowed builtin", CodeShadow, `let len = 1;
+print len;`, 1},
+		{"parameter named like a builtin", CodeShadow, `func f(time) {
+    return time;
+}
+f(1);`, 0},
+
+		{"code after return", CodeUnreachable, `func f() {
+    return 1;
+    print 2;
+}
+f();`, 3},
+		{"return in one branch", CodeUnreachable, `func f(a) {
+    if a {
+        return 1;
+    }
+    return 2;
+}
+f(true);`, 0},
+
+		{"wrong arity", CodeArgCount, `func f(a) {
+    return a;
+}
+f(1, 2);`, 4},
+		{"wrong builtin arity", CodeArgCount, `print len("a", "b");`, 1},
+		{"wrong module function arity", CodeArgCount, `print math.sqrt(1, 2);`, 1},
+		{"right arity", CodeArgCount, `func f(a) {
+    return a;
+}
+f(1);
+print len("a");`, 0},
+
+		{"unknown module member", CodeUnknownMember, `print math.sqr(4);`, 1},
+		{"known module member", CodeUnknownMember, `print math.sqrt(4);`, 0},
+		{"member of a redeclared module name", CodeUnknownMember, `let math = {"sqr": 1};
+print math.sqr;`, 0},
+
+		{"self outside a method", CodeSelfOutside, `print self;`, 1},
+		{"self in a method", CodeSelfOutside, `class A {
+    get() {
+        return self;
+    }
+}
+print A();`, 0},
+
+		{"return at the top level", CodeReturnOutside, `return 1;`, 1},
+		{"return in a function", CodeReturnOutside, `func f() {
+    return 1;
+}
+f();`, 0},
+
+		{"break outside a loop", CodeLoopControl, `break;`, 1},
+		{"continue in a loop", CodeLoopControl, `for i in range(2) {
+    continue;
+}`, 0},
+
+		{"redeclaration", CodeResolve, `func f() {
+    let a = 1;
+    let a = 2;
+    return a;
+}
+f();`, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := Source("test.nox", tt.source)
+			if tt.line > 0 && !has(diags, tt.code, tt.line) {
+				t.Errorf("want %s at line %d, got:\n%v", tt.code, tt.line, diags)
+			}
+			if tt.line == 0 {
+				for _, d := range diags {
+					if d.Code == tt.code {
+						t.Errorf("unexpected %s: %v", tt.code, d)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownMemberSuggestion(t *testing.T) {
+	diags := Source("test.nox", `print math.sqr(4);`)
+	if len(diags) != 1 || diags[0].Message != "module 'math' has no member 'sqr'; did you mean 'sqrt'?" {
+		t.Errorf("diagnostics = %v", diags)
+	}
+}
+
+// Os diagnósticos são ordenados por posição, e com erros de sintaxe só eles
+// são reportados.
+func TestOrderAndSyntaxErrors(t *testing.T) {
+	diags := Source("test.nox", `print math.sqr(4);
+func f() {
+    let x = 1;
+}
+f();`)
+	if len(diags) != 2 || diags[0].Line != 1 || diags[1].Line != 3 {
+		t.Errorf("diagnostics = %v, want line 1 then line 3", diags)
+	}
+
+	diags = Source("test.nox", "let x = ;\nfunc f() {\n    let y = 1;\n}\n")
+	for _, d := range diags {
+		if d.Severity == diagnostic.SeverityWarning || d.Code != "" {
+			t.Errorf("lint warning reported with a syntax error: %v", d)
+		}
+	}
+	if len(diags) == 0 {
+		t.Error("the syntax error was not reported")
+	}
+}
+
+func TestJSONShape(t *testing.T) {
+	diags := Source("test.nox", `func f() {
+    let x = 1;
+}
+f();`)
+	out, err := json.Marshal(diags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"file":     "test.nox",
+		"line":     float64(2),
+		"column":   float64(9),
+		"length":   float64(1),
+		"severity": diagnostic.SeverityWarning,
+		"code":     CodeUnusedVariable,
+		"message":  "variable 'x' is declared but never used",
+	}
+	if len(got) != 1 || len(got[0]) != len(want) {
+		t.Fatalf("json = %s", out)
+	}
+	for key, value := range want {
+		if got[0][key] != value {
+			t.Errorf("%s = %v, want %v", key, got[0][key], value)
+		}
+	}
+
+	// erros de sintaxe e do scanner também saem com a severidade preenchida
+	for _, source := range []string{"print (1;", "print @;"} {
+		out, err := json.Marshal(Source("test.nox", source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []map[string]any
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) == 0 {
+			t.Fatalf("%s: no diagnostics", source)
+		}
+		for _, d := range got {
+			if d["severity"] != diagnostic.SeverityError {
+				t.Errorf("%s: severity = %q, want %q", source, d["severity"], diagnostic.SeverityError)
+			}
+		}
+	}
+}
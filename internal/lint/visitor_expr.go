@@ -0,0 +1,134 @@
+package lint
+
+import (
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/runtime"
+)
+
+func (l *Linter) VisitLiteralExpr(expr *ast.LiteralExpr) any {
+	return nil
+}
+
+func (l *Linter) VisitGroupingExpr(expr *ast.GroupingExpr) any {
+	l.expr(expr.Expression)
+	return nil
+}
+
+func (l *Linter) VisitUnaryExpr(expr *ast.UnaryExpr) any {
+	l.expr(expr.Right)
+	return nil
+}
+
+func (l *Linter) VisitBinaryExpr(expr *ast.BinaryExpr) any {
+	l.expr(expr.Left)
+	l.expr(expr.Right)
+	return nil
+}
+
+func (l *Linter) VisitVariableExpr(expr *ast.VariableExpr) any {
+	if b := l.reference(expr.Name); b != nil {
+		b.used = true
+	}
+	return nil
+}
+
+func (l *Linter) VisitAssignExpr(expr *ast.AssignExpr) any {
+	// Atribuir não conta como uso da variável
+	l.expr(expr.Value)
+	l.reference(expr.Name)
+	return nil
+}
+
+func (l *Linter) VisitCallExpr(expr *ast.CallExpr) any {
+	l.expr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		l.expr(argument)
+	}
+
+	switch callee := expr.Callee.(type) {
+	case *ast.VariableExpr:
+		name := callee.Name.Lexeme
+		if b := l.lookup(name); b != nil {
+			if b.Kind == SymbolFunction || b.Kind == SymbolClass {
+				l.checkArity(name, callee.Name, b.arity, len(expr.Arguments))
+			}
+		} else if fn, ok := l.builtins[name].(runtime.Callable); ok {
+			l.checkArity(name, callee.Name, fn.Arity(), len(expr.Arguments))
+		}
+	case *ast.GetExpr:
+		if moduleName, module := l.builtinModule(callee.Object); module != nil {
+			if fn, ok := module.Entries[callee.Name.Lexeme].(runtime.Callable); ok {
+				l.checkArity(moduleName+"."+callee.Name.Lexeme, callee.Name, fn.Arity(), len(expr.Arguments))
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Linter) VisitGetExpr(expr *ast.GetExpr) any {
+	l.expr(expr.Object)
+
+	if moduleName, module := l.builtinModule(expr.Object); module != nil {
+		if _, ok := module.Entries[expr.Name.Lexeme]; !ok {
+			if suggestion := closest(expr.Name.Lexeme, module.Entries); suggestion != "" {
+				l.warn(expr.Name, CodeUnknownMember, "module '%s' has no member '%s'; did you mean '%s'?", moduleName, expr.Name.Lexeme, suggestion)
+			} else {
+				l.warn(expr.Name, CodeUnknownMember, "module '%s' has no member '%s'", moduleName, expr.Name.Lexeme)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Linter) VisitSetExpr(expr *ast.SetExpr) any {
+	l.expr(expr.Object)
+	l.expr(expr.Value)
+	return nil
+}
+
+func (l *Linter) VisitLogicalExpr(expr *ast.LogicalExpr) any {
+	l.expr(expr.Left)
+	l.expr(expr.Right)
+	return nil
+}
+
+func (l *Linter) VisitSuperExpr(expr *ast.SuperExpr) any {
+	return nil
+}
+
+func (l *Linter) VisitSelfExpr(expr *ast.SelfExpr) any {
+	return nil
+}
+
+func (l *Linter) VisitListExpr(expr *ast.ListExpr) any {
+	for _, element := range expr.Elements {
+		l.expr(element)
+	}
+	return nil
+}
+
+func (l *Linter) VisitIndexExpr(expr *ast.IndexExpr) any {
+	l.expr(expr.Object)
+	l.expr(expr.Index)
+	return nil
+}
+
+func (l *Linter) VisitSetIndexExpr(expr *ast.SetIndexExpr) any {
+	l.expr(expr.Object)
+	l.expr(expr.Index)
+	l.expr(expr.Value)
+	return nil
+}
+
+func (l *Linter) VisitDictExpr(expr *ast.DictExpr) any {
+	for _, pair := range expr.Pairs {
+		l.expr(pair.Key)
+		l.expr(pair.Value)
+	}
+	return nil
+}
+
+func (l *Linter) VisitSafeExpr(expr *ast.SafeExpr) any {
+	l.expr(expr.Expr)
+	return nil
+}
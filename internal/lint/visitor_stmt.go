@@ -0,0 +1,146 @@
+package lint
+
+import (
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+func (l *Linter) VisitBlockStmt(stmt *ast.BlockStmt) any {
+	l.beginScope()
+	l.block(stmt.Statements)
+	l.endScope()
+	return nil
+}
+
+func (l *Linter) VisitClassStmt(stmt *ast.ClassStmt) any {
+	if b := l.declare(stmt.Name, SymbolClass, classArity(stmt)); b != nil {
+		b.Detail = describe(stmt)
+	}
+	l.expr(stmt.Superclass)
+
+	for _, method := range stmt.Methods {
+		// Métodos não entram no escopo, mas aparecem como símbolos
+		l.symbols = append(l.symbols, &Symbol{
+			Name:      method.Name,
+			Kind:      SymbolMethod,
+			Detail:    stmt.Name.Lexeme + "." + method.Name.Lexeme + parameterList(method.Parameters),
+			Container: stmt.Name.Lexeme,
+			arity:     len(method.Parameters),
+		})
+		l.function(method)
+	}
+	return nil
+}
+
+func (l *Linter) VisitExpressionStmt(stmt *ast.ExpressionStmt) any {
+	l.expr(stmt.Expression)
+	return nil
+}
+
+func (l *Linter) VisitFunctionStmt(stmt *ast.FunctionStmt) any {
+	b := l.declare(stmt.Name, SymbolFunction, len(stmt.Parameters))
+	if b != nil {
+		// Funções locais declaradas e nunca chamadas não são reportadas
+		b.used = true
+		b.Detail = describe(stmt)
+	}
+	exporting := l.exporting
+	l.exporting = false
+	l.function(stmt)
+	l.exporting = exporting
+	return nil
+}
+
+func (l *Linter) VisitIfStmt(stmt *ast.IfStmt) any {
+	l.expr(stmt.Condition)
+	l.stmt(stmt.Then)
+	l.stmt(stmt.Else)
+	return nil
+}
+
+func (l *Linter) VisitPrintStmt(stmt *ast.PrintStmt) any {
+	for _, expr := range stmt.Expressions {
+		l.expr(expr)
+	}
+	return nil
+}
+
+func (l *Linter) VisitReturnStmt(stmt *ast.ReturnStmt) any {
+	l.expr(stmt.Value)
+	return nil
+}
+
+func (l *Linter) VisitVarStmt(stmt *ast.VarStmt) any {
+	// O inicializador é avaliado antes de o nome existir
+	l.expr(stmt.Initializer)
+	if b := l.declare(stmt.Name, SymbolVariable, -1); b != nil {
+		b.Detail = describe(stmt)
+	}
+	return nil
+}
+
+func (l *Linter) VisitForInStmt(stmt *ast.ForInStmt) any {
+	l.expr(stmt.Iterable)
+
+	l.beginScope()
+	for _, variable := range []*token.Token{stmt.IndexVar, stmt.ValueVar} {
+		if b := l.declare(variable, SymbolLoopVariable, -1); b != nil {
+			b.Detail = "(loop variable) " + variable.Lexeme
+		}
+	}
+	l.stmt(stmt.Body)
+	l.endScope()
+	return nil
+}
+
+func (l *Linter) VisitBreakStmt(stmt *ast.BreakStmt) any {
+	return nil
+}
+
+func (l *Linter) VisitContinueStmt(stmt *ast.ContinueStmt) any {
+	return nil
+}
+
+func (l *Linter) VisitWithStmt(stmt *ast.WithStmt) any {
+	l.expr(stmt.Resource)
+
+	l.beginScope()
+	if b := l.declare(stmt.Alias, SymbolVariable, -1); b != nil {
+		b.used = true // o recurso é fechado ao final, mesmo sem uso explícito
+		b.Detail = "with ... as " + stmt.Alias.Lexeme
+	}
+	l.stmt(stmt.Body)
+	l.endScope()
+	return nil
+}
+
+func (l *Linter) VisitImportStmt(stmt *ast.ImportStmt) any {
+	if l.isGlobalScope() {
+		return nil // declarado por hoist
+	}
+	if stmt.Alias != nil {
+		l.imports = append(l.imports, stmt)
+		if b := l.declare(stmt.Alias, SymbolImport, -1); b != nil {
+			b.Import = importPath(stmt)
+			b.Detail = describe(stmt)
+		}
+	}
+	if stmt.Names != nil {
+		l.imports = append(l.imports, stmt)
+		for _, name := range stmt.Names {
+			if b := l.declare(name.Binding(), SymbolImport, -1); b != nil {
+				imported := importedName(stmt, name)
+				b.Import, b.Member, b.Detail = imported.Import, imported.Member, imported.Detail
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Linter) VisitExportStmt(stmt *ast.ExportStmt) any {
+	exporting := l.exporting
+	l.exporting = true
+	l.stmt(stmt.Declaration)
+	l.exporting = exporting
+	return nil
+}
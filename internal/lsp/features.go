@@ -0,0 +1,522 @@
+package lsp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/MichelLacerda/nox/internal/format"
+	"github.com/MichelLacerda/nox/internal/keywords"
+	"github.com/MichelLacerda/nox/internal/lint"
+	"github.com/MichelLacerda/nox/internal/runtime"
+	"github.com/MichelLacerda/nox/internal/scanner"
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// Métodos dos tipos embutidos, oferecidos no completamento após um ".".
+var (
+	stringMethods = []string{"length", "upper", "lower", "split", "replace", "contains", "matches", "index_of", "last_index_of", "trim", "to_number"}
+	listMethods   = []string{"append", "pop", "insert", "remove", "clear", "length", "contains", "index_of", "reverse", "join"}
+	dictMethods   = []string{"get", "set", "remove", "keys", "values", "clear", "contains", "length"}
+)
+
+// position converte linha e coluna do scanner (a partir de 1, em runas)
+// para uma posição LSP (a partir de 0, em unidades UTF-16).
+func (doc *document) position(line, column int) Position {
+	if line < 1 {
+		return Position{}
+	}
+	if line > len(doc.lines) {
+		return Position{Line: line - 1}
+	}
+	runes := []rune(doc.lines[line-1])
+	n := min(max(column-1, 0), len(runes))
+	return Position{Line: line - 1, Character: len(utf16.Encode(runes[:n]))}
+}
+
+// column faz a conversão inversa de position, devolvendo a coluna em runas
+// (a partir de 1).
+func (doc *document) column(pos Position) int {
+	if pos.Line < 0 || pos.Line >= len(doc.lines) {
+		return 1
+	}
+	units := 0
+	for idx, r := range []rune(doc.lines[pos.Line]) {
+		if units >= pos.Character {
+			return idx + 1
+		}
+		units += utf16.RuneLen(r)
+	}
+	return utf8.RuneCountInString(doc.lines[pos.Line]) + 1
+}
+
+func (doc *document) tokenRange(t *token.Token) Range {
+	return Range{
+		Start: doc.position(t.Line, t.Column),
+		End:   doc.position(t.Line, t.Column+utf8.RuneCountInString(t.Lexeme)),
+	}
+}
+
+func (doc *document) location(t *token.Token) Location {
+	return Location{URI: doc.uri, Range: doc.tokenRange(t)}
+}
+
+// tokenAt devolve o índice do identificador sob o cursor. O cursor logo
+// após o último caractere também conta, como fazem os editores.
+func (doc *document) tokenAt(pos Position) int {
+	column := doc.column(pos)
+	for idx, t := range doc.tokens {
+		if t.Type != token.TokenType_IDENTIFIER && t.Type != token.TokenType_SELF {
+			continue
+		}
+		end := t.Column + utf8.RuneCountInString(t.Lexeme)
+		if t.Line == pos.Line+1 && t.Column <= column && column <= end {
+			return idx
+		}
+	}
+	return -1
+}
+
+// symbolAt devolve o símbolo declarado ou referenciado pelo token.
+func (doc *document) symbolAt(t *token.Token) *lint.Symbol {
+	same := func(other *token.Token) bool {
+		return other != nil && other.Offset == t.Offset && other.Lexeme == t.Lexeme
+	}
+	for _, sym := range doc.analysis.Symbols {
+		if same(sym.Name) {
+			return sym
+		}
+		for _, ref := range sym.Refs {
+			if same(ref) {
+				return sym
+			}
+		}
+	}
+	return nil
+}
+
+// global devolve o símbolo de topo com o nome dado.
+func (doc *document) global(name string) *lint.Symbol {
+	for _, sym := range doc.analysis.Symbols {
+		if sym.Global && sym.Name.Lexeme == name {
+			return sym
+		}
+	}
+	return nil
+}
+
+// exported devolve o símbolo que o documento, como módulo, exporta com o nome.
+func (doc *document) exported(name string) *lint.Symbol {
+	if sym := doc.global(name); sym != nil && sym.Exported {
+		return sym
+	}
+	return nil
+}
+
+func (doc *document) methods(name string) []*lint.Symbol {
+	var methods []*lint.Symbol
+	for _, sym := range doc.analysis.Symbols {
+		if sym.Kind == lint.SymbolMethod && (name == "" || sym.Name.Lexeme == name) {
+			methods = append(methods, sym)
+		}
+	}
+	return methods
+}
+
+// receiver devolve o token antes de um ".", quando tokens[idx] é o nome
+// acessado em "objeto.nome".
+func (doc *document) receiver(idx int) *token.Token {
+	if idx < 2 || doc.tokens[idx-1].Type != token.TokenType_DOT {
+		return nil
+	}
+	return doc.tokens[idx-2]
+}
+
+// moduleFile resolve um import de file. Sem saber qual script é o principal,
+// a busca parte da pasta do próprio arquivo (ou da raiz do projeto).
+func moduleFile(file, importPath string) (string, error) {
+	dir := filepath.Dir(file)
+	return runtime.FindModule(importPath, dir, dir)
+}
+
+// importedSymbol procura name entre os módulos importados por doc, através
+// do alias (quando dado) ou dos imports sem alias.
+func (s *Server) importedSymbol(doc *document, alias *lint.Symbol, name string) (*document, *lint.Symbol) {
+	if alias != nil {
+		if mod := s.module(doc, alias.Import); mod != nil {
+			if sym := mod.exported(name); sym != nil {
+				return mod, sym
+			}
+		}
+		return nil, nil
+	}
+	for _, stmt := range doc.analysis.Imports {
+		if stmt.Alias != nil || stmt.Names != nil {
+			continue
+		}
+		path, _ := stmt.Path.Literal.(string)
+		if mod := s.module(doc, path); mod != nil {
+			if sym := mod.exported(name); sym != nil {
+				return mod, sym
+			}
+		}
+	}
+	return nil, nil
+}
+
+// resolve encontra a declaração do identificador tokens[idx], que pode estar
+// no próprio documento ou em um módulo importado.
+func (s *Server) resolve(doc *document, idx int) (*document, *lint.Symbol) {
+	t := doc.tokens[idx]
+
+	if recv := doc.receiver(idx); recv != nil {
+		if recv.Type == token.TokenType_IDENTIFIER {
+			if alias := doc.symbolAt(recv); alias != nil && alias.Kind == lint.SymbolImport && alias.Member == "" {
+				return s.importedSymbol(doc, alias, t.Lexeme)
+			}
+		}
+		if _, module := s.builtinModule(doc, recv); module != nil {
+			return nil, nil
+		}
+		// Sem tipos estáticos, "obj.nome" leva ao método de mesmo nome
+		if methods := doc.methods(t.Lexeme); len(methods) > 0 {
+			return doc, methods[0]
+		}
+		return nil, nil
+	}
+
+	if sym := doc.symbolAt(t); sym != nil {
+		// "import { nome } from" leva à declaração dentro do módulo
+		if sym.Kind == lint.SymbolImport && sym.Member != "" {
+			if mod, decl := s.importedSymbol(doc, sym, sym.Member); decl != nil {
+				return mod, decl
+			}
+		}
+		return doc, sym
+	}
+	if t.Type == token.TokenType_IDENTIFIER {
+		return s.importedSymbol(doc, nil, t.Lexeme)
+	}
+	return nil, nil
+}
+
+func (s *Server) definition(params textDocumentPositionParams) []Location {
+	doc := s.docs[params.TextDocument.URI]
+	if doc == nil {
+		return nil
+	}
+	idx := doc.tokenAt(params.Position)
+	if idx < 0 {
+		return nil
+	}
+	if mod, sym := s.resolve(doc, idx); sym != nil {
+		return []Location{mod.location(sym.Name)}
+	}
+	return nil
+}
+
+func (s *Server) hover(params textDocumentPositionParams) *Hover {
+	doc := s.docs[params.TextDocument.URI]
+	if doc == nil {
+		return nil
+	}
+	idx := doc.tokenAt(params.Position)
+	if idx < 0 {
+		return nil
+	}
+	t := doc.tokens[idx]
+	tokenRange := doc.tokenRange(t)
+
+	var text string
+	if mod, sym := s.resolve(doc, idx); sym != nil {
+		text = codeBlock(symbolDetail(sym)) + sym.Kind.String()
+		if mod != doc {
+			text += " from " + filepath.Base(mod.path)
+		}
+	} else if recv := doc.receiver(idx); recv != nil {
+		if moduleName, module := s.builtinModule(doc, recv); module != nil {
+			if value, ok := module.Entries[t.Lexeme]; ok {
+				text = codeBlock(builtinDetail(moduleName+"."+t.Lexeme, value)) + "builtin"
+			}
+		}
+	} else if value, ok := s.builtins[t.Lexeme]; ok {
+		text = codeBlock(builtinDetail(t.Lexeme, value)) + "builtin"
+	}
+
+	if text == "" {
+		return nil
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: text}, Range: &tokenRange}
+}
+
+func codeBlock(code string) string {
+	return "```nox\n" + code + "\n```\n"
+}
+
+func symbolDetail(sym *lint.Symbol) string {
+	if sym.Detail != "" {
+		return sym.Detail
+	}
+	return sym.Name.Lexeme
+}
+
+func builtinDetail(name string, value any) string {
+	switch v := value.(type) {
+	case *runtime.MapInstance:
+		return "module " + name
+	case runtime.Callable:
+		if v.Arity() < 0 {
+			return name + "(...)"
+		}
+		params := make([]string, v.Arity())
+		for idx := range params {
+			params[idx] = fmt.Sprintf("arg%d", idx+1)
+		}
+		return name + "(" + strings.Join(params, ", ") + ")"
+	}
+	return fmt.Sprintf("%s = %v", name, value)
+}
+
+// builtinModule devolve o módulo builtin referenciado pelo token, se o
+// nome não tiver sido redeclarado no documento.
+func (s *Server) builtinModule(doc *document, t *token.Token) (string, *runtime.MapInstance) {
+	if t.Type != token.TokenType_IDENTIFIER || doc.symbolAt(t) != nil {
+		return "", nil
+	}
+	module, _ := s.builtins[t.Lexeme].(*runtime.MapInstance)
+	return t.Lexeme, module
+}
+
+func (s *Server) documentSymbols(uri string) []DocumentSymbol {
+	doc := s.docs[uri]
+	if doc == nil {
+		return nil
+	}
+
+	symbols := []DocumentSymbol{}
+	classes := map[string]int{}
+	for _, sym := range doc.analysis.Symbols {
+		if !sym.Global {
+			continue
+		}
+		kind := symbolKindVariable
+		switch sym.Kind {
+		case lint.SymbolFunction:
+			kind = symbolKindFunction
+		case lint.SymbolClass:
+			kind = symbolKindClass
+			classes[sym.Name.Lexeme] = len(symbols)
+		case lint.SymbolImport:
+			kind = symbolKindModule
+		}
+		r := doc.tokenRange(sym.Name)
+		symbols = append(symbols, DocumentSymbol{Name: sym.Name.Lexeme, Detail: sym.Detail, Kind: kind, Range: r, SelectionRange: r})
+	}
+
+	for _, method := range doc.methods("") {
+		owner, ok := classes[method.Container]
+		if !ok {
+			continue
+		}
+		r := doc.tokenRange(method.Name)
+		symbols[owner].Children = append(symbols[owner].Children, DocumentSymbol{
+			Name: method.Name.Lexeme, Detail: method.Detail, Kind: symbolKindMethod, Range: r, SelectionRange: r,
+		})
+	}
+	return symbols
+}
+
+func (s *Server) completion(params textDocumentPositionParams) []CompletionItem {
+	doc := s.docs[params.TextDocument.URI]
+	if doc == nil {
+		return nil
+	}
+
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	add := func(label string, kind int, detail string) {
+		if !seen[label] {
+			seen[label] = true
+			items = append(items, CompletionItem{Label: label, Kind: kind, Detail: detail})
+		}
+	}
+
+	if recv := doc.receiverBefore(params.Position); recv != "" {
+		t := &token.Token{Type: token.TokenType_IDENTIFIER, Lexeme: recv}
+		if sym := doc.global(recv); sym != nil && sym.Kind == lint.SymbolImport && sym.Member == "" {
+			if mod := s.module(doc, sym.Import); mod != nil {
+				for _, exported := range mod.analysis.Symbols {
+					if exported.Global && exported.Exported {
+						add(exported.Name.Lexeme, completionKind(exported.Kind), exported.Detail)
+					}
+				}
+			}
+			return items
+		}
+		if doc.global(recv) == nil {
+			if _, module := s.builtinModule(doc, t); module != nil {
+				for _, name := range sortedKeys(module.Entries) {
+					value := module.Entries[name]
+					kind := completionFunction
+					if _, ok := value.(runtime.Callable); !ok {
+						kind = completionConstant
+					}
+					add(name, kind, builtinDetail(recv+"."+name, value))
+				}
+				return items
+			}
+		}
+
+		// Sem tipos estáticos, são oferecidos os métodos das classes do
+		// documento e os dos tipos embutidos.
+		for _, method := range doc.methods("") {
+			add(method.Name.Lexeme, completionMethod, method.Detail)
+		}
+		if recv != "self" {
+			for _, group := range [][]string{stringMethods, listMethods, dictMethods} {
+				for _, name := range group {
+					add(name, completionMethod, "")
+				}
+			}
+		}
+		return items
+	}
+
+	for _, sym := range doc.analysis.Symbols {
+		if sym.Kind != lint.SymbolMethod {
+			add(sym.Name.Lexeme, completionKind(sym.Kind), sym.Detail)
+		}
+	}
+	for _, name := range sortedKeys(s.builtins) {
+		kind := completionFunction
+		if _, ok := s.builtins[name].(*runtime.MapInstance); ok {
+			kind = completionModule
+		}
+		add(name, kind, builtinDetail(name, s.builtins[name]))
+	}
+	for _, name := range sortedKeys(keywords.Keywords) {
+		add(name, completionKeyword, "")
+	}
+	return items
+}
+
+// receiverBefore devolve o nome antes do "." que precede o cursor, ou ""
+// quando o cursor não está após um acesso a membro.
+func (doc *document) receiverBefore(pos Position) string {
+	if pos.Line < 0 || pos.Line >= len(doc.lines) {
+		return ""
+	}
+	line := []rune(doc.lines[pos.Line])
+	end := min(doc.column(pos)-1, len(line))
+
+	var sc scanner.Scanner
+	isIdent := sc.IsAlphaNumeric
+
+	idx := end
+	for idx > 0 && isIdent(line[idx-1]) {
+		idx--
+	}
+	if idx == 0 || line[idx-1] != '.' {
+		return ""
+	}
+	idx--
+	start := idx
+	for start > 0 && isIdent(line[start-1]) {
+		start--
+	}
+	return string(line[start:idx])
+}
+
+func completionKind(kind lint.SymbolKind) int {
+	switch kind {
+	case lint.SymbolFunction:
+		return completionFunction
+	case lint.SymbolClass:
+		return completionClass
+	case lint.SymbolMethod:
+		return completionMethod
+	case lint.SymbolImport:
+		return completionModule
+	}
+	return completionVariable
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) rename(params renameParams) (*WorkspaceEdit, error) {
+	doc := s.docs[params.TextDocument.URI]
+	if doc == nil {
+		return nil, fmt.Errorf("document not open: %s", params.TextDocument.URI)
+	}
+	if !isIdentifier(params.NewName) {
+		return nil, fmt.Errorf("'%s' is not a valid identifier", params.NewName)
+	}
+	if _, ok := keywords.Keywords[params.NewName]; ok {
+		return nil, fmt.Errorf("'%s' is a keyword", params.NewName)
+	}
+
+	idx := doc.tokenAt(params.Position)
+	if idx < 0 || doc.receiver(idx) != nil {
+		return nil, fmt.Errorf("no renamable symbol at this position")
+	}
+	sym := doc.symbolAt(doc.tokens[idx])
+	if sym == nil {
+		return nil, fmt.Errorf("no renamable symbol at this position")
+	}
+	if sym.Kind == lint.SymbolMethod {
+		// As chamadas de métodos não são resolvidas estaticamente
+		return nil, fmt.Errorf("renaming methods is not supported")
+	}
+	if sym.Exported {
+		// O rename altera só este documento; os arquivos que importam o
+		// nome ficariam quebrados
+		return nil, fmt.Errorf("'%s' is exported and may be imported by other files; renaming exported names is not supported", sym.Name.Lexeme)
+	}
+	if sym.Kind == lint.SymbolImport && sym.Member == sym.Name.Lexeme {
+		// O token é o nome exportado pelo módulo, não um alias local
+		return nil, fmt.Errorf("use 'import { %s as name }' to rename an imported name", sym.Member)
+	}
+
+	edits := []TextEdit{{Range: doc.tokenRange(sym.Name), NewText: params.NewName}}
+	for _, ref := range sym.Refs {
+		edits = append(edits, TextEdit{Range: doc.tokenRange(ref), NewText: params.NewName})
+	}
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{doc.uri: edits}}, nil
+}
+
+// isIdentifier usa as regras de caractere do scanner, para que o novo nome
+// seja lido como um único identificador.
+func isIdentifier(name string) bool {
+	var sc scanner.Scanner
+	for idx, r := range name {
+		if !sc.IsAlpha(r) && (idx == 0 || !sc.IsDigit(r)) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func (s *Server) formatting(uri string) []TextEdit {
+	doc := s.docs[uri]
+	if doc == nil {
+		return nil
+	}
+	formatted, err := format.Source(doc.path, doc.text)
+	if err != nil || formatted == doc.text {
+		// Com erros de sintaxe o documento não é formatado
+		return []TextEdit{}
+	}
+	last := len(doc.lines)
+	end := doc.position(last, utf8.RuneCountInString(doc.lines[last-1])+1)
+	return []TextEdit{{Range: Range{End: end}, NewText: formatted}}
+}
@@ -0,0 +1,316 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+	"github.com/MichelLacerda/nox/internal/lint"
+	"github.com/MichelLacerda/nox/internal/scanner"
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// document é um arquivo aberto no editor, com a análise da última versão.
+type document struct {
+	uri      string
+	path     string
+	text     string
+	lines    []string
+	tokens   []*token.Token
+	analysis *lint.Analysis
+}
+
+func newDocument(uri, text string) *document {
+	doc := &document{uri: uri, path: uriToPath(uri), text: text}
+	doc.lines = strings.Split(text, "\n")
+	// Os tokens são usados para localizar o identificador sob o cursor,
+	// mesmo quando o arquivo tem erros de sintaxe.
+	doc.tokens, _ = scanner.NewScanner([]rune(text)).ScanTokens()
+	doc.analysis = lint.Analyze(doc.path, text)
+	return doc
+}
+
+// Server é um servidor LSP que conversa por JSON-RPC em um par de streams.
+type Server struct {
+	reader   *bufio.Reader
+	writer   io.Writer
+	writeMu  sync.Mutex
+	docs     map[string]*document
+	modules  map[string]*document // módulos importados lidos do disco
+	builtins map[string]any
+	shutdown bool
+}
+
+func NewServer() *Server {
+	return &Server{
+		docs:     map[string]*document{},
+		modules:  map[string]*document{},
+		builtins: lint.Builtins(),
+	}
+}
+
+// Run atende requisições até receber "exit" ou o fim da entrada.
+func (s *Server) Run(in io.Reader, out io.Writer) error {
+	s.reader = bufio.NewReader(in)
+	s.writer = out
+
+	for {
+		body, err := s.readMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.replyError(nil, codeParseError, err.Error())
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit received before shutdown")
+			}
+			return nil
+		}
+		s.handle(&req)
+	}
+}
+
+func (s *Server) readMessage() ([]byte, error) {
+	headers, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) write(message any) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *Server) reply(id json.RawMessage, result any) {
+	s.write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) replyError(id json.RawMessage, code int, message string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.write(errorResponse{JSONRPC: "2.0", ID: id, Error: responseError{Code: code, Message: message}})
+}
+
+func (s *Server) notify(method string, params any) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req *request) {
+	isRequest := len(req.ID) > 0
+
+	// Uma falha ao tratar um documento não pode encerrar a sessão do editor
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "nox lsp: %s: %v\n", req.Method, r)
+			if isRequest {
+				s.replyError(req.ID, codeInternalError, fmt.Sprint(r))
+			}
+		}
+	}()
+
+	decode := func(target any) bool {
+		if err := json.Unmarshal(req.Params, target); err != nil {
+			if isRequest {
+				s.replyError(req.ID, codeInvalidParams, err.Error())
+			}
+			return false
+		}
+		return true
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.reply(req.ID, map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":           1, // documento inteiro a cada mudança
+				"definitionProvider":         true,
+				"hoverProvider":              true,
+				"documentSymbolProvider":     true,
+				"renameProvider":             true,
+				"documentFormattingProvider": true,
+				"completionProvider": map[string]any{
+					"triggerCharacters": []string{"."},
+				},
+			},
+			"serverInfo": map[string]string{"name": "nox-lsp"},
+		})
+
+	case "initialized":
+
+	case "shutdown":
+		s.shutdown = true
+		s.reply(req.ID, nil)
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if decode(&params) {
+			s.open(params.TextDocument.URI, params.TextDocument.Text)
+		}
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if decode(&params) && len(params.ContentChanges) > 0 {
+			last := params.ContentChanges[len(params.ContentChanges)-1]
+			s.open(params.TextDocument.URI, last.Text)
+		}
+
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument textDocumentIdentifier `json:"textDocument"`
+		}
+		if decode(&params) {
+			delete(s.docs, params.TextDocument.URI)
+			s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+				URI:         params.TextDocument.URI,
+				Diagnostics: []Diagnostic{},
+			})
+		}
+
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if decode(&params) {
+			s.reply(req.ID, s.definition(params))
+		}
+
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if decode(&params) {
+			s.reply(req.ID, s.hover(params))
+		}
+
+	case "textDocument/documentSymbol":
+		var params struct {
+			TextDocument textDocumentIdentifier `json:"textDocument"`
+		}
+		if decode(&params) {
+			s.reply(req.ID, s.documentSymbols(params.TextDocument.URI))
+		}
+
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if decode(&params) {
+			s.reply(req.ID, s.completion(params))
+		}
+
+	case "textDocument/rename":
+		var params renameParams
+		if decode(&params) {
+			edit, err := s.rename(params)
+			if err != nil {
+				s.replyError(req.ID, codeInvalidRequest, err.Error())
+				return
+			}
+			s.reply(req.ID, edit)
+		}
+
+	case "textDocument/formatting":
+		var params struct {
+			TextDocument textDocumentIdentifier `json:"textDocument"`
+		}
+		if decode(&params) {
+			s.reply(req.ID, s.formatting(params.TextDocument.URI))
+		}
+
+	default:
+		if isRequest {
+			s.replyError(req.ID, codeMethodNotFound, "method not supported: "+req.Method)
+		}
+	}
+}
+
+func (s *Server) open(uri, text string) {
+	doc := newDocument(uri, text)
+	s.docs[uri] = doc
+	// Um módulo aberto no editor tem precedência sobre a versão em disco
+	delete(s.modules, doc.path)
+	s.publishDiagnostics(doc)
+}
+
+func (s *Server) publishDiagnostics(doc *document) {
+	diags := []Diagnostic{}
+	for _, d := range doc.analysis.Diagnostics {
+		severity := severityError
+		if d.Severity == diagnostic.SeverityWarning {
+			severity = severityWarning
+		}
+		start := doc.position(d.Line, d.Column)
+		end := doc.position(d.Line, d.Column+max(d.Length, 1))
+		diags = append(diags, Diagnostic{
+			Range:    Range{Start: start, End: end},
+			Severity: severity,
+			Code:     d.Code,
+			Source:   "nox",
+			Message:  d.Message,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: doc.uri, Diagnostics: diags})
+}
+
+// module devolve o documento de um módulo importado por doc, preferindo a
+// versão aberta no editor.
+func (s *Server) module(doc *document, importPath string) *document {
+	path, err := moduleFile(doc.path, importPath)
+	if err != nil {
+		return nil
+	}
+	for _, open := range s.docs {
+		if open.path == path {
+			return open
+		}
+	}
+	if mod, ok := s.modules[path]; ok {
+		return mod
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	mod := newDocument(pathToURI(path), string(source))
+	s.modules[path] = mod
+	return mod
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
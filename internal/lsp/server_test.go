@@ -0,0 +1,365 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const mainSource = `import "util" as util;
+import { double } from "util";
+
+class Counter {
+    init() {
+        self.n = 0;
+    }
+    inc() {
+        self.n = self.n + 1;
+    }
+}
+
+func add(a, b) {
+    return a + b;
+}
+
+export func twice(x) {
+    return add(x, x);
+}
+
+let total = add(1, 2);
+print util.double(total), double(total), len("abc");
+`
+
+const utilSource = `export func double(x) {
+    return x * 2;
+}
+`
+
+// message é uma mensagem JSON-RPC recebida do servidor.
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// session acumula as mensagens de um teste, depois de initialize e da
+// abertura de main.nox; run executa o servidor com elas e guarda o que ele
+// respondeu.
+type session struct {
+	t       *testing.T
+	uri     string
+	input   bytes.Buffer
+	nextID  int
+	replies map[int]message
+	notes   []message
+}
+
+func newSession(t *testing.T) *session {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "util.nox"), []byte(utilSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &session{t: t, uri: pathToURI(filepath.Join(dir, "main.nox"))}
+	s.request("initialize", map[string]any{})
+	s.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": s.uri, "version": 1, "text": mainSource},
+	})
+	return s
+}
+
+func (s *session) send(message map[string]any) {
+	message["jsonrpc"] = "2.0"
+	body, err := json.Marshal(message)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	fmt.Fprintf(&s.input, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// request enfileira uma requisição e devolve o id dela.
+func (s *session) request(method string, params any) int {
+	s.nextID++
+	s.send(map[string]any{"id": s.nextID, "method": method, "params": params})
+	return s.nextID
+}
+
+func (s *session) notify(method string, params any) {
+	s.send(map[string]any{"method": method, "params": params})
+}
+
+// at pede method na posição pos de main.nox.
+func (s *session) at(method string, pos Position, extra map[string]any) int {
+	params := map[string]any{
+		"textDocument": map[string]any{"uri": s.uri},
+		"position":     pos,
+	}
+	for key, value := range extra {
+		params[key] = value
+	}
+	return s.request(method, params)
+}
+
+// run encerra a sessão, executa o servidor e lê as respostas.
+func (s *session) run() {
+	s.t.Helper()
+	s.request("shutdown", nil)
+	s.notify("exit", nil)
+
+	var output bytes.Buffer
+	if err := NewServer().Run(&s.input, &output); err != nil {
+		s.t.Fatal(err)
+	}
+
+	s.replies = map[int]message{}
+	reader := bufio.NewReader(&output)
+	for {
+		headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		length, _ := strconv.Atoi(headers.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			s.t.Fatal(err)
+		}
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.t.Fatal(err)
+		}
+		if msg.Method != "" {
+			s.notes = append(s.notes, msg)
+			continue
+		}
+		id, _ := strconv.Atoi(string(msg.ID))
+		s.replies[id] = msg
+	}
+}
+
+// result decodifica a resposta da requisição id em target.
+func (s *session) result(id int, target any) {
+	s.t.Helper()
+	reply, ok := s.replies[id]
+	if !ok {
+		s.t.Fatalf("no reply to request %d", id)
+	}
+	if reply.Error != nil {
+		s.t.Fatalf("request %d failed: %s", id, reply.Error.Message)
+	}
+	if err := json.Unmarshal(reply.Result, target); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// find devolve a posição da primeira ocorrência de needle em main.nox.
+func find(t *testing.T, needle string) Position {
+	t.Helper()
+	for line, content := range strings.Split(mainSource, "\n") {
+		if idx := strings.Index(content, needle); idx >= 0 {
+			return Position{Line: line, Character: idx}
+		}
+	}
+	t.Fatalf("%q not found", needle)
+	return Position{}
+}
+
+// member devolve a posição do membro em "objeto.membro".
+func member(t *testing.T, needle string) Position {
+	t.Helper()
+	pos := find(t, needle)
+	pos.Character += strings.Index(needle, ".") + 1
+	return pos
+}
+
+func TestInitializeAndDiagnostics(t *testing.T) {
+	s := newSession(t)
+	initialize := 1
+	s.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": s.uri, "version": 2},
+		"contentChanges": []map[string]any{{"text": "let x = ;\n"}},
+	})
+	s.run()
+
+	var capabilities struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	s.result(initialize, &capabilities)
+	for _, name := range []string{"definitionProvider", "hoverProvider", "renameProvider", "documentFormattingProvider", "completionProvider"} {
+		if capabilities.Capabilities[name] == nil {
+			t.Errorf("capability %s missing", name)
+		}
+	}
+
+	var published []publishDiagnosticsParams
+	for _, note := range s.notes {
+		if note.Method == "textDocument/publishDiagnostics" {
+			var params publishDiagnosticsParams
+			json.Unmarshal(note.Params, &params)
+			published = append(published, params)
+		}
+	}
+	if len(published) != 2 {
+		t.Fatalf("got %d diagnostics notifications, want one per version", len(published))
+	}
+	if diags := published[0].Diagnostics; len(diags) != 0 {
+		t.Errorf("main.nox diagnostics = %+v, want none", diags)
+	}
+	diags := published[1].Diagnostics
+	if len(diags) != 1 || diags[0].Message != "Expect expression." || diags[0].Range.Start != (Position{Line: 0, Character: 8}) {
+		t.Errorf("diagnostics after the change = %+v", diags)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	s := newSession(t)
+	local := s.at("textDocument/definition", find(t, "add(1, 2)"), nil)
+	moduleMember := s.at("textDocument/definition", member(t, "util.double"), nil)
+	imported := s.at("textDocument/definition", find(t, "double(total), len"), nil)
+	s.run()
+
+	tests := []struct {
+		name string
+		id   int
+		file string
+		line int
+	}{
+		{"local function", local, "main.nox", find(t, "func add").Line},
+		{"module member", moduleMember, "util.nox", 0},
+		{"selective import", imported, "util.nox", 0},
+	}
+	for _, tt := range tests {
+		var locations []Location
+		s.result(tt.id, &locations)
+		if len(locations) != 1 || filepath.Base(uriToPath(locations[0].URI)) != tt.file || locations[0].Range.Start.Line != tt.line {
+			t.Errorf("%s: definition = %+v, want %s:%d", tt.name, locations, tt.file, tt.line+1)
+		}
+	}
+}
+
+func TestHoverAndCompletion(t *testing.T) {
+	s := newSession(t)
+	function := s.at("textDocument/hover", find(t, "add(1, 2)"), nil)
+	builtin := s.at("textDocument/hover", find(t, `len("abc")`), nil)
+	completion := s.at("textDocument/completion", member(t, "util.double"), nil)
+	s.run()
+
+	var hover Hover
+	s.result(function, &hover)
+	if !strings.Contains(hover.Contents.Value, "func add(a, b)") {
+		t.Errorf("hover on add = %q", hover.Contents.Value)
+	}
+	s.result(builtin, &hover)
+	if !strings.Contains(hover.Contents.Value, "len(arg1)") || !strings.Contains(hover.Contents.Value, "builtin") {
+		t.Errorf("hover on len = %q", hover.Contents.Value)
+	}
+
+	var items []CompletionItem
+	s.result(completion, &items)
+	var labels []string
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	if len(labels) != 1 || labels[0] != "double" {
+		t.Errorf("completion after util. = %v, want the module exports", labels)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	s := newSession(t)
+	id := s.request("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": s.uri}})
+	s.run()
+
+	var symbols []DocumentSymbol
+	s.result(id, &symbols)
+	var got []string
+	for _, sym := range symbols {
+		entry := sym.Name
+		for _, child := range sym.Children {
+			entry += " " + child.Name
+		}
+		got = append(got, entry)
+	}
+	want := []string{"util", "double", "Counter init inc", "add", "twice", "total"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("symbols = %q, want %q", got, want)
+	}
+}
+
+func TestRename(t *testing.T) {
+	s := newSession(t)
+	local := s.at("textDocument/rename", find(t, "total ="), map[string]any{"newName": "sum"})
+	parameter := s.at("textDocument/rename", find(t, "a, b)"), map[string]any{"newName": "left"})
+	exported := s.at("textDocument/rename", find(t, "twice"), map[string]any{"newName": "double_it"})
+	keyword := s.at("textDocument/rename", find(t, "add(1, 2)"), map[string]any{"newName": "class"})
+	nonASCII := s.at("textDocument/rename", find(t, "total ="), map[string]any{"newName": "ação"})
+	s.run()
+
+	tests := []struct {
+		id    int
+		edits int
+	}{
+		{local, 3},     // declaração e dois usos
+		{parameter, 2}, // parâmetro e uso
+	}
+	for _, tt := range tests {
+		var edit WorkspaceEdit
+		s.result(tt.id, &edit)
+		if len(edit.Changes) != 1 || len(edit.Changes[s.uri]) != tt.edits {
+			t.Errorf("rename %d edits = %+v, want %d in main.nox", tt.id, edit.Changes, tt.edits)
+		}
+	}
+
+	for id, want := range map[int]string{
+		exported: "'twice' is exported",
+		keyword:  "'class' is a keyword",
+		nonASCII: "'ação' is not a valid identifier",
+	} {
+		reply := s.replies[id]
+		if reply.Error == nil || !strings.Contains(reply.Error.Message, want) {
+			t.Errorf("rename %d error = %+v, want %q", id, reply.Error, want)
+		}
+	}
+}
+
+// Um documento pela metade, como enquanto se digita, só gera diagnósticos:
+// as requisições seguintes continuam sendo atendidas.
+func TestIncompleteDocument(t *testing.T) {
+	s := newSession(t)
+	s.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": s.uri, "version": 2},
+		"contentChanges": []map[string]any{{"text": "let config = {\"name\": "}},
+	})
+	hover := s.at("textDocument/hover", Position{Line: 0, Character: 5}, nil)
+	completion := s.at("textDocument/completion", Position{Line: 0, Character: 22}, nil)
+	symbols := s.request("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": s.uri}})
+	formatting := s.request("textDocument/formatting", map[string]any{"textDocument": map[string]any{"uri": s.uri}})
+	s.run()
+
+	for _, id := range []int{hover, completion, symbols, formatting} {
+		if reply, ok := s.replies[id]; !ok || reply.Error != nil {
+			t.Errorf("request %d: reply = %+v", id, reply)
+		}
+	}
+	var last publishDiagnosticsParams
+	for _, note := range s.notes {
+		if note.Method == "textDocument/publishDiagnostics" {
+			json.Unmarshal(note.Params, &last)
+		}
+	}
+	if len(last.Diagnostics) != 1 || last.Diagnostics[0].Message != "Expect expression." {
+		t.Errorf("diagnostics = %+v", last.Diagnostics)
+	}
+}
@@ -0,0 +1,94 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Lock é o conteúdo do nox.lock: a origem exata de cada dependência
+// instalada, incluindo as indiretas, e o hash dos arquivos instalados.
+type Lock struct {
+	Dependencies map[string]Locked `json:"dependencies"`
+}
+
+// Locked é uma dependência fixada pelo lockfile. Path é relativo à pasta
+// do projeto.
+type Locked struct {
+	Path   string `json:"path,omitempty"`
+	Git    string `json:"git,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	Hash   string `json:"hash"`
+}
+
+// ReadLock lê o nox.lock da pasta dir; sem arquivo, devolve um lock vazio.
+func ReadLock(dir string) (*Lock, error) {
+	lock := &Lock{Dependencies: map[string]Locked{}}
+	data, err := os.ReadFile(filepath.Join(dir, LockFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, errors.New(LockFile + ": " + err.Error())
+	}
+	if lock.Dependencies == nil {
+		lock.Dependencies = map[string]Locked{}
+	}
+	return lock, nil
+}
+
+// Write grava o lock na pasta dir. As chaves saem ordenadas, então o
+// arquivo só muda quando as dependências mudam.
+func (l *Lock) Write(dir string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, LockFile), append(data, '\n'), 0o644)
+}
+
+// HashDir calcula o hash dos arquivos de uma pasta, a partir dos caminhos
+// relativos e do conteúdo, no formato "sha256:<hex>".
+func HashDir(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, path := range files {
+		rel, _ := filepath.Rel(dir, path)
+		io.WriteString(h, filepath.ToSlash(rel)+"\x00")
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,210 @@
+// Package manifest lê o manifesto de um projeto Nox (nox.toml ou nox.json),
+// mantém o lockfile e instala as dependências na pasta de vendor.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Files são os nomes aceitos para o manifesto, em ordem de preferência.
+var Files = []string{"nox.toml", "nox.json"}
+
+const (
+	LockFile  = "nox.lock"    // versões exatas instaladas, ao lado do manifesto
+	VendorDir = "nox_modules" // pasta onde "nox mod" instala as dependências
+)
+
+// Manifest descreve um projeto:
+//
+//	[package]
+//	name = "app"
+//	version = "0.1.0"
+//
+//	[dependencies]
+//	shapes = { path = "../shapes" }
+//	strutil = { git = "https://example.com/strutil.git", tag = "v1.2.0" }
+//
+// O nox.json tem a mesma estrutura: {"package": {...}, "dependencies": {...}}.
+type Manifest struct {
+	File         string // caminho do arquivo lido
+	Name         string
+	Version      string
+	Dependencies map[string]Dependency
+}
+
+// Dependency é a origem de uma dependência: uma pasta local ou um
+// repositório git, opcionalmente fixado numa tag (ou branch, ou commit).
+type Dependency struct {
+	Path string `json:"path,omitempty"`
+	Git  string `json:"git,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+func (d Dependency) String() string {
+	if d.Git != "" {
+		if d.Tag != "" {
+			return d.Git + "@" + d.Tag
+		}
+		return d.Git
+	}
+	return d.Path
+}
+
+// Dir devolve a pasta do projeto.
+func (m *Manifest) Dir() string {
+	return filepath.Dir(m.File)
+}
+
+// Names devolve os nomes das dependências em ordem alfabética.
+func (m *Manifest) Names() []string {
+	names := make([]string, 0, len(m.Dependencies))
+	for name := range m.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Find devolve o manifesto da pasta dir, ou nil quando ela não tem um.
+func Find(dir string) (*Manifest, error) {
+	for _, name := range Files {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	return nil, nil
+}
+
+// Load lê e valida um manifesto.
+func Load(path string) (*Manifest, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]any
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(source, &data)
+	} else {
+		data, err = parseTOML(string(source))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	m, err := fromTable(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if m.File, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func fromTable(data map[string]any) (*Manifest, error) {
+	m := &Manifest{Dependencies: map[string]Dependency{}}
+
+	if pkg, ok := data["package"]; ok {
+		table, ok := pkg.(map[string]any)
+		if !ok {
+			return nil, errors.New("'package' must be a table")
+		}
+		var err error
+		if m.Name, err = stringField(table, "package", "name"); err != nil {
+			return nil, err
+		}
+		if m.Version, err = stringField(table, "package", "version"); err != nil {
+			return nil, err
+		}
+	}
+
+	deps, ok := data["dependencies"]
+	if !ok {
+		return m, nil
+	}
+	table, ok := deps.(map[string]any)
+	if !ok {
+		return nil, errors.New("'dependencies' must be a table")
+	}
+	for name, value := range table {
+		if !validName(name) {
+			return nil, fmt.Errorf("invalid dependency name '%s' (use letters, digits, '_' and '-')", name)
+		}
+		spec, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("dependency '%s' must be a table like { path = \"...\" } or { git = \"...\", tag = \"...\" }", name)
+		}
+		var dep Dependency
+		var err error
+		section := "dependency '" + name + "'"
+		if dep.Path, err = stringField(spec, section, "path"); err != nil {
+			return nil, err
+		}
+		if dep.Git, err = stringField(spec, section, "git"); err != nil {
+			return nil, err
+		}
+		if dep.Tag, err = stringField(spec, section, "tag"); err != nil {
+			return nil, err
+		}
+		for key := range spec {
+			if key != "path" && key != "git" && key != "tag" {
+				return nil, fmt.Errorf("%s: unknown key '%s'", section, key)
+			}
+		}
+		switch {
+		case (dep.Path == "") == (dep.Git == ""):
+			return nil, fmt.Errorf("%s needs exactly one of 'path' or 'git'", section)
+		case dep.Tag != "" && dep.Git == "":
+			return nil, fmt.Errorf("%s: 'tag' is only valid with 'git'", section)
+		case strings.HasPrefix(dep.Git, "-"), strings.HasPrefix(dep.Tag, "-"):
+			// seriam lidos pelo git como opções
+			return nil, fmt.Errorf("%s: 'git' and 'tag' must not start with '-'", section)
+		}
+		m.Dependencies[name] = dep
+	}
+	return m, nil
+}
+
+func stringField(table map[string]any, section, key string) (string, error) {
+	value, ok := table[key]
+	if !ok {
+		return "", nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: '%s' must be a string", section, key)
+	}
+	return text, nil
+}
+
+func validName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Init cria um nox.toml mínimo na pasta dir.
+func Init(dir, name string) (string, error) {
+	for _, file := range Files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return "", fmt.Errorf("%s already exists", file)
+		}
+	}
+	path := filepath.Join(dir, Files[0])
+	content := fmt.Sprintf("[package]\nname = %q\nversion = \"0.1.0\"\n\n[dependencies]\n", name)
+	return path, os.WriteFile(path, []byte(content), 0o644)
+}
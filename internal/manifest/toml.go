@@ -0,0 +1,159 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML lê o subconjunto de TOML usado pelo nox.toml: tabelas
+// ([package], [dependencies.nome]), chaves com strings e tabelas inline
+// ({ git = "...", tag = "..." }) e comentários com "#".
+func parseTOML(source string) (map[string]any, error) {
+	root := map[string]any{}
+	current := root
+
+	for idx, line := range strings.Split(source, "\n") {
+		lineNo := idx + 1
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header %s", lineNo, line)
+			}
+			table := root
+			for _, key := range strings.Split(line[1:len(line)-1], ".") {
+				key = unquoteKey(strings.TrimSpace(key))
+				if key == "" {
+					return nil, fmt.Errorf("line %d: empty table name", lineNo)
+				}
+				next, ok := table[key].(map[string]any)
+				if !ok {
+					if _, exists := table[key]; exists {
+						return nil, fmt.Errorf("line %d: '%s' is not a table", lineNo, key)
+					}
+					next = map[string]any{}
+					table[key] = next
+				}
+				table = next
+			}
+			current = table
+			continue
+		}
+
+		key, value, err := parseKeyValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if _, exists := current[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key '%s'", lineNo, key)
+		}
+		current[key] = value
+	}
+	return root, nil
+}
+
+func parseKeyValue(text string) (string, any, error) {
+	eq := strings.Index(text, "=")
+	if eq < 0 {
+		return "", nil, fmt.Errorf("expected 'key = value', got %q", text)
+	}
+	key := unquoteKey(strings.TrimSpace(text[:eq]))
+	if key == "" {
+		return "", nil, fmt.Errorf("missing key before '='")
+	}
+	value, rest, err := parseValue(strings.TrimSpace(text[eq+1:]))
+	if err != nil {
+		return "", nil, fmt.Errorf("value of '%s': %w", key, err)
+	}
+	if strings.TrimSpace(rest) != "" {
+		return "", nil, fmt.Errorf("unexpected %q after value of '%s'", rest, key)
+	}
+	return key, value, nil
+}
+
+// parseValue lê um valor no início de text e devolve o que sobrou.
+func parseValue(text string) (any, string, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		end := 1
+		for end < len(text) && text[end] != '"' {
+			if text[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(text) {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		value, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid string %s", text[:end+1])
+		}
+		return value, text[end+1:], nil
+
+	case strings.HasPrefix(text, "'"):
+		end := strings.Index(text[1:], "'")
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		return text[1 : end+1], text[end+2:], nil
+
+	case strings.HasPrefix(text, "{"):
+		table := map[string]any{}
+		rest := strings.TrimSpace(text[1:])
+		for !strings.HasPrefix(rest, "}") {
+			eq := strings.Index(rest, "=")
+			if eq < 0 {
+				return nil, "", fmt.Errorf("expected 'key = value' in inline table")
+			}
+			key := unquoteKey(strings.TrimSpace(rest[:eq]))
+			value, after, err := parseValue(strings.TrimSpace(rest[eq+1:]))
+			if err != nil {
+				return nil, "", err
+			}
+			table[key] = value
+			rest = strings.TrimSpace(after)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "}") {
+				return nil, "", fmt.Errorf("expected ',' or '}' in inline table")
+			}
+		}
+		return table, rest[1:], nil
+
+	case text == "true" || text == "false":
+		return text == "true", "", nil
+	}
+	return nil, "", fmt.Errorf("unsupported value %q (use a quoted string or an inline table)", text)
+}
+
+// stripComment remove um comentário "#" que não esteja dentro de string.
+func stripComment(line string) string {
+	var quote byte
+	for idx := 0; idx < len(line); idx++ {
+		switch c := line[idx]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				idx++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:idx]
+		}
+	}
+	return line
+}
+
+func unquoteKey(key string) string {
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+		return key[1 : len(key)-1]
+	}
+	return key
+}
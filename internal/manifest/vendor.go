@@ -0,0 +1,275 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// VendorOptions configura Vendor.
+type VendorOptions struct {
+	UpdateAll bool      // ignora todos os commits fixados no lock
+	Update    []string  // ignora os commits fixados destas dependências
+	Log       io.Writer // uma linha por dependência instalada; nil silencia
+}
+
+func (o VendorOptions) updates(name string) bool {
+	return o.UpdateAll || slices.Contains(o.Update, name)
+}
+
+// pending é uma dependência a instalar, com quem a pediu.
+type pending struct {
+	name   string
+	dep    Dependency
+	base   string // pasta contra a qual um "path" relativo é resolvido
+	source string // caminho absoluto ou git@tag, para detectar conflitos
+	by     string // "" para as dependências diretas
+}
+
+// Vendor instala as dependências de m, diretas e indiretas, em
+// <projeto>/nox_modules/<nome> e grava o nox.lock. Dependências git já
+// presentes no lock voltam ao mesmo commit e precisam ter o mesmo hash;
+// as de pasta local são copiadas de novo a cada execução. Pastas de
+// nox_modules que não correspondem a nenhuma dependência são removidas.
+func Vendor(m *Manifest, opts VendorOptions) (*Lock, error) {
+	root := m.Dir()
+	previous, err := ReadLock(root)
+	if err != nil {
+		return nil, err
+	}
+	vendor := filepath.Join(root, VendorDir)
+	if err := os.MkdirAll(vendor, 0o755); err != nil {
+		return nil, err
+	}
+
+	var queue []pending
+	enqueue := func(deps *Manifest, base, by string, fromGit bool) error {
+		for _, name := range deps.Names() {
+			dep := deps.Dependencies[name]
+			p := pending{name: name, dep: dep, base: base, by: by}
+			if dep.Git != "" {
+				p.source = dep.String()
+			} else if fromGit {
+				return fmt.Errorf("dependency '%s' of '%s' is a local path, which is not allowed inside a git dependency", name, by)
+			} else {
+				p.source = filepath.Clean(filepath.Join(base, dep.Path))
+			}
+			queue = append(queue, p)
+		}
+		return nil
+	}
+	if err := enqueue(m, root, "", false); err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{Dependencies: map[string]Locked{}}
+	installed := map[string]pending{}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if first, ok := installed[p.name]; ok {
+			if first.source != p.source {
+				return nil, fmt.Errorf("dependency '%s' is required as %s by %s and as %s by %s",
+					p.name, first.source, requiredBy(first.by), p.source, requiredBy(p.by))
+			}
+			continue
+		}
+		installed[p.name] = p
+
+		locked, err := install(root, vendor, p, previous.Dependencies[p.name], opts.updates(p.name))
+		if err != nil {
+			return nil, fmt.Errorf("dependency '%s': %w", p.name, err)
+		}
+		lock.Dependencies[p.name] = locked
+		if opts.Log != nil {
+			fmt.Fprintf(opts.Log, "%s %s\n", p.name, describeLocked(locked))
+		}
+
+		sub, err := Find(filepath.Join(vendor, p.name))
+		if err != nil {
+			return nil, fmt.Errorf("dependency '%s': %w", p.name, err)
+		}
+		if sub != nil {
+			if err := enqueue(sub, p.source, p.name, p.dep.Git != ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(vendor)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if _, ok := lock.Dependencies[entry.Name()]; !ok {
+			os.RemoveAll(filepath.Join(vendor, entry.Name()))
+		}
+	}
+	return lock, lock.Write(root)
+}
+
+func requiredBy(by string) string {
+	if by == "" {
+		return "the project"
+	}
+	return "'" + by + "'"
+}
+
+func describeLocked(l Locked) string {
+	if l.Git == "" {
+		return l.Path
+	}
+	text := l.Git
+	if l.Tag != "" {
+		text += "@" + l.Tag
+	}
+	return text + " (" + shortCommit(l.Commit) + ")"
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// install copia ou clona uma dependência numa pasta temporária e só então
+// substitui a instalada, para não deixar nox_modules pela metade.
+func install(root, vendor string, p pending, previous Locked, update bool) (Locked, error) {
+	tmp, err := os.MkdirTemp(vendor, "."+p.name+"-")
+	if err != nil {
+		return Locked{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	var locked Locked
+	pinned := false
+	if p.dep.Git != "" {
+		locked = Locked{Git: p.dep.Git, Tag: p.dep.Tag}
+		ref := p.dep.Tag
+		if !update && previous.Git == p.dep.Git && previous.Tag == p.dep.Tag && previous.Commit != "" {
+			ref, pinned = previous.Commit, true
+		}
+		if locked.Commit, err = gitFetch(p.dep.Git, ref, tmp); err != nil {
+			return Locked{}, err
+		}
+	} else {
+		if info, err := os.Stat(p.source); err != nil || !info.IsDir() {
+			return Locked{}, fmt.Errorf("path %s is not a directory", p.source)
+		}
+		if err := copyTree(p.source, tmp); err != nil {
+			return Locked{}, err
+		}
+		rel, err := filepath.Rel(root, p.source)
+		if err != nil {
+			rel = p.source
+		}
+		locked.Path = filepath.ToSlash(rel)
+	}
+
+	if locked.Hash, err = HashDir(tmp); err != nil {
+		return Locked{}, err
+	}
+	if pinned && previous.Hash != "" && previous.Hash != locked.Hash {
+		return Locked{}, fmt.Errorf("checksum mismatch at commit %s: %s has %s, got %s (use 'nox mod update %s' if the change is expected)",
+			shortCommit(locked.Commit), LockFile, previous.Hash, locked.Hash, p.name)
+	}
+
+	dest := filepath.Join(vendor, p.name)
+	if err := os.RemoveAll(dest); err != nil {
+		return Locked{}, err
+	}
+	return locked, os.Rename(tmp, dest)
+}
+
+// gitFetch clona url em dir, posiciona no ref (tag, branch ou commit; vazio
+// para o branch padrão) e devolve o commit. A pasta .git é removida.
+func gitFetch(url, ref, dir string) (string, error) {
+	// url e ref vêm do manifesto e do lock: com "-" na frente, o git os
+	// leria como opções
+	for _, arg := range []string{url, ref} {
+		if strings.HasPrefix(arg, "-") {
+			return "", fmt.Errorf("invalid git argument '%s': must not start with '-'", arg)
+		}
+	}
+	if _, err := git("", "clone", "--quiet", "--", url, dir); err != nil {
+		return "", err
+	}
+	if ref != "" {
+		// o "--" depois do ref impede que ele seja lido como caminho
+		if _, err := git(dir, "-c", "advice.detachedHead=false", "checkout", "--quiet", ref, "--"); err != nil {
+			return "", err
+		}
+	}
+	commit, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return commit, os.RemoveAll(filepath.Join(dir, ".git"))
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", gitCommand(args), message)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitCommand devolve o subcomando de args ("clone", "checkout"), pulando
+// opções globais como "-c chave=valor".
+func gitCommand(args []string) string {
+	for idx := 0; idx < len(args); idx++ {
+		switch {
+		case args[idx] == "-c" || args[idx] == "-C":
+			idx++
+		case !strings.HasPrefix(args[idx], "-"):
+			return args[idx]
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// copyTree copia os arquivos de src para dst, sem .git e sem nox_modules.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if path != src && (d.Name() == ".git" || d.Name() == VendorDir) {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,186 @@
+package manifest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo cria um repositório com lib.nox e a tag v1.0.0 e devolve a pasta
+// e a URL file:// dele.
+func gitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	run(t, dir, "init", "--quiet")
+	commitFile(t, dir, "lib.nox", "export let version = 1;\n")
+	run(t, dir, "tag", "v1.0.0")
+	return dir, "file://" + filepath.ToSlash(dir)
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	base := []string{"-c", "user.name=nox", "-c", "user.email=nox@example.com", "-c", "commit.gpgsign=false"}
+	out, err := exec.Command("git", append(append(base, "-C", dir), args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, name), content)
+	run(t, dir, "add", name)
+	run(t, dir, "commit", "--quiet", "-m", "update "+name)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// project cria um projeto que depende de url na tag v1.0.0.
+func project(t *testing.T, url string) *Manifest {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "nox.toml"), "[package]\nname = \"app\"\n\n[dependencies]\nlib = { git = \""+url+"\", tag = \"v1.0.0\" }\n")
+	m, err := Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestVendorGitTag(t *testing.T) {
+	repo, url := gitRepo(t)
+	m := project(t, url)
+
+	lock, err := Vendor(m, VendorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installed := filepath.Join(m.Dir(), VendorDir, "lib")
+	if got := readFile(t, filepath.Join(installed, "lib.nox")); got != "export let version = 1;\n" {
+		t.Errorf("lib.nox = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(installed, ".git")); !os.IsNotExist(err) {
+		t.Errorf(".git was not removed from the vendored copy")
+	}
+
+	hash, err := HashDir(installed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Locked{Git: url, Tag: "v1.0.0", Commit: run(t, repo, "rev-parse", "v1.0.0"), Hash: hash}
+	if got := lock.Dependencies["lib"]; got != want {
+		t.Errorf("lock = %+v, want %+v", got, want)
+	}
+	written, err := ReadLock(m.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := written.Dependencies["lib"]; got != want {
+		t.Errorf("%s = %+v, want %+v", LockFile, got, want)
+	}
+}
+
+func TestVendorRepinsLockedCommit(t *testing.T) {
+	repo, url := gitRepo(t)
+	m := project(t, url)
+	first, err := Vendor(m, VendorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a tag passa a apontar para outro commit
+	commitFile(t, repo, "lib.nox", "export let version = 2;\n")
+	run(t, repo, "tag", "--force", "v1.0.0")
+	moved := run(t, repo, "rev-parse", "HEAD")
+
+	lock, err := Vendor(m, VendorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.Dependencies["lib"] != first.Dependencies["lib"] {
+		t.Errorf("lock changed without an update: %+v", lock.Dependencies["lib"])
+	}
+	if got := readFile(t, filepath.Join(m.Dir(), VendorDir, "lib", "lib.nox")); got != "export let version = 1;\n" {
+		t.Errorf("lib.nox = %q, want the locked version", got)
+	}
+
+	lock, err = Vendor(m, VendorOptions{Update: []string{"lib"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lock.Dependencies["lib"].Commit; got != moved {
+		t.Errorf("commit after update = %s, want %s", got, moved)
+	}
+	if got := readFile(t, filepath.Join(m.Dir(), VendorDir, "lib", "lib.nox")); got != "export let version = 2;\n" {
+		t.Errorf("lib.nox = %q after update", got)
+	}
+}
+
+func TestVendorChecksumMismatch(t *testing.T) {
+	_, url := gitRepo(t)
+	m := project(t, url)
+	lock, err := Vendor(m, VendorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locked := lock.Dependencies["lib"]
+	locked.Hash = "sha256:0000"
+	lock.Dependencies["lib"] = locked
+	if err := lock.Write(m.Dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Vendor(m, VendorOptions{})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch at commit "+shortCommit(locked.Commit)) {
+		t.Fatalf("err = %v, want a checksum mismatch", err)
+	}
+	if got := readFile(t, filepath.Join(m.Dir(), VendorDir, "lib", "lib.nox")); got != "export let version = 1;\n" {
+		t.Errorf("installed copy changed after the failed vendor: %q", got)
+	}
+}
+
+func TestGitArgumentsStartingWithDash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nox.toml")
+	writeFile(t, path, "[dependencies]\nlib = { git = \"https://example.com/lib.git\", tag = \"--output=/tmp/x\" }\n")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "must not start with '-'") {
+		t.Errorf("Load err = %v", err)
+	}
+
+	if _, err := gitFetch("--upload-pack=touch /tmp/x", "", t.TempDir()); err == nil || !strings.Contains(err.Error(), "must not start with '-'") {
+		t.Errorf("gitFetch url err = %v", err)
+	}
+	if _, err := gitFetch("https://example.com/lib.git", "--output=/tmp/x", t.TempDir()); err == nil || !strings.Contains(err.Error(), "must not start with '-'") {
+		t.Errorf("gitFetch ref err = %v", err)
+	}
+}
+
+func TestGitErrorNamesTheSubcommand(t *testing.T) {
+	_, url := gitRepo(t)
+	_, err := gitFetch(url, "no-such-tag", filepath.Join(t.TempDir(), "lib"))
+	if err == nil || !strings.HasPrefix(err.Error(), "git checkout: ") {
+		t.Errorf("err = %v, want it to start with 'git checkout: '", err)
+	}
+}
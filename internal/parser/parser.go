@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/MichelLacerda/nox/internal/ast"
 	"github.com/MichelLacerda/nox/internal/token"
@@ -10,6 +11,7 @@ import (
 type Parser struct {
 	tokens  []*token.Token
 	current int
+	errors  ParserErrors // erros recuperados dentro de blocos
 }
 
 type ParserError struct {
@@ -18,7 +20,22 @@ type ParserError struct {
 }
 
 func (e ParserError) Error() string {
-	return fmt.Sprintf("Parser Error at %s: %s", e.Token.Lexeme, e.Message)
+	where := "'" + e.Token.Lexeme + "'"
+	if e.Token.Type == token.TokenType_EOF {
+		where = "end"
+	}
+	return fmt.Sprintf("[line %d:%d] Error at %s: %s", e.Token.Line, e.Token.Column, where, e.Message)
+}
+
+// ParserErrors reúne todos os erros encontrados em uma passada do parser.
+type ParserErrors []ParserError
+
+func (e ParserErrors) Error() string {
+	messages := make([]string, len(e))
+	for idx, err := range e {
+		messages[idx] = err.Error()
+	}
+	return strings.Join(messages, "\n")
 }
 
 func NewParser(tokens []*token.Token) *Parser {
@@ -28,22 +45,42 @@ func NewParser(tokens []*token.Token) *Parser {
 	}
 }
 
+// Parse analisa o programa inteiro. Ao encontrar um erro, sincroniza no
+// próximo início de declaração e continua, devolvendo todos os erros
+// encontrados como ParserErrors.
 func (p *Parser) Parse() ([]ast.Stmt, error) {
 	statements := []ast.Stmt{}
+	p.errors = nil
 
 	for !p.IsAtEnd() {
 		d, err := p.declaration()
 
 		if err != nil {
-			return nil, err
+			p.recordError(err)
+			p.Synchronize()
+			continue
 		}
 
 		statements = append(statements, d)
 	}
 
+	if len(p.errors) > 0 {
+		return statements, p.errors
+	}
 	return statements, nil
 }
 
+func (p *Parser) recordError(err error) {
+	perr, ok := err.(ParserError)
+	if !ok {
+		perr = ParserError{Token: p.Peek(), Message: err.Error()}
+	}
+	if perr.Token != nil && perr.Token.Type == token.TokenType_Unknown {
+		return // o scanner já reportou o erro desse trecho
+	}
+	p.errors = append(p.errors, perr)
+}
+
 func (p *Parser) declaration() (ast.Stmt, error) {
 	if p.Match(token.TokenType_EXPORT) {
 		decl, err := p.exportDeclaration()
@@ -112,6 +149,11 @@ func (p *Parser) exportDeclaration() (ast.Stmt, error) {
 }
 
 func (p *Parser) ImportStmt() (ast.Stmt, error) {
+	keyword := p.Previous()
+	if p.Match(token.TokenType_LEFT_BRACE) {
+		return p.ImportNames(keyword)
+	}
+
 	pathToken, err := p.Consume(token.TokenType_STRING, "Expect module path.")
 	if err != nil {
 		return nil, err
@@ -137,7 +179,53 @@ func (p *Parser) ImportStmt() (ast.Stmt, error) {
 	// Optional semicolon
 	p.Match(token.TokenType_SEMICOLON)
 
-	return &ast.ImportStmt{Path: pathToken, Alias: aliasToken}, nil
+	return &ast.ImportStmt{Keyword: keyword, Path: pathToken, Alias: aliasToken}, nil
+}
+
+// ImportNames lê "{ a, b as c } from "<path>"" depois de "import". "from"
+// não é palavra reservada: só tem esse papel aqui.
+func (p *Parser) ImportNames(keyword *token.Token) (ast.Stmt, error) {
+	names := []*ast.ImportName{}
+	for !p.Check(token.TokenType_RIGHT_BRACE) {
+		name, err := p.Consume(token.TokenType_IDENTIFIER, "Expect name to import.")
+		if err != nil {
+			return nil, err
+		}
+		importName := &ast.ImportName{Name: name}
+		if p.Match(token.TokenType_AS) {
+			alias, err := p.Consume(token.TokenType_IDENTIFIER, "Expect identifier after 'as'.")
+			if err != nil {
+				return nil, err
+			}
+			importName.Alias = alias
+		}
+		names = append(names, importName)
+		if !p.Match(token.TokenType_COMMA) {
+			break
+		}
+	}
+	if _, err := p.Consume(token.TokenType_RIGHT_BRACE, "Expect '}' after imported names."); err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, ParserError{Token: p.Previous(), Message: "Expect at least one name to import."}
+	}
+
+	from := p.Peek()
+	if from.Type != token.TokenType_IDENTIFIER || from.Lexeme != "from" {
+		return nil, ParserError{Token: from, Message: "Expect 'from' after imported names."}
+	}
+	p.Advance()
+
+	pathToken, err := p.Consume(token.TokenType_STRING, "Expect module path after 'from'.")
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional semicolon
+	p.Match(token.TokenType_SEMICOLON)
+
+	return &ast.ImportStmt{Keyword: keyword, Path: pathToken, Names: names}, nil
 }
 
 func (p *Parser) ClassDeclaration() (ast.Stmt, error) {
@@ -155,7 +243,10 @@ func (p *Parser) ClassDeclaration() (ast.Stmt, error) {
 
 		expr := ast.Expr(&ast.VariableExpr{Name: tok})
 		for p.Match(token.TokenType_DOT) {
-			name, _ := p.Consume(token.TokenType_IDENTIFIER, "Expect property name after '.'.")
+			name, err := p.Consume(token.TokenType_IDENTIFIER, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
 			expr = &ast.GetExpr{
 				Object: expr,
 				Name:   name,
@@ -305,11 +396,13 @@ func (p *Parser) Statement() (ast.Stmt, error) {
 	// }
 
 	if p.Match(token.TokenType_LEFT_BRACE) {
+		brace := p.Previous()
 		stmts, err := p.Block()
 		if err != nil {
 			return nil, err
 		}
 		return &ast.BlockStmt{
+			Brace:      brace,
 			Statements: stmts,
 		}, nil
 	}
@@ -318,6 +411,7 @@ func (p *Parser) Statement() (ast.Stmt, error) {
 }
 
 func (p *Parser) WithStatement() (ast.Stmt, error) {
+	keyword := p.Previous()
 	resourceExpr, err := p.Expression()
 	if err != nil {
 		return nil, err
@@ -338,6 +432,7 @@ func (p *Parser) WithStatement() (ast.Stmt, error) {
 	}
 
 	return &ast.WithStmt{
+		Keyword:  keyword,
 		Resource: resourceExpr,
 		Alias:    alias,
 		Body:     body,
@@ -366,15 +461,19 @@ func (p *Parser) ReturnStatement() (ast.Stmt, error) {
 }
 
 func (p *Parser) ForInStatement() (ast.Stmt, error) {
+	keyword := p.Previous()
+
 	// Suporta o estilo Go: for { ... }
 	if p.Match(token.TokenType_LEFT_BRACE) {
+		brace := p.Previous()
 		bodyStmts, err := p.Block()
 		if err != nil {
 			return nil, err
 		}
-		body := &ast.BlockStmt{Statements: bodyStmts}
+		body := &ast.BlockStmt{Brace: brace, Statements: bodyStmts}
 
 		return &ast.ForInStmt{
+			Keyword:  keyword,
 			IndexVar: nil,
 			ValueVar: nil,
 			Iterable: &ast.LiteralExpr{Value: true}, // sinaliza loop infinito
@@ -419,6 +518,7 @@ func (p *Parser) ForInStatement() (ast.Stmt, error) {
 	}
 
 	return &ast.ForInStmt{
+		Keyword:  keyword,
 		IndexVar: indexVar,
 		ValueVar: valueVar,
 		Iterable: iterable,
@@ -533,21 +633,21 @@ func (p *Parser) ForInStatement() (ast.Stmt, error) {
 // }
 
 func (p *Parser) IfStatement() (ast.Stmt, error) {
-	// if _, err := p.Consume(token.TokenType_LEFT_PAREN, "Expect '(' after 'if'."); err != nil {
-	// 	return nil, err
-	// }
-	p.Match(token.TokenType_LEFT_PAREN)
+	keyword := p.Previous()
+
+	// Os parênteses da condição são opcionais, mas quem abre tem que fechar.
+	paren := p.Match(token.TokenType_LEFT_PAREN)
 
 	condition, err := p.Expression()
 	if err != nil {
 		return nil, err
 	}
 
-	p.Match(token.TokenType_RIGHT_PAREN)
-
-	// if _, err := p.Consume(token.TokenType_RIGHT_PAREN, "Expect ')' after if condition."); err != nil {
-	// 	return nil, err
-	// }
+	if paren {
+		if _, err := p.Consume(token.TokenType_RIGHT_PAREN, "Expect ')' after if condition."); err != nil {
+			return nil, err
+		}
+	}
 
 	thenStmt, err := p.Statement()
 	if err != nil {
@@ -563,6 +663,7 @@ func (p *Parser) IfStatement() (ast.Stmt, error) {
 	}
 
 	return &ast.IfStmt{
+		Keyword:   keyword,
 		Condition: condition,
 		Then:      thenStmt,
 		Else:      elseStmt,
@@ -575,7 +676,11 @@ func (p *Parser) Block() ([]ast.Stmt, error) {
 	for !p.IsAtEnd() && !p.Check(token.TokenType_RIGHT_BRACE) {
 		stmt, err := p.declaration()
 		if err != nil {
-			return nil, err
+			// Registra o erro e continua no mesmo bloco, para não perder
+			// o '}' que o fecha e gerar erros em cascata.
+			p.recordError(err)
+			p.synchronizeBlock()
+			continue
 		}
 		statements = append(statements, stmt)
 	}
@@ -589,6 +694,7 @@ func (p *Parser) Block() ([]ast.Stmt, error) {
 }
 
 func (p *Parser) PrintStatement() (ast.Stmt, error) {
+	keyword := p.Previous()
 	var expressions []ast.Expr
 
 	for {
@@ -610,7 +716,7 @@ func (p *Parser) PrintStatement() (ast.Stmt, error) {
 	// Optional semicolon
 	p.Match(token.TokenType_SEMICOLON)
 
-	return &ast.PrintStmt{Expressions: expressions}, nil
+	return &ast.PrintStmt{Keyword: keyword, Expressions: expressions}, nil
 }
 
 func (p *Parser) ExpressionStatement() (ast.Stmt, error) {
@@ -659,9 +765,10 @@ func (p *Parser) Assignment() (ast.Expr, error) {
 			}, nil
 		case *ast.IndexExpr:
 			return &ast.SetIndexExpr{
-				Object: assign.Object,
-				Index:  assign.Index,
-				Value:  value,
+				Object:  assign.Object,
+				Index:   assign.Index,
+				Value:   value,
+				Bracket: assign.Bracket,
 			}, nil
 		}
 
@@ -868,12 +975,15 @@ func (p *Parser) Call() (ast.Expr, error) {
 				Name:   token,
 			}
 		} else if p.Match(token.TokenType_LEFT_BRACKET) {
+			bracket := p.Previous()
 			index, err := p.Expression()
 			if err != nil {
 				return nil, err
 			}
-			p.Consume(token.TokenType_RIGHT_BRACKET, "Expect ']' after index.")
-			expr = &ast.IndexExpr{Object: expr, Index: index}
+			if _, err := p.Consume(token.TokenType_RIGHT_BRACKET, "Expect ']' after index."); err != nil {
+				return nil, err
+			}
+			expr = &ast.IndexExpr{Object: expr, Index: index, Bracket: bracket}
 		} else {
 			break
 		}
@@ -911,38 +1021,46 @@ func (p *Parser) FinishCall(callee ast.Expr) (ast.Expr, error) {
 
 func (p *Parser) Primary() (ast.Expr, error) {
 	if p.Match(token.TokenType_FALSE) {
-		return &ast.LiteralExpr{Value: false}, nil
+		return &ast.LiteralExpr{Value: false, Token: p.Previous()}, nil
 	}
 
 	if p.Match(token.TokenType_TRUE) {
-		return &ast.LiteralExpr{Value: true}, nil
+		return &ast.LiteralExpr{Value: true, Token: p.Previous()}, nil
 	}
 
 	if p.Match(token.TokenType_NIL) {
-		return &ast.LiteralExpr{Value: nil}, nil
+		return &ast.LiteralExpr{Value: nil, Token: p.Previous()}, nil
 	}
 
 	if p.Match(token.TokenType_NUMBER, token.TokenType_STRING) {
-		return &ast.LiteralExpr{Value: p.Previous().Literal}, nil
+		return &ast.LiteralExpr{Value: p.Previous().Literal, Token: p.Previous()}, nil
 	}
 
 	if p.Match(token.TokenType_LEFT_BRACE) {
+		brace := p.Previous()
 		var pairs []ast.DictPair
 		for !p.Check(token.TokenType_RIGHT_BRACE) && !p.IsAtEnd() {
 			key, err := p.Expression()
 			if err != nil {
 				return nil, err
 			}
-			p.Consume(token.TokenType_COLON, "Expect ':' after key.")
+			if _, err := p.Consume(token.TokenType_COLON, "Expect ':' after key."); err != nil {
+				return nil, err
+			}
 			value, err := p.Expression()
+			if err != nil {
+				return nil, err
+			}
 			pairs = append(pairs, ast.DictPair{Key: key, Value: value})
 
 			if !p.Match(token.TokenType_COMMA) {
 				break
 			}
 		}
-		p.Consume(token.TokenType_RIGHT_BRACE, "Expect '}' after dictionary.")
-		return &ast.DictExpr{Pairs: pairs}, nil
+		if _, err := p.Consume(token.TokenType_RIGHT_BRACE, "Expect '}' after dictionary."); err != nil {
+			return nil, err
+		}
+		return &ast.DictExpr{Pairs: pairs, Brace: brace}, nil
 	}
 
 	if p.Match(token.TokenType_LEFT_BRACKET) {
@@ -964,7 +1082,10 @@ func (p *Parser) Primary() (ast.Expr, error) {
 			}
 		}
 
-		closing, _ := p.Consume(token.TokenType_RIGHT_BRACKET, "Expect ']' after list elements.")
+		closing, err := p.Consume(token.TokenType_RIGHT_BRACKET, "Expect ']' after list elements.")
+		if err != nil {
+			return nil, err
+		}
 
 		return &ast.ListExpr{
 			Elements: elements,
@@ -974,7 +1095,9 @@ func (p *Parser) Primary() (ast.Expr, error) {
 
 	if p.Match(token.TokenType_SUPER) {
 		keyword := p.Previous()
-		p.Consume(token.TokenType_DOT, "Expect '.' after 'super'.")
+		if _, err := p.Consume(token.TokenType_DOT, "Expect '.' after 'super'."); err != nil {
+			return nil, err
+		}
 		if method, err := p.Consume(token.TokenType_IDENTIFIER, "Expect superclass method name."); err == nil {
 			return &ast.SuperExpr{
 				Keyword: keyword,
@@ -1001,7 +1124,9 @@ func (p *Parser) Primary() (ast.Expr, error) {
 			return nil, err
 		}
 
-		p.Consume(token.TokenType_RIGHT_PAREN, "Expect ')' after expression.")
+		if _, err := p.Consume(token.TokenType_RIGHT_PAREN, "Expect ')' after expression."); err != nil {
+			return nil, err
+		}
 		return &ast.GroupingExpr{Expression: expr}, nil
 	}
 
@@ -1064,23 +1189,42 @@ func (p *Parser) Previous() *token.Token {
 func (p *Parser) Synchronize() {
 	p.Advance()
 	for !p.IsAtEnd() {
-		if p.Previous().Type == token.TokenType_SEMICOLON {
+		if p.Previous().Type == token.TokenType_SEMICOLON || startsStatement(p.Peek().Type) {
 			return
 		}
+		p.Advance()
+	}
+}
 
-		switch p.Peek().Type {
-		case
-			token.TokenType_CLASS,
-			token.TokenType_FUNC,
-			token.TokenType_LET,
-			token.TokenType_IF,
-			token.TokenType_FOR,
-			token.TokenType_WHILE,
-			token.TokenType_PRINT,
-			token.TokenType_RETURN:
+// synchronizeBlock é a recuperação usada dentro de blocos: além dos pontos
+// de Synchronize, para antes do '}' que fecha o bloco.
+func (p *Parser) synchronizeBlock() {
+	if p.Check(token.TokenType_RIGHT_BRACE) {
+		return
+	}
+	p.Advance()
+	for !p.IsAtEnd() {
+		if p.Previous().Type == token.TokenType_SEMICOLON ||
+			p.Check(token.TokenType_RIGHT_BRACE) ||
+			startsStatement(p.Peek().Type) {
 			return
 		}
-
 		p.Advance()
 	}
 }
+
+func startsStatement(t token.TokenType) bool {
+	switch t {
+	case
+		token.TokenType_CLASS,
+		token.TokenType_FUNC,
+		token.TokenType_LET,
+		token.TokenType_IF,
+		token.TokenType_FOR,
+		token.TokenType_WHILE,
+		token.TokenType_PRINT,
+		token.TokenType_RETURN:
+		return true
+	}
+	return false
+}
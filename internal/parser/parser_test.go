@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MichelLacerda/nox/internal/scanner"
+)
+
+// Todo delimitador ausente vira um erro, e o comando incompleto não chega à
+// AST: só os comandos seguintes, depois da sincronização.
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // linha:coluna mensagem
+	}{
+		{"print (1 + 2;\nprint 3;", "1:13 Expect ')' after expression."},
+		{"l[0;\nprint 3;", "1:4 Expect ']' after index."},
+		{"print [1, 2;\nprint 3;", "1:12 Expect ']' after list elements."},
+		{"let d = {\"a\" 1};\nprint 3;", "1:14 Expect ':' after key."},
+		{"let d = {\"a\": };\nprint 3;", "1:15 Expect expression."},
+		{"let d = {\"a\": 1;\nprint 3;", "1:16 Expect '}' after dictionary."},
+		{"let config = {\"name\": ", "1:23 Expect expression."},
+		{"print super x;\nprint 3;", "1:13 Expect '.' after 'super'."},
+		{"class A < B. {}\nprint 3;", "1:14 Expect property name after '.'."},
+		{"if (x < 1 print 2;\nprint 3;", "1:11 Expect ')' after if condition."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			tokens, err := scanner.NewScanner([]rune(tt.source)).ScanTokens()
+			if err != nil {
+				t.Fatal(err)
+			}
+			statements, err := NewParser(tokens).Parse()
+			errs, ok := err.(ParserErrors)
+			if !ok || len(errs) != 1 {
+				t.Fatalf("err = %v, want one parser error", err)
+			}
+			got := fmt.Sprintf("%d:%d %s", errs[0].Token.Line, errs[0].Token.Column, errs[0].Message)
+			if got != tt.want {
+				t.Errorf("error = %q, want %q", got, tt.want)
+			}
+			for _, stmt := range statements {
+				if stmt.String() != "print 3 ;" {
+					t.Errorf("incomplete statement reached the AST: %s", stmt)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Package pprof escreve perfis no formato do pprof (profile.proto compactado
+// com gzip), sem depender do módulo github.com/google/pprof.
+package pprof
+
+import (
+	"compress/gzip"
+	"io"
+	"time"
+)
+
+// ValueType descreve um dos valores de cada amostra, como ("time", "nanoseconds").
+type ValueType struct {
+	Type string
+	Unit string
+}
+
+// Function é uma função do programa perfilado.
+type Function struct {
+	ID        uint64
+	Name      string
+	File      string
+	StartLine int64
+}
+
+// Location é uma linha de uma função.
+type Location struct {
+	ID       uint64
+	Function uint64
+	Line     int64
+}
+
+// Sample é uma pilha, da folha para a raiz, e um valor para cada ValueType.
+type Sample struct {
+	Locations []uint64
+	Values    []int64
+}
+
+type Profile struct {
+	SampleTypes []ValueType
+	Samples     []Sample
+	Locations   []Location
+	Functions   []Function
+	Start       time.Time
+	Duration    time.Duration
+	PeriodType  ValueType
+	Period      int64
+}
+
+// Write grava o perfil compactado, pronto para "go tool pprof".
+func (p *Profile) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(p.encode()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// encode serializa o perfil conforme os números de campo de profile.proto.
+func (p *Profile) encode() []byte {
+	strings := &stringTable{index: map[string]int64{}}
+	strings.add("") // a posição 0 é sempre a string vazia
+
+	var b buffer
+	for _, vt := range p.SampleTypes {
+		b.message(1, valueType(strings, vt))
+	}
+	for _, s := range p.Samples {
+		var sb buffer
+		sb.packedUint(1, s.Locations)
+		sb.packedInt(2, s.Values)
+		b.message(2, sb)
+	}
+	for _, l := range p.Locations {
+		var line buffer
+		line.uint(1, l.Function)
+		line.int(2, l.Line)
+
+		var lb buffer
+		lb.uint(1, l.ID)
+		lb.message(4, line)
+		b.message(4, lb)
+	}
+	for _, f := range p.Functions {
+		var fb buffer
+		fb.uint(1, f.ID)
+		fb.int(2, strings.add(f.Name))
+		fb.int(3, strings.add(f.Name))
+		fb.int(4, strings.add(f.File))
+		fb.int(5, f.StartLine)
+		b.message(5, fb)
+	}
+
+	// A tabela de strings precisa estar completa antes de ser escrita
+	period := valueType(strings, p.PeriodType)
+	for _, s := range strings.values {
+		b.bytes(6, []byte(s))
+	}
+	b.int(9, p.Start.UnixNano())
+	b.int(10, int64(p.Duration))
+	b.message(11, period)
+	b.int(12, p.Period)
+	return b
+}
+
+func valueType(strings *stringTable, vt ValueType) buffer {
+	var b buffer
+	b.int(1, strings.add(vt.Type))
+	b.int(2, strings.add(vt.Unit))
+	return b
+}
+
+type stringTable struct {
+	values []string
+	index  map[string]int64
+}
+
+func (t *stringTable) add(s string) int64 {
+	if idx, ok := t.index[s]; ok {
+		return idx
+	}
+	idx := int64(len(t.values))
+	t.values = append(t.values, s)
+	t.index[s] = idx
+	return idx
+}
+
+// buffer acumula campos codificados em protobuf.
+type buffer []byte
+
+func (b *buffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *buffer) key(field int, wireType uint64) {
+	b.varint(uint64(field)<<3 | wireType)
+}
+
+// uint e int omitem valores zero, como o protobuf faz com campos padrão.
+func (b *buffer) uint(field int, v uint64) {
+	if v != 0 {
+		b.key(field, 0)
+		b.varint(v)
+	}
+}
+
+func (b *buffer) int(field int, v int64) {
+	b.uint(field, uint64(v))
+}
+
+func (b *buffer) bytes(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *buffer) message(field int, m buffer) {
+	b.bytes(field, m)
+}
+
+func (b *buffer) packedUint(field int, values []uint64) {
+	var packed buffer
+	for _, v := range values {
+		packed.varint(v)
+	}
+	b.bytes(field, packed)
+}
+
+func (b *buffer) packedInt(field int, values []int64) {
+	var packed buffer
+	for _, v := range values {
+		packed.varint(uint64(v))
+	}
+	b.bytes(field, packed)
+}
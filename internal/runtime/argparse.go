@@ -0,0 +1,374 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+type argSpec struct {
+	name       string // nome usado na linha de comando (ex: "dry-run")
+	key        string // chave no dict resultante (ex: "dry_run")
+	short      string
+	help       string
+	kind       string // "string", "number" ou "bool"
+	defaultVal any
+	required   bool
+	many       bool // apenas posicionais: consome o restante dos argumentos
+}
+
+// ArgParser declara flags e argumentos posicionais de um script e
+// transforma a linha de comando em um dict.
+type ArgParser struct {
+	prog        string
+	description string
+	flags       []*argSpec
+	positionals []*argSpec
+}
+
+func (p *ArgParser) String() string {
+	return fmt.Sprintf("<argparse %s>", p.prog)
+}
+
+func (p *ArgParser) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "flag":
+		return &BuiltinFunction{ArityValue: -1, CallFunc: func(i *Interpreter, args []any) any {
+			spec := p.declare(i, "argparse.flag", args)
+			if spec == nil {
+				return nil
+			}
+			if spec.kind == "bool" && spec.defaultVal == nil {
+				spec.defaultVal = false
+			}
+			p.flags = append(p.flags, spec)
+			return p
+		}}
+	case "positional":
+		return &BuiltinFunction{ArityValue: -1, CallFunc: func(i *Interpreter, args []any) any {
+			spec := p.declare(i, "argparse.positional", args)
+			if spec == nil {
+				return nil
+			}
+			if len(p.positionals) > 0 && p.positionals[len(p.positionals)-1].many {
+				i.Runtime.ReportRuntimeError(name, "argparse.positional: no positional can follow one with 'many'.")
+				return nil
+			}
+			p.positionals = append(p.positionals, spec)
+			return p
+		}}
+	case "help":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			return p.usage()
+		}}
+	case "parse":
+		return &BuiltinFunction{ArityValue: -1, CallFunc: func(i *Interpreter, args []any) any {
+			var argv []string
+			if len(args) == 0 {
+				if len(i.Runtime.Args) > 0 {
+					argv = i.Runtime.Args[1:]
+				}
+			} else {
+				list, ok := args[0].(*ListInstance)
+				if !ok {
+					i.Runtime.ReportRuntimeError(name, "argparse.parse(args?) expects a list of strings.")
+					return nil
+				}
+				for _, el := range list.Elements {
+					argv = append(argv, StringifyCompact(el))
+				}
+			}
+
+			result, err := p.parse(argv)
+			if err == errHelpRequested {
+				fmt.Fprintln(i.Runtime.stdout(), p.usage())
+				panic(&ExitError{Code: 0})
+			}
+			if err != nil {
+				fmt.Fprintf(i.Runtime.stderr(), "%s: error: %s\n\n%s\n", p.prog, err.Error(), p.usage())
+				panic(&ExitError{Code: 2}) // Convenção para erro de uso na linha de comando
+			}
+			return result
+		}}
+	default:
+		return nil
+	}
+}
+
+var errHelpRequested = fmt.Errorf("help requested")
+
+// declare interpreta (name, options?) comum a flag e positional.
+func (p *ArgParser) declare(i *Interpreter, fn string, args []any) *argSpec {
+	if len(args) < 1 || len(args) > 2 {
+		i.Runtime.ReportRuntimeError(nil, fn+"(name, options?) expects 1 or 2 arguments.")
+		return nil
+	}
+	name, ok := args[0].(string)
+	if !ok || strings.TrimLeft(name, "-") == "" {
+		i.Runtime.ReportRuntimeError(nil, fn+" expects a non-empty name.")
+		return nil
+	}
+	name = strings.TrimLeft(name, "-")
+	spec := &argSpec{
+		name: name,
+		key:  strings.ReplaceAll(name, "-", "_"),
+		kind: "string",
+	}
+
+	if len(args) == 2 && args[1] != nil {
+		opts, ok := args[1].(*DictInstance)
+		if !ok {
+			i.Runtime.ReportRuntimeError(nil, fn+" expects options to be a dict.")
+			return nil
+		}
+		for key, value := range opts.Entries {
+			switch key {
+			case "short":
+				s, ok := value.(string)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, fn+": option 'short' must be a string.")
+					return nil
+				}
+				spec.short = strings.TrimLeft(s, "-")
+			case "help":
+				spec.help = StringifyCompact(value)
+			case "type":
+				kind, _ := value.(string)
+				if kind != "string" && kind != "number" && kind != "bool" {
+					i.Runtime.ReportRuntimeError(nil, fn+": option 'type' must be \"string\", \"number\" or \"bool\".")
+					return nil
+				}
+				spec.kind = kind
+			case "default":
+				spec.defaultVal = value
+			case "required":
+				spec.required = i.isTruthy(value)
+			case "many":
+				spec.many = i.isTruthy(value)
+			default:
+				i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("%s: unknown option '%s'.", fn, key))
+				return nil
+			}
+		}
+	}
+	return spec
+}
+
+func (p *ArgParser) findFlag(name string, short bool) *argSpec {
+	for _, f := range p.flags {
+		if (!short && f.name == name) || (short && f.short != "" && f.short == name) {
+			return f
+		}
+	}
+	return nil
+}
+
+func convertArg(spec *argSpec, raw string) (any, error) {
+	switch spec.kind {
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("argument '%s' expects a number, got '%s'", spec.name, raw)
+		}
+		return n, nil
+	case "bool":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("argument '%s' expects true or false, got '%s'", spec.name, raw)
+		}
+		return b, nil
+	default:
+		return raw, nil
+	}
+}
+
+func (p *ArgParser) parse(argv []string) (*DictInstance, error) {
+	values := map[string]any{}
+	seen := map[string]bool{}
+	var rest []string
+
+	for idx := 0; idx < len(argv); idx++ {
+		arg := argv[idx]
+
+		if arg == "--" {
+			rest = append(rest, argv[idx+1:]...)
+			break
+		}
+		if arg == "--help" || arg == "-h" {
+			return nil, errHelpRequested
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" || isNumeric(arg) {
+			rest = append(rest, arg)
+			continue
+		}
+
+		short := !strings.HasPrefix(arg, "--")
+		name := strings.TrimLeft(arg, "-")
+		var inline *string
+		if eq := strings.Index(name, "="); eq >= 0 {
+			value := name[eq+1:]
+			inline = &value
+			name = name[:eq]
+		}
+
+		spec := p.findFlag(name, short)
+		if spec == nil {
+			return nil, fmt.Errorf("unknown flag '%s'", arg)
+		}
+
+		var raw string
+		switch {
+		case inline != nil:
+			raw = *inline
+		case spec.kind == "bool":
+			raw = "true"
+		case idx+1 < len(argv):
+			idx++
+			raw = argv[idx]
+		default:
+			return nil, fmt.Errorf("flag '%s' expects a value", arg)
+		}
+
+		value, err := convertArg(spec, raw)
+		if err != nil {
+			return nil, err
+		}
+		values[spec.key] = value
+		seen[spec.key] = true
+	}
+
+	for idx, spec := range p.positionals {
+		if spec.many {
+			items := []any{}
+			for _, raw := range rest[min(idx, len(rest)):] {
+				value, err := convertArg(spec, raw)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, value)
+			}
+			if spec.required && len(items) == 0 {
+				return nil, fmt.Errorf("missing required argument '%s'", spec.name)
+			}
+			values[spec.key] = NewListInstance(items)
+			seen[spec.key] = true
+			rest = nil
+			break
+		}
+		if idx < len(rest) {
+			value, err := convertArg(spec, rest[idx])
+			if err != nil {
+				return nil, err
+			}
+			values[spec.key] = value
+			seen[spec.key] = true
+		}
+	}
+
+	if len(p.positionals) == 0 || !p.positionals[len(p.positionals)-1].many {
+		if len(rest) > len(p.positionals) {
+			return nil, fmt.Errorf("unexpected argument '%s'", rest[len(p.positionals)])
+		}
+	}
+
+	for _, spec := range append(append([]*argSpec{}, p.flags...), p.positionals...) {
+		if seen[spec.key] {
+			continue
+		}
+		if spec.required {
+			return nil, fmt.Errorf("missing required argument '%s'", spec.name)
+		}
+		values[spec.key] = spec.defaultVal
+	}
+
+	return NewDictInstance(values), nil
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func (p *ArgParser) usage() string {
+	var sb strings.Builder
+	sb.WriteString("Usage: " + p.prog)
+	if len(p.flags) > 0 {
+		sb.WriteString(" [options]")
+	}
+	for _, pos := range p.positionals {
+		name := pos.name
+		if pos.many {
+			name += "..."
+		}
+		if pos.required {
+			sb.WriteString(" <" + name + ">")
+		} else {
+			sb.WriteString(" [" + name + "]")
+		}
+	}
+	sb.WriteString("\n")
+
+	if p.description != "" {
+		sb.WriteString("\n" + p.description + "\n")
+	}
+
+	if len(p.positionals) > 0 {
+		sb.WriteString("\nArguments:\n")
+		for _, pos := range p.positionals {
+			sb.WriteString(fmt.Sprintf("  %-20s %s\n", pos.name, describeArg(pos)))
+		}
+	}
+
+	flags := append([]*argSpec{}, p.flags...)
+	sort.SliceStable(flags, func(a, b int) bool { return flags[a].name < flags[b].name })
+	sb.WriteString("\nOptions:\n")
+	for _, f := range flags {
+		label := "--" + f.name
+		if f.short != "" {
+			label = "-" + f.short + ", " + label
+		}
+		if f.kind != "bool" {
+			label += " <" + f.kind + ">"
+		}
+		sb.WriteString(fmt.Sprintf("  %-20s %s\n", label, describeArg(f)))
+	}
+	sb.WriteString(fmt.Sprintf("  %-20s %s\n", "-h, --help", "show this help message"))
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func describeArg(spec *argSpec) string {
+	desc := spec.help
+	if spec.required {
+		desc += " (required)"
+	} else if spec.defaultVal != nil && spec.defaultVal != false {
+		desc += fmt.Sprintf(" (default: %s)", StringifyCompact(spec.defaultVal))
+	}
+	return strings.TrimSpace(desc)
+}
+
+func NewArgparseModule() *MapInstance {
+	return NewMapInstance(map[string]any{
+		"parser": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) > 1 {
+					i.Runtime.ReportRuntimeError(nil, "argparse.parser(description?) expects at most 1 argument.")
+					return nil
+				}
+				prog := "nox"
+				if len(i.Runtime.Args) > 0 {
+					prog = i.Runtime.Args[0]
+				}
+				parser := &ArgParser{prog: prog}
+				if len(args) == 1 && args[0] != nil {
+					parser.description = StringifyCompact(args[0])
+				}
+				return parser
+			},
+		},
+	})
+}
@@ -0,0 +1,133 @@
+package runtime
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testParser declara o parser do exemplo de docs/builtins.md.
+func testParser() *ArgParser {
+	return &ArgParser{
+		prog: "copy.nox",
+		flags: []*argSpec{
+			{name: "verbose", key: "verbose", short: "v", kind: "bool", defaultVal: false},
+			{name: "count", key: "count", short: "n", kind: "number", defaultVal: 1.0},
+			{name: "dry-run", key: "dry_run", kind: "bool", defaultVal: false},
+			{name: "mode", key: "mode", kind: "string"},
+		},
+		positionals: []*argSpec{
+			{name: "src", key: "src", kind: "string", required: true},
+			{name: "dest", key: "dest", kind: "string", many: true},
+		},
+	}
+}
+
+func TestArgParse(t *testing.T) {
+	tests := []struct {
+		name string
+		argv []string
+		want map[string]any
+	}{
+		{
+			name: "defaults",
+			argv: []string{"a"},
+			want: map[string]any{"verbose": false, "count": 1.0, "dry_run": false, "mode": nil, "src": "a", "dest": []any{}},
+		},
+		{
+			name: "long, short and inline values",
+			argv: []string{"--verbose", "-n", "3", "--mode=fast", "a", "b", "c"},
+			want: map[string]any{"verbose": true, "count": 3.0, "dry_run": false, "mode": "fast", "src": "a", "dest": []any{"b", "c"}},
+		},
+		{
+			name: "dashes become underscores",
+			argv: []string{"--dry-run", "--verbose=false", "a"},
+			want: map[string]any{"verbose": false, "count": 1.0, "dry_run": true, "mode": nil, "src": "a", "dest": []any{}},
+		},
+		{
+			name: "negative numbers and everything after -- are positional",
+			argv: []string{"-5", "--", "--verbose"},
+			want: map[string]any{"verbose": false, "count": 1.0, "dry_run": false, "mode": nil, "src": "-5", "dest": []any{"--verbose"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := testParser().parse(tt.argv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]any{}
+			for key, value := range result.Entries {
+				if list, ok := value.(*ListInstance); ok {
+					value = append([]any{}, list.Elements...)
+				}
+				got[key] = value
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse(%q) = %v, want %v", tt.argv, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgParseErrors(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want string
+	}{
+		{[]string{}, "missing required argument 'src'"},
+		{[]string{"--color", "a"}, "unknown flag '--color'"},
+		{[]string{"a", "--mode"}, "flag '--mode' expects a value"},
+		{[]string{"-n", "many", "a"}, "argument 'count' expects a number, got 'many'"},
+		{[]string{"--verbose=maybe", "a"}, "argument 'verbose' expects true or false, got 'maybe'"},
+		{[]string{"a", "-h"}, errHelpRequested.Error()},
+	}
+	for _, tt := range tests {
+		_, err := testParser().parse(tt.argv)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("parse(%q) err = %v, want %q", tt.argv, err, tt.want)
+		}
+	}
+
+	single := &ArgParser{prog: "p", positionals: []*argSpec{{name: "file", key: "file", kind: "string"}}}
+	if _, err := single.parse([]string{"a", "b"}); err == nil || err.Error() != "unexpected argument 'b'" {
+		t.Errorf("extra positional err = %v", err)
+	}
+}
+
+func TestArgParseUsage(t *testing.T) {
+	usage := testParser().usage()
+	for _, want := range []string{
+		"Usage: copy.nox [options] <src> [dest...]",
+		"-v, --verbose",
+		"-n, --count <number>",
+		"(default: 1)",
+		"(required)",
+		"-h, --help",
+	} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage is missing %q:\n%s", want, usage)
+		}
+	}
+}
+
+// os.args e parser.parse() recebem o que vem depois do script.
+func TestArgParseFromScript(t *testing.T) {
+	n := NewNox()
+	var out strings.Builder
+	n.Stdout, n.Stderr = &out, &out
+	n.Args = []string{"copy.nox", "-v", "src.txt"}
+	err := n.Run(`
+let p = argparse.parser("Copies files.");
+p.flag("verbose", {"short": "v", "type": "bool"});
+p.positional("src", {"required": true});
+let args = p.parse();
+print os.args, args["verbose"], args["src"];
+`, NewInterpreter(n, false))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if got := strings.TrimSpace(out.String()); got != "[copy.nox, -v, src.txt] true src.txt" {
+		t.Errorf("output = %q", got)
+	}
+}
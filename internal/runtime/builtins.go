@@ -1,7 +1,6 @@
 package runtime
 
 import (
-	"bufio"
 	"fmt"
 	"math"
 	"math/rand/v2"
@@ -66,6 +65,8 @@ func RegisterLenBuiltin(i *Interpreter) *BuiltinFunction {
 				return float64(len(v.Elements))
 			case *DictInstance:
 				return float64(len(v.Entries))
+			case *BytesInstance:
+				return float64(len(v.Data))
 			case *FileObject:
 				if v.File == nil {
 					i.Runtime.ReportRuntimeError(nil, "File is not open.")
@@ -149,13 +150,16 @@ func RegisterAssertBuiltin(i *Interpreter) *BuiltinFunction {
 
 			// modo debug → apenas imprime
 			if i.debug {
-				fmt.Printf("Assertion failed: %v\n", i.stringify(message))
+				fmt.Fprintf(i.Runtime.stderr(), "Assertion failed: %v\n", i.stringify(message))
 				return nil
 			}
 
 			// modo normal → erro fatal
-			i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "assert"}, fmt.Sprintf("Assertion failed: %v", message))
-			return nil
+			panic(&RuntimeError{
+				Token:   &token.Token{Lexeme: "assert"},
+				Message: fmt.Sprintf("Assertion failed: %v", message),
+				Kind:    ErrorAssertion,
+			})
 		},
 	}
 }
@@ -187,10 +191,7 @@ func RegisterIoBuiltins(i *Interpreter) *BuiltinFunction {
 				i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "open"}, "failed to open file: "+err.Error())
 				return nil
 			}
-			return &FileObject{
-				File:   f,
-				Reader: bufio.NewReader(f),
-			}
+			return NewFileObject(f)
 		},
 	}
 }
@@ -593,18 +594,18 @@ func RegisterTypeBuiltins(i *Interpreter) *MapInstance {
 }
 
 func RegisterMathConstants(i *Interpreter) {
-	i.globals.Define("PI", 3.141592)
-	i.globals.Define("E", 2.718281)
-	i.globals.Define("PHI", 1.618033)
-	i.globals.Define("TAU", 6.283185) // tau = 2 * PI
-	i.globals.Define("sqrt2", 1.414213)
-	i.globals.Define("sqrtE", 1.648721)
-	i.globals.Define("sqrtPi", 1.772453)
-	i.globals.Define("sqrtPhi", 1.272019)
-	i.globals.Define("ln2", 0.693147)
-	i.globals.Define("log2E", 1/0.693147)
-	i.globals.Define("ln10", 2.302585)
-	i.globals.Define("log10E", 1/2.302585)
+	i.builtins.DefineByName("PI", 3.141592)
+	i.builtins.DefineByName("E", 2.718281)
+	i.builtins.DefineByName("PHI", 1.618033)
+	i.builtins.DefineByName("TAU", 6.283185) // tau = 2 * PI
+	i.builtins.DefineByName("sqrt2", 1.414213)
+	i.builtins.DefineByName("sqrtE", 1.648721)
+	i.builtins.DefineByName("sqrtPi", 1.772453)
+	i.builtins.DefineByName("sqrtPhi", 1.272019)
+	i.builtins.DefineByName("ln2", 0.693147)
+	i.builtins.DefineByName("log2E", 1/0.693147)
+	i.builtins.DefineByName("ln10", 2.302585)
+	i.builtins.DefineByName("log10E", 1/2.302585)
 }
 
 func RegisterRandomBuiltins(i *Interpreter) *MapInstance {
@@ -643,7 +644,13 @@ func RegisterRandomBuiltins(i *Interpreter) *MapInstance {
 }
 
 func RegisterOsBuiltins(i *Interpreter) *MapInstance {
+	argv := make([]any, len(i.Runtime.Args))
+	for idx, arg := range i.Runtime.Args {
+		argv[idx] = arg
+	}
+
 	return NewMapInstance(map[string]any{
+		"args": NewListInstance(argv),
 		"exit": &BuiltinFunction{
 			ArityValue: 1,
 			CallFunc: func(i *Interpreter, args []any) any {
@@ -660,8 +667,8 @@ func RegisterOsBuiltins(i *Interpreter) *MapInstance {
 					i.Runtime.ReportRuntimeError(nil, "os.exit(code) expects a code between 0 and 255.")
 					return nil
 				}
-				os.Exit(int(code))
-				return nil // nunca alcançado, mas necessário para satisfazer a assinatura
+				// desfaz a pilha até Nox.Run, fechando os recursos de "with"
+				panic(&ExitError{Code: int(code)})
 			},
 		},
 		"exec": &BuiltinFunction{
@@ -924,16 +931,7 @@ func RegisterOsBuiltins(i *Interpreter) *MapInstance {
 					i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("os.path failed: %v", err))
 					return nil
 				}
-				return NewDictInstance(map[string]any{
-					"name":        info.Name(),
-					"size":        float64(info.Size()),
-					"mode":        float64(info.Mode()),
-					"mod_time":    info.ModTime().Format(time.RFC3339),
-					"is_dir":      info.IsDir(),
-					"is_file":     !info.IsDir(),
-					"is_symlink":  info.Mode()&os.ModeSymlink != 0,
-					"permissions": fmt.Sprintf("%04o", info.Mode().Perm()),
-				})
+				return fileInfoDict(info)
 			},
 		},
 	})
@@ -1177,7 +1175,7 @@ func RegisterPathBuiltins(i *Interpreter) *MapInstance {
 					i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("path.time failed: %v", err))
 					return nil
 				}
-				return info.ModTime()
+				return NewTimeInstance(info.ModTime())
 			},
 		},
 		"isdir": &BuiltinFunction{
@@ -1253,19 +1251,28 @@ func RegisterPathBuiltins(i *Interpreter) *MapInstance {
 }
 
 func RegisterBuiltins(i *Interpreter) {
-	i.globals.Define("clock", RegisterClockBuiltin(i))
-	i.globals.Define("len", RegisterLenBuiltin(i))
-	i.globals.Define("range", RegisterRangeBuiltin(i))
-	i.globals.Define("assert", RegisterAssertBuiltin(i))
-	i.globals.Define("open", RegisterIoBuiltins(i))
-	i.globals.Define("math", RegisterMathBuiltin(i))
-	i.globals.Define("fmt", RegisterFmtBuiltin(i))
-	i.globals.Define("type", RegisterTypeBuiltins(i))
-	i.globals.Define("random", RegisterRandomBuiltins(i))
-	i.globals.Define("os", RegisterOsBuiltins(i))
-	i.globals.Define("path", RegisterPathBuiltins(i))
-	i.globals.Define("http", NewHttpModule())
-	i.globals.Define("json", NewJsonModule())
+	i.builtins.DefineByName("clock", RegisterClockBuiltin(i))
+	i.builtins.DefineByName("len", RegisterLenBuiltin(i))
+	i.builtins.DefineByName("range", RegisterRangeBuiltin(i))
+	i.builtins.DefineByName("assert", RegisterAssertBuiltin(i))
+	i.builtins.DefineByName("open", RegisterIoBuiltins(i))
+	i.builtins.DefineByName("bytes", RegisterBytesBuiltin(i))
+	i.builtins.DefineByName("input", RegisterInputBuiltin(i))
+	i.builtins.DefineByName("eprint", RegisterEprintBuiltin(i))
+	i.builtins.DefineByName("io", NewIoModule(i.Runtime))
+	i.builtins.DefineByName("math", RegisterMathBuiltin(i))
+	i.builtins.DefineByName("fmt", RegisterFmtBuiltin(i))
+	i.builtins.DefineByName("type", RegisterTypeBuiltins(i))
+	i.builtins.DefineByName("random", RegisterRandomBuiltins(i))
+	i.builtins.DefineByName("os", RegisterOsBuiltins(i))
+	i.builtins.DefineByName("path", RegisterPathBuiltins(i))
+	i.builtins.DefineByName("http", NewHttpModule())
+	i.builtins.DefineByName("json", NewJsonModule())
+	i.builtins.DefineByName("regex", NewRegexModule())
+	i.builtins.DefineByName("time", NewTimeModule())
+	i.builtins.DefineByName("process", NewProcessModule())
+	i.builtins.DefineByName("argparse", NewArgparseModule())
+	i.builtins.DefineByName("test", NewTestModule())
 	RegisterMathConstants(i)
 }
 
@@ -1293,6 +1300,22 @@ func TypeOf(v any) any {
 		return "dict"
 	case *ListInstance:
 		return "list"
+	case *RegexInstance:
+		return "regex"
+	case *TimeInstance:
+		return "time"
+	case *DurationInstance:
+		return "duration"
+	case *ProcessInstance:
+		return "process"
+	case *ArgParser:
+		return "argparse"
+	case *StreamObject:
+		return "stream"
+	case *FileObject:
+		return "file"
+	case *BytesInstance:
+		return "bytes"
 	default:
 		return "unknown"
 	}
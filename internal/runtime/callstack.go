@@ -0,0 +1,173 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// StackFrame identifica uma chamada em andamento: a função executada, o
+// arquivo onde ela foi declarada e a última linha executada dentro dela.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+
+	env  *Environment  // escopo do chamador no momento em que chamou o próximo frame
+	call *ast.CallExpr // chamada em andamento; ver markCall
+}
+
+func (f StackFrame) String() string {
+	file := f.File
+	if file == "" {
+		file = "<unknown>"
+	}
+	return fmt.Sprintf("%s:%d in %s", file, f.Line, f.Function)
+}
+
+// resetStack descarta a pilha atual e cria o frame raiz do programa.
+func (i *Interpreter) resetStack(name, file string) {
+	i.currentFile = file
+	i.frames = []*StackFrame{{Function: name, File: file}}
+}
+
+// pushFrame empilha o frame de name, que começa na linha line. A linha vem
+// antes de checkDepth para que o traceback de um estouro de pilha mostre
+// onde cada chamada estava.
+func (i *Interpreter) pushFrame(name, file string, line int) {
+	if i.profiler != nil {
+		i.profiler.enterOrLeave(i, true)
+	}
+	if len(i.frames) > 0 {
+		i.frames[len(i.frames)-1].env = i.environment
+	}
+	i.frames = append(i.frames, &StackFrame{Function: name, File: file, Line: line})
+	i.checkDepth()
+}
+
+func (i *Interpreter) popFrame() {
+	if i.profiler != nil {
+		i.profiler.enterOrLeave(i, false)
+	}
+	if len(i.frames) > 0 {
+		i.frames = i.frames[:len(i.frames)-1]
+	}
+}
+
+// markLine registra a linha em execução no frame do topo.
+func (i *Interpreter) markLine(line int) {
+	if line > 0 && len(i.frames) > 0 {
+		i.frames[len(i.frames)-1].Line = line
+	}
+}
+
+// markCall registra a chamada feita pelo frame do topo, que termina com
+// endCall. Builtins costumam reportar erros sem token (ou com um token
+// sintético na linha 0); se a chamada falhar assim, captureStack usa a
+// posição dela no lugar.
+func (i *Interpreter) markCall(call *ast.CallExpr) {
+	i.markLine(call.Parenthesis.Line)
+	if len(i.frames) > 0 {
+		i.frames[len(i.frames)-1].call = call
+	}
+}
+
+// endCall encerra a chamada registrada por markCall, que retornou sem erro.
+func (i *Interpreter) endCall() {
+	if len(i.frames) > 0 {
+		i.frames[len(i.frames)-1].call = nil
+	}
+}
+
+// callToken devolve o token da chamada em andamento no frame do topo, para
+// erros que pertencem à linha que chamou, como o número de argumentos
+// errado; fallback quando não há chamada registrada.
+func (i *Interpreter) callToken(fallback *token.Token) *token.Token {
+	if len(i.frames) > 0 {
+		if call := i.frames[len(i.frames)-1].call; call != nil {
+			return callSite(call.Callee, call.Parenthesis)
+		}
+	}
+	return fallback
+}
+
+// captureStack anexa ao erro uma cópia da pilha no ponto em que ele ocorreu.
+// Só a primeira captura vale, pois é a mais profunda.
+func (i *Interpreter) captureStack(err *RuntimeError) {
+	if err.Stack != nil {
+		return
+	}
+	if err.Token == nil || err.Token.Line == 0 {
+		err.Token = i.callToken(err.Token)
+	}
+	// A linha de cada frame vem de execute e markCall. O token do erro não a
+	// altera: ele pode ser de outro frame ou de outro arquivo.
+	err.Stack = make([]StackFrame, len(i.frames))
+	for idx, frame := range i.frames {
+		err.Stack[idx] = *frame
+	}
+}
+
+// captureOnPanic é usado com defer em pontos que descartam frames, para que
+// o erro leve a pilha antes de ela ser desfeita.
+func (i *Interpreter) captureOnPanic() {
+	if r := recover(); r != nil {
+		if err, ok := r.(*RuntimeError); ok {
+			i.captureStack(err)
+		}
+		panic(r)
+	}
+}
+
+// displayPath encurta caminhos absolutos relativos ao diretório de trabalho.
+func displayPath(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// callSite devolve o token usado para erros de builtins que não informam
+// posição: o nome da função chamada, na linha do parêntese.
+func callSite(callee ast.Expr, paren *token.Token) *token.Token {
+	site := *paren
+	switch expr := callee.(type) {
+	case *ast.VariableExpr:
+		site.Lexeme = expr.Name.Lexeme
+	case *ast.GetExpr:
+		site.Lexeme = expr.Name.Lexeme
+	}
+	return &site
+}
+
+func (e *RuntimeError) Traceback() string {
+	if len(e.Stack) == 0 {
+		return e.Error()
+	}
+	var sb strings.Builder
+	sb.WriteString("Traceback (most recent call last):\n")
+	// frames iguais seguidos (recursão) aparecem uma vez, como no Python
+	previous, repeated := "", 0
+	flush := func() {
+		if repeated > 0 {
+			fmt.Fprintf(&sb, "  [previous frame repeated %d more times]\n", repeated)
+			repeated = 0
+		}
+	}
+	for _, frame := range e.Stack {
+		line := frame.String()
+		if line == previous {
+			repeated++
+			continue
+		}
+		flush()
+		sb.WriteString("  " + line + "\n")
+		previous = line
+	}
+	flush()
+	sb.WriteString(e.Error())
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+package runtime
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// runError executa source como script.nox e devolve o erro de execução.
+func runError(t *testing.T, source string) *RuntimeError {
+	t.Helper()
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	i := NewInterpreter(n, false)
+	i.resetStack("<script>", "script.nox")
+	var err *RuntimeError
+	if !errors.As(n.Run(source, i), &err) {
+		t.Fatal("script did not fail")
+	}
+	return err
+}
+
+func TestTraceback(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		absent string // o traceback não pode conter
+	}{
+		{
+			name: "builtin error takes the call site",
+			source: `func inner(x) {
+    let a = 1;
+    return len(x);
+}
+func outer() {
+    return inner(3);
+}
+outer();`,
+			want: `Traceback (most recent call last):
+  script.nox:8 in <script>
+  script.nox:6 in outer
+  script.nox:3 in inner
+[line 3] RuntimeError at 'len': len() expects a string, list, dict, or file, but got float64.`,
+		},
+		{
+			name: "method call site",
+			source: `let items = [1, 2];
+func run() {
+    items.insert("x", 1);
+}
+run();`,
+			want: "[line 3] RuntimeError at 'insert': insert(index, value) expects index as number.",
+		},
+		{
+			name: "a caught error does not leak its call site",
+			source: `func check() {
+    ?len(1);
+    for line in io.stdout {
+    }
+}
+check();`,
+			want:   "RuntimeError: Cannot iterate over stdout: it is not readable.",
+			absent: "'len'",
+		},
+		{
+			name: "error inside a function called by the failing line",
+			source: `func fail() {
+    let x = nil;
+    x.go();
+}
+func caller() {
+    print 1;
+    fail();
+}
+caller();`,
+			want: `  script.nox:9 in <script>
+  script.nox:7 in caller
+  script.nox:3 in fail
+[line 3]`,
+		},
+		{
+			name: "undefined variable at the top level",
+			source: `let a = 1;
+let b = 2;
+print zzz;`,
+			want: `  script.nox:3 in <script>
+[line 3] RuntimeError at 'zzz': Undefined variable: zzz`,
+		},
+		{
+			name: "frame shows the line being run, not the declaration",
+			source: `func f() {
+    let x = 1;
+    print zzz;
+}
+f();`,
+			want: `  script.nox:5 in <script>
+  script.nox:3 in f
+[line 3]`,
+		},
+		{
+			name: "arity error is reported at the call site",
+			source: `func f(a, b) {
+    return a;
+}
+func g() {
+    let y = 0;
+    return f(1);
+}
+g();`,
+			want: `  script.nox:8 in <script>
+  script.nox:6 in g
+[line 6] RuntimeError at 'f': Expected 2 arguments but got 1.`,
+			absent: "script.nox:1 in",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runError(t, tt.source)
+			got := err.Traceback()
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("traceback:\n%s\nwant it to contain:\n%s", got, tt.want)
+			}
+			if tt.absent != "" && strings.Contains(got, tt.absent) {
+				t.Errorf("traceback:\n%s\nmust not contain %s", got, tt.absent)
+			}
+		})
+	}
+}
@@ -0,0 +1,504 @@
+package runtime
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+)
+
+// Coverage registra quais comandos e ramos de um programa foram executados.
+// Cada arquivo carregado (o script e os módulos importados) tem seus
+// comandos registrados ao ser analisado; durante a execução só se conta
+// quantas vezes cada comando rodou. Linhas, ramos e funções são calculados
+// no relatório, a partir da linha dos tokens de cada comando.
+//
+// Os ramos são os dois caminhos de cada if, de cada for-in (o corpo rodou
+// ou não havia o que percorrer) e de cada "and"/"or" (o operando da direita
+// foi avaliado ou a expressão parou no da esquerda). O "for { }" sem
+// coleção não tem decisão e fica de fora.
+//
+// Uma mesma Coverage pode ser usada em várias execuções, somando os
+// resultados. Handlers de http.serve a atualizam em outras goroutines, e os
+// relatórios podem ser gerados durante a execução (Ctrl+C).
+type Coverage struct {
+	mu    sync.Mutex
+	files map[string]*coverageFile // caminho absoluto → arquivo
+	hits  map[ast.Stmt]int
+	loops map[*ast.ForInStmt]*[2]int   // execuções com e sem iterações
+	logic map[*ast.LogicalExpr]*[2]int // avaliações com e sem o lado direito
+}
+
+type coverageFile struct {
+	path   string
+	parses []*coverageParse // uma por vez que o arquivo foi carregado
+}
+
+// coverageParse são os comandos de uma análise do arquivo. Análises do mesmo
+// arquivo têm a mesma forma, e os resultados são somados por posição.
+type coverageParse struct {
+	statements []ast.Stmt
+	branches   []any // *ast.IfStmt, *ast.ForInStmt e *ast.LogicalExpr
+	functions  []coverageFunction
+}
+
+type coverageFunction struct {
+	name string
+	decl *ast.FunctionStmt
+}
+
+func NewCoverage() *Coverage {
+	return &Coverage{
+		files: map[string]*coverageFile{},
+		hits:  map[ast.Stmt]int{},
+		loops: map[*ast.ForInStmt]*[2]int{},
+		logic: map[*ast.LogicalExpr]*[2]int{},
+	}
+}
+
+// AttachCoverage liga a medição de cobertura ao interpretador.
+func (i *Interpreter) AttachCoverage(c *Coverage) {
+	i.coverage = c
+}
+
+// register guarda os comandos de um arquivo recém-analisado.
+func (c *Coverage) register(file string, statements []ast.Stmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path, err := filepath.Abs(file)
+	if err != nil {
+		path = file
+	}
+	cf, ok := c.files[path]
+	if !ok {
+		cf = &coverageFile{path: path}
+		c.files[path] = cf
+	}
+	parse := &coverageParse{}
+	parse.walk(statements)
+	cf.parses = append(cf.parses, parse)
+}
+
+func (cp *coverageParse) walk(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		if stmt == nil {
+			continue
+		}
+		if stmtLine(stmt) > 0 {
+			cp.statements = append(cp.statements, stmt)
+		}
+
+		switch s := stmt.(type) {
+		case *ast.BlockStmt:
+			cp.walk(s.Statements)
+		case *ast.ExportStmt:
+			cp.walk([]ast.Stmt{s.Declaration})
+		case *ast.FunctionStmt:
+			cp.functions = append(cp.functions, coverageFunction{s.Name.Lexeme, s})
+			cp.walk(s.Body)
+		case *ast.ClassStmt:
+			// Métodos não são comandos executados: só o corpo conta
+			for _, method := range s.Methods {
+				cp.functions = append(cp.functions, coverageFunction{s.Name.Lexeme + "." + method.Name.Lexeme, method})
+				cp.walk(method.Body)
+			}
+		case *ast.ExpressionStmt:
+			cp.exprs(s.Expression)
+		case *ast.PrintStmt:
+			cp.exprs(s.Expressions...)
+		case *ast.ReturnStmt:
+			cp.exprs(s.Value)
+		case *ast.VarStmt:
+			cp.exprs(s.Initializer)
+		case *ast.IfStmt:
+			cp.branches = append(cp.branches, s)
+			cp.exprs(s.Condition)
+			cp.walk([]ast.Stmt{s.Then, s.Else})
+		case *ast.ForInStmt:
+			if s.ValueVar != nil {
+				cp.branches = append(cp.branches, s)
+				cp.exprs(s.Iterable)
+			}
+			cp.walk([]ast.Stmt{s.Body})
+		case *ast.WithStmt:
+			cp.exprs(s.Resource)
+			cp.walk([]ast.Stmt{s.Body})
+		}
+	}
+}
+
+// exprs procura os "and"/"or" dentro das expressões de um comando.
+func (cp *coverageParse) exprs(exprs ...ast.Expr) {
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case *ast.LogicalExpr:
+			cp.branches = append(cp.branches, e)
+			cp.exprs(e.Left, e.Right)
+		case *ast.AssignExpr:
+			cp.exprs(e.Value)
+		case *ast.BinaryExpr:
+			cp.exprs(e.Left, e.Right)
+		case *ast.CallExpr:
+			cp.exprs(e.Callee)
+			cp.exprs(e.Arguments...)
+		case *ast.GetExpr:
+			cp.exprs(e.Object)
+		case *ast.GroupingExpr:
+			cp.exprs(e.Expression)
+		case *ast.SetExpr:
+			cp.exprs(e.Object, e.Value)
+		case *ast.UnaryExpr:
+			cp.exprs(e.Right)
+		case *ast.ListExpr:
+			cp.exprs(e.Elements...)
+		case *ast.IndexExpr:
+			cp.exprs(e.Object, e.Index)
+		case *ast.SetIndexExpr:
+			cp.exprs(e.Object, e.Index, e.Value)
+		case *ast.DictExpr:
+			for _, pair := range e.Pairs {
+				cp.exprs(pair.Key, pair.Value)
+			}
+		case *ast.SafeExpr:
+			cp.exprs(e.Expr)
+		}
+	}
+}
+
+// statement é chamado pelo interpretador antes de cada comando.
+func (c *Coverage) statement(stmt ast.Stmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hits[stmt]++
+}
+
+// count devolve quantas vezes stmt executou.
+func (c *Coverage) count(stmt ast.Stmt) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits[stmt]
+}
+
+// loop registra o fim de uma execução de for-in; before é a contagem do
+// corpo antes do laço começar.
+func (c *Coverage) loop(stmt *ast.ForInStmt, before int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts, ok := c.loops[stmt]
+	if !ok {
+		counts = &[2]int{}
+		c.loops[stmt] = counts
+	}
+	if c.hits[stmt.Body] > before {
+		counts[0]++
+	} else {
+		counts[1]++
+	}
+}
+
+// logical registra uma avaliação de "and"/"or"; right diz se o operando da
+// direita foi avaliado.
+func (c *Coverage) logical(expr *ast.LogicalExpr, right bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts, ok := c.logic[expr]
+	if !ok {
+		counts = &[2]int{}
+		c.logic[expr] = counts
+	}
+	if right {
+		counts[0]++
+	} else {
+		counts[1]++
+	}
+}
+
+// CoverageFile é o resultado da cobertura de um arquivo.
+type CoverageFile struct {
+	Path      string
+	Lines     map[int]int // linha → execuções, só linhas com comandos
+	Branches  []CoverageBranch
+	Functions []CoverageFunction
+}
+
+// CoverageBranch é um dos caminhos de um if, for-in, "and" ou "or". Taken é
+// -1 quando o próprio comando ou expressão nunca executou.
+type CoverageBranch struct {
+	Line   int
+	Block  int // índice da decisão no arquivo
+	Branch int // 0: then / corpo do laço / lado direito, 1: else / laço sem iterações / atalho
+	Taken  int
+}
+
+type CoverageFunction struct {
+	Name  string
+	Line  int
+	Calls int
+}
+
+// LinesHit conta as linhas executadas ao menos uma vez.
+func (f *CoverageFile) LinesHit() int {
+	hit := 0
+	for _, count := range f.Lines {
+		if count > 0 {
+			hit++
+		}
+	}
+	return hit
+}
+
+// BranchesHit conta os ramos seguidos ao menos uma vez.
+func (f *CoverageFile) BranchesHit() int {
+	hit := 0
+	for _, b := range f.Branches {
+		if b.Taken > 0 {
+			hit++
+		}
+	}
+	return hit
+}
+
+// Files devolve o resultado de cada arquivo, em ordem de caminho.
+func (c *Coverage) Files() []*CoverageFile {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	paths := make([]string, 0, len(c.files))
+	for path := range c.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := make([]*CoverageFile, 0, len(paths))
+	for _, path := range paths {
+		cf := c.files[path]
+		file := &CoverageFile{Path: path, Lines: map[int]int{}}
+		first := cf.parses[0]
+
+		// Vários comandos na mesma linha contam como a linha mais executada
+		for idx, stmt := range first.statements {
+			hits := 0
+			for _, parse := range cf.parses {
+				if idx < len(parse.statements) {
+					hits += c.hits[parse.statements[idx]]
+				}
+			}
+			line := stmtLine(stmt)
+			file.Lines[line] = max(file.Lines[line], hits)
+		}
+
+		for block, decision := range first.branches {
+			taken := [2]int{-1, -1}
+			for _, parse := range cf.parses {
+				if block >= len(parse.branches) {
+					continue
+				}
+				counts, ran := c.branchCounts(parse.branches[block])
+				if !ran {
+					continue
+				}
+				taken[0] = max(taken[0], 0) + counts[0]
+				taken[1] = max(taken[1], 0) + counts[1]
+			}
+			for branch, count := range taken {
+				file.Branches = append(file.Branches, CoverageBranch{branchLine(decision), block, branch, count})
+			}
+		}
+
+		for idx, fn := range first.functions {
+			calls := 0
+			for _, parse := range cf.parses {
+				if idx < len(parse.functions) && len(parse.functions[idx].decl.Body) > 0 {
+					calls += c.hits[parse.functions[idx].decl.Body[0]]
+				}
+			}
+			file.Functions = append(file.Functions, CoverageFunction{fn.name, fn.decl.Name.Line, calls})
+		}
+		result = append(result, file)
+	}
+	return result
+}
+
+// branchCounts devolve quantas vezes cada ramo de uma decisão foi seguido,
+// e se ela chegou a executar.
+func (c *Coverage) branchCounts(decision any) ([2]int, bool) {
+	switch s := decision.(type) {
+	case *ast.IfStmt:
+		if runs := c.hits[s]; runs > 0 {
+			then := c.hits[s.Then]
+			return [2]int{then, runs - then}, true
+		}
+	case *ast.ForInStmt:
+		if counts, ok := c.loops[s]; ok {
+			return *counts, true
+		}
+	case *ast.LogicalExpr:
+		if counts, ok := c.logic[s]; ok {
+			return *counts, true
+		}
+	}
+	return [2]int{}, false
+}
+
+// branchLine é a linha de uma decisão: a do comando ou a do operador.
+func branchLine(decision any) int {
+	if expr, ok := decision.(*ast.LogicalExpr); ok {
+		return expr.Operator.Line
+	}
+	return stmtLine(decision.(ast.Stmt))
+}
+
+// WriteLCOV grava a cobertura no formato tracefile do LCOV (geninfo).
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	var sb strings.Builder
+	for _, file := range c.Files() {
+		sb.WriteString("TN:\n")
+		fmt.Fprintf(&sb, "SF:%s\n", file.Path)
+
+		called := 0
+		for _, fn := range file.Functions {
+			fmt.Fprintf(&sb, "FN:%d,%s\n", fn.Line, fn.Name)
+		}
+		for _, fn := range file.Functions {
+			fmt.Fprintf(&sb, "FNDA:%d,%s\n", fn.Calls, fn.Name)
+			if fn.Calls > 0 {
+				called++
+			}
+		}
+		fmt.Fprintf(&sb, "FNF:%d\nFNH:%d\n", len(file.Functions), called)
+
+		for _, b := range file.Branches {
+			taken := "-"
+			if b.Taken >= 0 {
+				taken = fmt.Sprint(b.Taken)
+			}
+			fmt.Fprintf(&sb, "BRDA:%d,%d,%d,%s\n", b.Line, b.Block, b.Branch, taken)
+		}
+		fmt.Fprintf(&sb, "BRF:%d\nBRH:%d\n", len(file.Branches), file.BranchesHit())
+
+		for _, line := range sortedLines(file.Lines) {
+			fmt.Fprintf(&sb, "DA:%d,%d\n", line, file.Lines[line])
+		}
+		fmt.Fprintf(&sb, "LF:%d\nLH:%d\n", len(file.Lines), file.LinesHit())
+		sb.WriteString("end_of_record\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Summary escreve a porcentagem de linhas e ramos cobertos por arquivo.
+func (c *Coverage) Summary(w io.Writer) {
+	cwd, _ := os.Getwd()
+	for _, file := range c.Files() {
+		fmt.Fprintf(w, "%-40s lines %6s (%d/%d)  branches %6s (%d/%d)\n",
+			displayPath(cwd, file.Path),
+			coveragePercent(file.LinesHit(), len(file.Lines)), file.LinesHit(), len(file.Lines),
+			coveragePercent(file.BranchesHit(), len(file.Branches)), file.BranchesHit(), len(file.Branches))
+	}
+}
+
+func coveragePercent(hit, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(hit)*100/float64(total))
+}
+
+func sortedLines(lines map[int]int) []int {
+	sorted := make([]int, 0, len(lines))
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+const coverageHTMLHead = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Nox coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.summary td, table.summary th { padding: 0.2em 1em; text-align: left; }
+pre { font-family: monospace; line-height: 1.3; border: 1px solid #ddd; padding: 0.5em 0; }
+pre span { display: block; padding: 0 0.5em; white-space: pre; }
+.n { color: #999; display: inline-block; width: 4em; }
+.c { color: #999; display: inline-block; width: 5em; text-align: right; margin-right: 1em; }
+.hit { background: #dfd; }
+.miss { background: #fdd; }
+.partial { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>Nox coverage</h1>
+`
+
+// WriteHTML grava um relatório navegável com o código de cada arquivo:
+// linhas executadas em verde, não executadas em vermelho e linhas com
+// ramos não seguidos em amarelo.
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	cwd, _ := os.Getwd()
+	files := c.Files()
+
+	var sb strings.Builder
+	sb.WriteString(coverageHTMLHead)
+	sb.WriteString("<table class=\"summary\">\n<tr><th>File</th><th>Lines</th><th>Branches</th><th>Functions</th></tr>\n")
+	for idx, file := range files {
+		called := 0
+		for _, fn := range file.Functions {
+			if fn.Calls > 0 {
+				called++
+			}
+		}
+		fmt.Fprintf(&sb, "<tr><td><a href=\"#file%d\">%s</a></td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			idx, html.EscapeString(displayPath(cwd, file.Path)),
+			coveragePercent(file.LinesHit(), len(file.Lines)),
+			coveragePercent(file.BranchesHit(), len(file.Branches)),
+			coveragePercent(called, len(file.Functions)))
+	}
+	sb.WriteString("</table>\n")
+
+	for idx, file := range files {
+		fmt.Fprintf(&sb, "<h2 id=\"file%d\">%s</h2>\n<pre>\n", idx, html.EscapeString(displayPath(cwd, file.Path)))
+
+		partial := map[int]bool{}
+		for _, b := range file.Branches {
+			if b.Taken == 0 {
+				partial[b.Line] = true
+			}
+		}
+
+		source, err := os.ReadFile(file.Path)
+		if err != nil {
+			fmt.Fprintf(&sb, "<span>%s</span>\n", html.EscapeString(err.Error()))
+		}
+		for n, text := range strings.Split(strings.TrimSuffix(string(source), "\n"), "\n") {
+			line := n + 1
+			class, count := "", ""
+			if hits, ok := file.Lines[line]; ok {
+				count = fmt.Sprint(hits)
+				switch {
+				case hits == 0:
+					class = "miss"
+				case partial[line]:
+					class = "partial"
+				default:
+					class = "hit"
+				}
+			}
+			fmt.Fprintf(&sb, "<span class=\"%s\"><span class=\"n\">%d</span><span class=\"c\">%s</span>%s</span>",
+				class, line, count, html.EscapeString(text))
+		}
+		sb.WriteString("</pre>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
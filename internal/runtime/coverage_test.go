@@ -0,0 +1,126 @@
+package runtime
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Cada if, for-in, "and" e "or" gera dois ramos; o "for { }" não tem
+// decisão e fica de fora.
+func TestCoverageBranches(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "cover.nox")
+	source := `func check(a, b) {
+    if a and b {
+        return 1;
+    }
+    return a or b;
+}
+check(true, false);
+check(true, true);
+for x in [] {
+    print x;
+}
+for {
+    break;
+}
+let skipped = false and check(1, 2);
+if (false) {
+    print nil or 1;
+}
+`
+	if err := os.WriteFile(script, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	n.Coverage = NewCoverage()
+	if err := n.ExecFile(script); err != nil {
+		t.Fatal(err)
+	}
+
+	var lcov strings.Builder
+	if err := n.Coverage.WriteLCOV(&lcov); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(lcov.String(), "\n") {
+		if strings.HasPrefix(line, "BR") {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		"BRDA:2,0,0,1", "BRDA:2,0,1,1", // if a and b
+		"BRDA:2,1,0,2", "BRDA:2,1,1,0", // a and b
+		"BRDA:5,2,0,0", "BRDA:5,2,1,1", // a or b
+		"BRDA:9,3,0,0", "BRDA:9,3,1,1", // for x in []
+		"BRDA:15,4,0,0", "BRDA:15,4,1,1", // false and check(1, 2)
+		"BRDA:16,5,0,0", "BRDA:16,5,1,1", // if (false)
+		"BRDA:17,6,0,-", "BRDA:17,6,1,-", // nil or 1, nunca avaliado
+		"BRF:14", "BRH:7",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("branches:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+// Os relatórios podem ser gerados enquanto handlers de http.serve ainda
+// registram comandos em outras goroutines (go test -race).
+func TestCoverageReportDuringHandlers(t *testing.T) {
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	n.Coverage = NewCoverage()
+	interpreter := NewInterpreter(n, false)
+	interpreter.resetStack("<script>", "srv.nox")
+	interpreter.AttachCoverage(n.Coverage)
+	if err := n.Run(`func handle(req, res) {
+    let total = 0;
+    for i in range(50) {
+        total = total + i;
+    }
+    return total > 0 and total;
+}
+http.route("/test/cover", handle);
+`, interpreter); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(n.mux)
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				if resp, err := http.Get(server.URL + "/test/cover"); err == nil {
+					resp.Body.Close()
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for reporting := true; reporting; {
+		select {
+		case <-done:
+			reporting = false
+		default:
+			n.Coverage.Summary(io.Discard)
+		}
+	}
+
+	files := n.Coverage.Files()
+	if len(files) != 1 || files[0].Lines[4] == 0 {
+		t.Errorf("handler lines were not recorded: %+v", files)
+	}
+}
@@ -0,0 +1,475 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/parser"
+	"github.com/MichelLacerda/nox/internal/scanner"
+)
+
+// DebugAction diz como a execução continua depois de uma parada.
+type DebugAction int
+
+const (
+	DebugContinue DebugAction = iota
+	DebugStepIn
+	DebugStepOver
+	DebugStepOut
+	DebugTerminate // encerra o programa como os.exit(0)
+)
+
+// DebugStop descreve o ponto em que o programa parou.
+type DebugStop struct {
+	Reason string // "entry", "breakpoint", "step" ou "pause"
+	File   string
+	Line   int
+}
+
+// DebugFrame é um frame da pilha visto pelo depurador, do mais recente
+// (índice 0) para o mais antigo.
+type DebugFrame struct {
+	Function string
+	File     string
+	Line     int
+	env      *Environment
+}
+
+// DebugVariable é um nome visível em um escopo e o seu valor.
+type DebugVariable struct {
+	Name  string
+	Value any
+}
+
+// Debugger controla a execução de um Interpreter: a cada comando executado
+// verifica breakpoints e passos e, quando precisa parar, chama OnStop, que
+// bloqueia até o usuário decidir como continuar. Enquanto OnStop não
+// retorna, Frames, Variables e Evaluate podem ser usados com segurança.
+type Debugger struct {
+	// OnStop é chamado na goroutine do interpretador a cada parada.
+	OnStop func(stop DebugStop) DebugAction
+
+	// StopOnEntry faz o programa parar antes do primeiro comando.
+	StopOnEntry bool
+
+	interpreter *Interpreter
+
+	mu          sync.Mutex
+	breakpoints map[string]map[int]bool // caminho absoluto → linhas
+	paths       map[string]string       // cache de caminhos dos frames → absolutos
+
+	pause      atomic.Bool
+	action     DebugAction
+	stepDepth  int
+	stepFile   string
+	stepLine   int
+	started    bool
+	evaluating bool
+
+	lastFile  string
+	lastLine  int
+	lastDepth int
+}
+
+func NewDebugger() *Debugger {
+	return &Debugger{
+		breakpoints: map[string]map[int]bool{},
+		paths:       map[string]string{},
+	}
+}
+
+// AttachDebugger liga o depurador ao interpretador.
+func (i *Interpreter) AttachDebugger(d *Debugger) {
+	i.debugger = d
+	d.interpreter = i
+}
+
+// SetBreakpoints substitui os breakpoints de um arquivo.
+func (d *Debugger) SetBreakpoints(file string, lines []int) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+	set := map[int]bool{}
+	for _, line := range lines {
+		set[line] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[abs] = set
+}
+
+// Breakpoints devolve os breakpoints de todos os arquivos, em ordem.
+func (d *Debugger) Breakpoints() map[string][]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := map[string][]int{}
+	for file, set := range d.breakpoints {
+		for line := range set {
+			result[file] = append(result[file], line)
+		}
+		sort.Ints(result[file])
+	}
+	return result
+}
+
+// Pause pede uma parada no próximo comando. Pode ser chamado de outra
+// goroutine enquanto o programa executa.
+func (d *Debugger) Pause() {
+	d.pause.Store(true)
+}
+
+// Path converte o arquivo de um frame em um caminho absoluto. O script
+// principal é relativo ao diretório do processo; módulos importados, ao
+// diretório do script.
+func (d *Debugger) Path(file string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if abs, ok := d.paths[file]; ok {
+		return abs
+	}
+	abs := file
+	if !filepath.IsAbs(file) {
+		abs, _ = filepath.Abs(file)
+		if _, err := os.Stat(abs); err != nil {
+			abs = filepath.Join(d.interpreter.Runtime.WorkingDir, file)
+		}
+	}
+	d.paths[file] = abs
+	return abs
+}
+
+// before é chamado pelo interpretador antes de cada comando.
+func (d *Debugger) before(stmt ast.Stmt) {
+	if d.evaluating {
+		return
+	}
+	line := stmtLine(stmt)
+	if line == 0 {
+		return // blocos e comandos sem posição não são pontos de parada
+	}
+
+	i := d.interpreter
+	file := i.frames[len(i.frames)-1].File
+	depth := len(i.frames)
+
+	reason := ""
+	switch {
+	case !d.started:
+		d.started = true
+		if d.StopOnEntry {
+			reason = "entry"
+		}
+	case d.pause.Swap(false):
+		reason = "pause"
+	}
+
+	if reason == "" {
+		moved := file != d.stepFile || line != d.stepLine
+		switch d.action {
+		case DebugStepIn:
+			if moved || depth != d.stepDepth {
+				reason = "step"
+			}
+		case DebugStepOver:
+			if depth < d.stepDepth || (depth == d.stepDepth && moved) {
+				reason = "step"
+			}
+		case DebugStepOut:
+			if depth < d.stepDepth {
+				reason = "step"
+			}
+		}
+	}
+
+	if reason == "" && (file != d.lastFile || line != d.lastLine || depth != d.lastDepth) {
+		path := d.Path(file)
+		d.mu.Lock()
+		hit := d.breakpoints[path][line]
+		d.mu.Unlock()
+		if hit {
+			reason = "breakpoint"
+		}
+	}
+
+	d.lastFile, d.lastLine, d.lastDepth = file, line, depth
+	if reason == "" || d.OnStop == nil {
+		return
+	}
+
+	d.action = d.OnStop(DebugStop{Reason: reason, File: file, Line: line})
+	if d.action == DebugTerminate {
+		panic(&ExitError{Code: 0})
+	}
+	d.stepDepth, d.stepFile, d.stepLine = depth, file, line
+}
+
+// Frames devolve a pilha de chamadas, do frame mais recente para o mais antigo.
+func (d *Debugger) Frames() []DebugFrame {
+	i := d.interpreter
+	frames := make([]DebugFrame, len(i.frames))
+	for idx, frame := range i.frames {
+		env := frame.env
+		if idx == len(i.frames)-1 {
+			env = i.environment
+		}
+		frames[len(i.frames)-1-idx] = DebugFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+			env:      env,
+		}
+	}
+	return frames
+}
+
+// Locals devolve as variáveis visíveis no frame, percorrendo a cadeia de
+// Environment até o escopo de topo do script ou do módulo do frame. Nomes
+// internos escondem os externos.
+func (d *Debugger) Locals(frame DebugFrame) []DebugVariable {
+	seen := map[string]bool{}
+	var vars []DebugVariable
+	for env := frame.env; env != nil && env.local(); env = env.Enclosing {
+		for _, name := range sortedNames(env.Values) {
+			if !seen[name] {
+				seen[name] = true
+				vars = append(vars, DebugVariable{Name: name, Value: env.Values[name]})
+			}
+		}
+	}
+	return vars
+}
+
+// Globals devolve as variáveis globais declaradas pelo programa.
+func (d *Debugger) Globals() []DebugVariable {
+	var vars []DebugVariable
+	values := d.interpreter.globals.Values
+	for _, name := range sortedNames(values) {
+		vars = append(vars, DebugVariable{Name: name, Value: values[name]})
+	}
+	return vars
+}
+
+// Children devolve os elementos de listas, dicionários, instâncias e
+// módulos, para inspeção de valores compostos.
+func (d *Debugger) Children(value any) []DebugVariable {
+	var vars []DebugVariable
+	switch v := value.(type) {
+	case *ListInstance:
+		for idx, element := range v.Elements {
+			vars = append(vars, DebugVariable{Name: fmt.Sprintf("[%d]", idx), Value: element})
+		}
+	case *DictInstance:
+		for _, key := range sortedNames(v.Entries) {
+			vars = append(vars, DebugVariable{Name: key, Value: v.Entries[key]})
+		}
+	case *Instance:
+		for _, key := range sortedNames(v.Fields) {
+			vars = append(vars, DebugVariable{Name: key, Value: v.Fields[key]})
+		}
+	case *MapInstance:
+		for _, key := range sortedNames(v.Entries) {
+			vars = append(vars, DebugVariable{Name: key, Value: v.Entries[key]})
+		}
+	case *EnvironmentWrapper:
+		for _, key := range sortedNames(v.Exports) {
+			vars = append(vars, DebugVariable{Name: key, Value: v.Env.Values[key]})
+		}
+	}
+	return vars
+}
+
+// HasChildren diz se Children tem algo a mostrar para o valor.
+func (d *Debugger) HasChildren(value any) bool {
+	switch value.(type) {
+	case *ListInstance, *DictInstance, *Instance, *MapInstance, *EnvironmentWrapper:
+		return true
+	}
+	return false
+}
+
+// Format devolve a representação de um valor usada pelo depurador.
+func (d *Debugger) Format(value any) string {
+	return Repr(value)
+}
+
+// Evaluate avalia uma expressão no escopo do frame. Breakpoints são
+// ignorados durante a avaliação.
+func (d *Debugger) Evaluate(frame DebugFrame, source string) (result any, err error) {
+	tokens, scanErr := scanner.NewScanner([]rune(source)).ScanTokens()
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	p := parser.NewParser(tokens)
+	expr, parseErr := p.Expression()
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if !p.IsAtEnd() {
+		return nil, fmt.Errorf("unexpected '%s' after expression", p.Peek().Lexeme)
+	}
+
+	i := d.interpreter
+
+	// Recria os escopos do resolver a partir da cadeia de Environment, para
+	// que as variáveis locais sejam encontradas na distância correta.
+	var chain []*Environment
+	for env := frame.env; env != nil && env.local(); env = env.Enclosing {
+		chain = append(chain, env)
+	}
+	resolver := NewResolver(i)
+	for idx := len(chain) - 1; idx >= 0; idx-- {
+		scope := map[string]bool{}
+		for name := range chain[idx].Values {
+			scope[name] = true
+		}
+		resolver.scopes.Push(scope)
+	}
+	resolver.ResolveExpr(expr)
+	if len(resolver.Errors) > 0 {
+		return nil, resolver.Errors
+	}
+
+	previous := i.environment
+	i.environment = frame.env
+	d.evaluating = true
+	defer func() {
+		i.environment = previous
+		d.evaluating = false
+		if r := recover(); r != nil {
+			if runtimeErr, ok := r.(*RuntimeError); ok {
+				err = runtimeErr
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	return i.evaluate(expr), nil
+}
+
+func sortedNames[V any](values map[string]V) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stmtLine devolve a linha de um comando, ou 0 quando ele não tem posição
+// própria (blocos).
+func stmtLine(stmt ast.Stmt) int {
+	switch s := stmt.(type) {
+	case *ast.ExpressionStmt:
+		return exprLine(s.Expression)
+	case *ast.VarStmt:
+		return s.Name.Line
+	case *ast.FunctionStmt:
+		return s.Name.Line
+	case *ast.ClassStmt:
+		return s.Name.Line
+	case *ast.PrintStmt:
+		return s.Keyword.Line
+	case *ast.ReturnStmt:
+		return s.Keyword.Line
+	case *ast.BreakStmt:
+		return s.Keyword.Line
+	case *ast.ContinueStmt:
+		return s.Keyword.Line
+	case *ast.IfStmt:
+		return s.Keyword.Line
+	case *ast.ForInStmt:
+		if s.ValueVar != nil {
+			return s.ValueVar.Line
+		}
+		return exprLine(s.Iterable)
+	case *ast.WithStmt:
+		return s.Alias.Line
+	case *ast.ImportStmt:
+		return s.Path.Line
+	case *ast.ExportStmt:
+		return stmtLine(s.Declaration)
+	}
+	return 0
+}
+
+func exprLine(expr ast.Expr) int {
+	switch e := expr.(type) {
+	case *ast.AssignExpr:
+		return e.Name.Line
+	case *ast.BinaryExpr:
+		if line := exprLine(e.Left); line > 0 {
+			return line
+		}
+		return e.Operator.Line
+	case *ast.LogicalExpr:
+		if line := exprLine(e.Left); line > 0 {
+			return line
+		}
+		return e.Operator.Line
+	case *ast.CallExpr:
+		if line := exprLine(e.Callee); line > 0 {
+			return line
+		}
+		return e.Parenthesis.Line
+	case *ast.GetExpr:
+		if line := exprLine(e.Object); line > 0 {
+			return line
+		}
+		return e.Name.Line
+	case *ast.SetExpr:
+		if line := exprLine(e.Object); line > 0 {
+			return line
+		}
+		return e.Name.Line
+	case *ast.IndexExpr:
+		if line := exprLine(e.Object); line > 0 {
+			return line
+		}
+		return e.Bracket.Line
+	case *ast.SetIndexExpr:
+		if line := exprLine(e.Object); line > 0 {
+			return line
+		}
+		return e.Bracket.Line
+	case *ast.GroupingExpr:
+		return exprLine(e.Expression)
+	case *ast.UnaryExpr:
+		return e.Operator.Line
+	case *ast.VariableExpr:
+		return e.Name.Line
+	case *ast.LiteralExpr:
+		if e.Token != nil {
+			return e.Token.Line
+		}
+	case *ast.SelfExpr:
+		return e.Keyword.Line
+	case *ast.SuperExpr:
+		return e.Keyword.Line
+	case *ast.SafeExpr:
+		return e.Name.Line
+	case *ast.ListExpr:
+		if e.Bracket != nil {
+			return e.Bracket.Line
+		}
+	case *ast.DictExpr:
+		if e.Brace != nil {
+			return e.Brace.Line
+		}
+		for _, pair := range e.Pairs {
+			if line := exprLine(pair.Key); line > 0 {
+				return line
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package runtime
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+	"github.com/MichelLacerda/nox/internal/parser"
+	"github.com/MichelLacerda/nox/internal/scanner"
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// ParseSource varre e analisa uma fonte inteira, acumulando os erros do
+// scanner e do parser em uma única lista, em ordem de posição. Nunca entra em pânico: falhas
+// inesperadas viram um diagnóstico. As declarações devolvidas podem estar
+// incompletas quando há erros.
+func ParseSource(file, source string) (statements []ast.Stmt, diags diagnostic.List) {
+	defer func() {
+		if r := recover(); r != nil {
+			diags = append(diags, diagnostic.Diagnostic{
+				File:    file,
+				Line:    1,
+				Message: fmt.Sprintf("internal error while parsing: %v", r),
+			})
+		}
+	}()
+
+	tokens, err := scanner.NewScanner([]rune(source)).ScanTokens()
+	diags = append(diags, syntaxDiagnostics(file, err)...)
+
+	statements, err = parser.NewParser(tokens).Parse()
+	diags = append(diags, syntaxDiagnostics(file, err)...)
+
+	diags.Sort()
+	return statements, diags
+}
+
+// syntaxDiagnostics converte erros do scanner, do parser e do resolver em
+// diagnósticos posicionados.
+func syntaxDiagnostics(file string, err error) diagnostic.List {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case diagnostic.List:
+		return e
+	case scanner.ScannerErrors:
+		diags := make(diagnostic.List, len(e))
+		for idx, scanErr := range e {
+			diags[idx] = diagnostic.Diagnostic{
+				File:     file,
+				Line:     scanErr.Line,
+				Column:   scanErr.Column,
+				Length:   1,
+				Severity: diagnostic.SeverityError,
+				Message:  scanErr.Message,
+			}
+		}
+		return diags
+	case scanner.ScannerError:
+		return syntaxDiagnostics(file, scanner.ScannerErrors{e})
+	case parser.ParserErrors:
+		diags := make(diagnostic.List, len(e))
+		for idx, parseErr := range e {
+			diags[idx] = tokenDiagnostic(file, parseErr.Token, parseErr.Message)
+		}
+		return diags
+	case parser.ParserError:
+		return diagnostic.List{tokenDiagnostic(file, e.Token, e.Message)}
+	}
+	return nil
+}
+
+func tokenDiagnostic(file string, t *token.Token, message string) diagnostic.Diagnostic {
+	d := diagnostic.Diagnostic{File: file, Message: message, Length: 1, Severity: diagnostic.SeverityError}
+	if t != nil {
+		d.Line = t.Line
+		d.Column = t.Column
+		d.Length = max(utf8.RuneCountInString(t.Lexeme), 1)
+	}
+	return d
+}
@@ -0,0 +1,54 @@
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Um erro do scanner não gera erros do parser em cascata.
+func TestParseSourceScannerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "unterminated string",
+			source: "let a = 1;\nlet s = \"abc;\nprint a;\n",
+			want:   []string{"2:9 Unterminated string."},
+		},
+		{
+			name:   "unexpected character",
+			source: "let a = 1 @ 2;\nprint a;\n",
+			want:   []string{"1:11 Unexpected character '@'"},
+		},
+		{
+			name:   "unterminated block comment",
+			source: "let a = 1;\n/* open\nprint a;\n",
+			want:   []string{"2:1 Unterminated block comment."},
+		},
+		{
+			name:   "parser errors elsewhere are still reported",
+			source: "let a = @;\nlet b = ;\n",
+			want:   []string{"1:9 Unexpected character '@'", "2:9 Expect expression."},
+		},
+		{
+			name:   "scanner and parser errors in source order",
+			source: "let b = ;\nlet a = @;\n",
+			want:   []string{"1:9 Expect expression.", "2:9 Unexpected character '@'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := ParseSource("test.nox", tt.source)
+			var got []string
+			for _, d := range diags {
+				got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diagnostics = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
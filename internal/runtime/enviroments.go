@@ -8,6 +8,7 @@ type Environment struct {
 	runtime   *Nox
 	Values    map[string]any
 	Enclosing *Environment
+	globals   *Environment // escopo de topo do script ou módulo; nil nos builtins
 }
 
 func (e *Environment) Exists(name string) bool {
@@ -20,18 +21,44 @@ func (e *Environment) String() string {
 }
 
 func NewEnvironment(r *Nox, scope *Environment) *Environment {
-	return &Environment{
+	env := &Environment{
 		runtime:   r,
 		Values:    map[string]any{},
 		Enclosing: scope,
 	}
+	if scope != nil {
+		env.globals = scope.globals
+	}
+	return env
+}
+
+// newGlobals cria o escopo de topo de um script ou módulo. Ele só enxerga
+// os builtins: cada módulo tem suas próprias variáveis globais, e uma
+// declaração pode esconder um builtin, como "func test()" ou "let time = 0".
+func newGlobals(r *Nox, builtins *Environment) *Environment {
+	env := NewEnvironment(r, builtins)
+	env.globals = env
+	return env
+}
+
+// local diz se e é um escopo de função ou bloco, abaixo do escopo de topo
+// do script ou módulo. Os builtins e o escopo de topo não são locais.
+func (e *Environment) local() bool {
+	return e.globals != nil && e != e.globals
 }
 
-func (e *Environment) Define(name string, value any) {
-	if _, exists := e.Values[name]; exists {
-		e.runtime.ReportRuntimeError(&token.Token{Lexeme: name}, "Variable already defined: "+name)
+// Define declara name no escopo. O token é o da declaração, para que o
+// erro de redeclaração aponte a linha certa.
+func (e *Environment) Define(name *token.Token, value any) {
+	if _, exists := e.Values[name.Lexeme]; exists {
+		e.runtime.ReportRuntimeError(name, "Variable already defined: "+name.Lexeme)
 	}
-	e.Values[name] = value
+	e.Values[name.Lexeme] = value
+}
+
+// Declara um nome sem token na fonte, como os builtins, self e super
+func (e *Environment) DefineByName(name string, value any) {
+	e.Define(&token.Token{Lexeme: name}, value)
 }
 
 func (e *Environment) Get(t *token.Token) any {
@@ -43,11 +70,12 @@ func (e *Environment) Get(t *token.Token) any {
 		return e.Enclosing.Get(t)
 	}
 
-	e.runtime.ReportRuntimeError(&token.Token{Lexeme: t.Lexeme}, "Undefined variable: "+t.Lexeme)
+	e.runtime.ReportRuntimeError(t, "Undefined variable: "+t.Lexeme)
 	return nil
 }
 
-// Busca uma variável por nome, ignorando o token (usado para self em inicializadores)
+// Busca uma variável por nome, sem token (usado para self em inicializadores).
+// O erro sai sem linha e captureStack o atribui à chamada em andamento.
 func (e *Environment) GetByName(name string) any {
 	if value, exists := e.Values[name]; exists {
 		return value
@@ -55,7 +83,7 @@ func (e *Environment) GetByName(name string) any {
 	if e.Enclosing != nil {
 		return e.Enclosing.GetByName(name)
 	}
-	e.runtime.ReportRuntimeError(&token.Token{Lexeme: name, Line: 0}, "Undefined variable: "+name)
+	e.runtime.ReportRuntimeError(&token.Token{Lexeme: name}, "Undefined variable: "+name)
 	return nil
 }
 
@@ -100,8 +128,20 @@ func (e *Environment) Ancestor(distance int) *Environment {
 	return env
 }
 
+// EnvironmentWrapper é um módulo importado: o escopo de topo do arquivo e
+// os nomes que ele exporta.
 type EnvironmentWrapper struct {
-	Env *Environment
+	Env     *Environment
+	Exports map[string]bool
+}
+
+// Export devolve o valor atual de um nome exportado pelo módulo.
+func (w *EnvironmentWrapper) Export(name string) (any, bool) {
+	if !w.Exports[name] {
+		return nil, false
+	}
+	value, ok := w.Env.Values[name]
+	return value, ok
 }
 
 func (w *EnvironmentWrapper) TypeName() string {
@@ -113,8 +153,6 @@ func (w *EnvironmentWrapper) String() string {
 }
 
 func (w *EnvironmentWrapper) Get(name *token.Token) any {
-	if val, ok := w.Env.Values[name.Lexeme]; ok {
-		return val
-	}
-	return nil
+	value, _ := w.Export(name.Lexeme)
+	return value
 }
@@ -6,9 +6,34 @@ import (
 	"github.com/MichelLacerda/nox/internal/token"
 )
 
+// ErrorKind distingue erros que o "nox test" e quem embute o interpretador
+// tratam de forma diferente.
+type ErrorKind int
+
+const (
+	ErrorGeneric       ErrorKind = iota
+	ErrorAssertion               // assert() e test.equal, test.fail, ...: o teste falhou
+	ErrorSkip                    // test.skip(): o teste foi interrompido sem falhar
+	ErrorPermission              // operação negada pelo Sandbox
+	ErrorCanceled                // Nox.Context foi cancelado
+	ErrorTimeout                 // o prazo de Nox.Context acabou
+	ErrorStepLimit               // Limits.MaxSteps foi atingido
+	ErrorStackOverflow           // Limits.MaxDepth foi atingido
+	ErrorMemoryLimit             // Limits.MaxMemory foi atingido
+)
+
+// IsLimit diz se o erro interrompe a execução por cancelamento ou por um
+// dos Limits. Esses erros não são capturados por "?expr" nem por
+// test.raises: o script não pode ignorá-los.
+func (k ErrorKind) IsLimit() bool {
+	return k >= ErrorCanceled && k <= ErrorMemoryLimit
+}
+
 type RuntimeError struct {
 	Token   *token.Token
 	Message string
+	Stack   []StackFrame // pilha de chamadas no momento do erro
+	Kind    ErrorKind
 }
 
 func (r *RuntimeError) Error() string {
@@ -19,6 +44,18 @@ func (r *RuntimeError) Error() string {
 	return fmt.Sprintf("RuntimeError: %s", r.Message)
 }
 
+// ExitError é devolvido por Nox.Run quando o script chama os.exit (ou
+// argparse encerra o programa). O interpretador nunca encerra o processo:
+// quem o embute decide o que fazer com Code, e o cmd/nox o usa como código
+// de saída.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("script exited with code %d", e.Code)
+}
+
 func NewRuntimeError(token *token.Token, message string) *RuntimeError {
 	return &RuntimeError{
 		Token:   token,
@@ -27,7 +64,7 @@ func NewRuntimeError(token *token.Token, message string) *RuntimeError {
 }
 
 func (n *Interpreter) ReportError(line int, where, message string) {
-	fmt.Printf("[line %d] Error%s: %s\n", line, where, message)
+	fmt.Fprintf(n.Runtime.stderr(), "[line %d] Error%s: %s\n", line, where, message)
 }
 
 func (n *Interpreter) ErrorAt(line int, message string) {
@@ -0,0 +1,163 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// exitCode devolve o código de err se ele é um *ExitError.
+func exitCode(t *testing.T, err error) int {
+	t.Helper()
+	var exit *ExitError
+	if !errors.As(err, &exit) {
+		t.Fatalf("err = %v, want *ExitError", err)
+	}
+	return exit.Code
+}
+
+// os.exit não encerra o processo: desfaz a pilha até Run, que devolve
+// *ExitError, e nada depois dele executa.
+func TestExit(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		code   int
+		output string
+	}{
+		{"top level", `print 1;
+os.exit(3);
+print 2;`, 3, "1"},
+		{"zero", `os.exit(0);
+print 2;`, 0, ""},
+		{"inside functions", `func inner() {
+    os.exit(7);
+}
+func outer() {
+    inner();
+    print "outer";
+}
+outer();`, 7, ""},
+		{"inside a loop", `for i in range(10) {
+    print i;
+    if i == 1 {
+        os.exit(2);
+    }
+}`, 2, "0\n1"},
+		{"through ?expr", `print ?os.exit(4);
+print "after";`, 4, ""},
+		{"through test.raises", `import "test" as test;
+func leave() {
+    os.exit(5);
+}
+test.raises(leave);
+print "after";`, 5, ""},
+		{"with closes the resource", `class Resource {
+    close() {
+        print "closed";
+    }
+}
+with Resource() as r {
+    print "inside";
+    os.exit(6);
+}
+print "after";`, 6, "inside\nclosed"},
+		{"with ignores an error while closing", `class Broken {
+    close() {
+        let x = nil;
+        return x.y;
+    }
+}
+with Broken() as b {
+    os.exit(8);
+}`, 8, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runSource(tt.source)
+			if code := exitCode(t, err); code != tt.code {
+				t.Errorf("code = %d, want %d", code, tt.code)
+			}
+			if out != tt.output {
+				t.Errorf("output = %q, want %q", out, tt.output)
+			}
+		})
+	}
+}
+
+func TestExitInvalidCode(t *testing.T) {
+	for _, call := range []string{`os.exit(-1)`, `os.exit(256)`, `os.exit("1")`} {
+		_, err := runSource(call + ";")
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Errorf("%s = %v, want a runtime error", call, err)
+		}
+	}
+}
+
+func TestRunFileExit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exit.nox")
+	if err := os.WriteFile(path, []byte("print 1;\nos.exit(9);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	err := n.RunFile(path)
+	if code := exitCode(t, err); code != 9 {
+		t.Errorf("code = %d, want 9", code)
+	}
+	if n.HadRuntimeError {
+		t.Error("os.exit counted as a runtime error")
+	}
+}
+
+// os.exit num handler para o servidor, e http.serve devolve o código para
+// Run na goroutine do script.
+func TestExitFromHandler(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := runSource(fmt.Sprintf(`func stop(req, res) {
+    os.exit(11);
+}
+http.route("/test/exit", stop);
+http.serve(%d);
+print "after";`, port))
+		done <- err
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/test/exit", port)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start:", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-done:
+		if code := exitCode(t, err); code != 11 {
+			t.Errorf("code = %d, want 11", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("http.serve did not return after os.exit in a handler")
+	}
+}
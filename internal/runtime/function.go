@@ -11,6 +11,7 @@ type Function struct {
 	Declaration   *ast.FunctionStmt
 	closure       *Environment
 	IsInitializer bool
+	File          string // arquivo onde a função foi declarada
 }
 
 func NewFunction(r *Nox, declaration *ast.FunctionStmt, closure *Environment, isInitializer bool) *Function {
@@ -26,15 +27,18 @@ func (f *Function) Call(i *Interpreter, args []any) (result any) {
 	environment := NewEnvironment(f.runtime, f.closure)
 
 	if len(args) != f.Arity() {
-		f.runtime.ReportRuntimeError(f.Declaration.Name, fmt.Sprintf(
+		f.runtime.ReportRuntimeError(i.callToken(f.Declaration.Name), fmt.Sprintf(
 			"Expected %d arguments but got %d.", f.Arity(), len(args)))
 		return nil
 	}
 
 	for idx, param := range f.Declaration.Parameters {
-		environment.Define(param.Lexeme, args[idx])
+		environment.Define(param, args[idx])
 	}
 
+	i.pushFrame(f.Declaration.Name.Lexeme, f.File, f.Declaration.Name.Line)
+	defer i.popFrame()
+
 	defer func() {
 		if r := recover(); r != nil {
 			if ret, ok := r.(Return); ok {
@@ -44,6 +48,9 @@ func (f *Function) Call(i *Interpreter, args []any) (result any) {
 				}
 				result = ret.Value
 			} else {
+				if err, ok := r.(*RuntimeError); ok {
+					i.captureStack(err)
+				}
 				panic(r)
 			}
 		}
@@ -65,12 +72,13 @@ func (f *Function) Arity() int {
 
 func (f *Function) Bind(instance *Instance) *Function {
 	env := NewEnvironment(f.runtime, f.closure)
-	env.Define("self", instance)
+	env.DefineByName("self", instance)
 	bound := &Function{
 		runtime:       f.runtime,
 		Declaration:   f.Declaration,
 		closure:       env,
 		IsInitializer: f.IsInitializer,
+		File:          f.File,
 	}
 	return bound
 }
@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// runOutput executa source e devolve o que ele imprimiu, sem a quebra de
+// linha final.
+func runOutput(t *testing.T, source string) string {
+	t.Helper()
+	out, err := runSource(source)
+	if err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	return out
+}
+
+// runSource executa source e devolve o que ele imprimiu, sem a quebra de
+// linha final, e o erro de Run.
+func runSource(source string) (string, error) {
+	n := NewNox()
+	var out strings.Builder
+	n.Stdout, n.Stderr = &out, io.Discard
+	err := n.Run(source, NewInterpreter(n, false))
+	return strings.TrimSuffix(out.String(), "\n"), err
+}
+
+// exprTest é um caso de tabela: o que "print expr" imprime.
+type exprTest struct {
+	expr string
+	want string
+}
+
+// runExprTests imprime cada expressão depois de setup e compara a saída.
+func runExprTests(t *testing.T, setup string, tests []exprTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := runOutput(t, setup+"\nprint "+tt.expr+";"); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
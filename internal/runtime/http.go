@@ -4,13 +4,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	goruntime "runtime"
 	"strings"
 
 	"github.com/MichelLacerda/nox/internal/token"
 )
 
-var mux = http.NewServeMux()
-
 func NewHttpModule() *MapInstance {
 	return NewMapInstance(map[string]any{
 		"route": &BuiltinFunction{
@@ -24,12 +24,12 @@ func NewHttpModule() *MapInstance {
 
 				switch handler := args[1].(type) {
 				case *Function:
-					mux.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+					i.Runtime.handle("http.route", route, func(w http.ResponseWriter, r *http.Request) {
 						safeHttpHandlerCall(i, w, r, handler)
 					})
 
 				case *Instance:
-					mux.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+					i.Runtime.handle("http.route", route, func(w http.ResponseWriter, r *http.Request) {
 						methodFn := handler.Get(&token.Token{Lexeme: strings.ToLower(r.Method)})
 
 						if methodFn == nil {
@@ -52,6 +52,28 @@ func NewHttpModule() *MapInstance {
 			},
 		},
 
+		"websocket": &BuiltinFunction{
+			ArityValue: 2,
+			CallFunc: func(i *Interpreter, args []any) any {
+				route, ok := args[0].(string)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "First argument to http.websocket must be a string (path)")
+					return nil
+				}
+
+				switch handler := args[1].(type) {
+				case *Function, *Instance:
+					i.Runtime.handle("http.websocket", route, func(w http.ResponseWriter, r *http.Request) {
+						handleWebSocket(i, w, r, handler)
+					})
+				default:
+					i.Runtime.ReportRuntimeError(nil, "Second argument to http.websocket must be a function or class instance")
+				}
+
+				return nil
+			},
+		},
+
 		"serve": &BuiltinFunction{
 			ArityValue: 1,
 			CallFunc: func(i *Interpreter, args []any) any {
@@ -63,13 +85,25 @@ func NewHttpModule() *MapInstance {
 
 				server := &http.Server{
 					Addr:    fmt.Sprintf(":%d", int(port)),
-					Handler: mux,
+					Handler: i.Runtime.mux,
 				}
 
-				fmt.Printf("Starting HTTP server on port %d\n", int(port))
-				err := server.ListenAndServe()
-				if err != nil {
+				fmt.Fprintf(i.Runtime.stdout(), "Starting HTTP server on port %d\n", int(port))
+				i.serverExit = make(chan *ExitError, 1)
+				failed := make(chan error, 1)
+				go func() { failed <- server.ListenAndServe() }()
+
+				// os.exit num handler e o cancelamento de Nox.Context param o
+				// servidor e seguem a partir daqui, na goroutine do script
+				select {
+				case err := <-failed:
 					i.Runtime.ReportRuntimeError(nil, "Server error: "+err.Error())
+				case exit := <-i.serverExit:
+					server.Close()
+					panic(exit)
+				case <-i.context().Done():
+					server.Close()
+					panic(i.checkContext())
 				}
 				return nil
 			},
@@ -77,57 +111,53 @@ func NewHttpModule() *MapInstance {
 	})
 }
 
-func safeHttpHandlerCall(i *Interpreter, w http.ResponseWriter, r *http.Request, fn Callable) {
+// registeredAt é a parte das mensagens do ServeMux que aponta para o código
+// Go que registrou o padrão, sem sentido para quem escreveu o script.
+var registeredAt = regexp.MustCompile(` \(registered at [^)]*\)`)
+
+// handle registra uma rota no mux desta execução. O ServeMux entra em pânico
+// com um padrão inválido ou em conflito com outro já registrado; aqui isso
+// vira um RuntimeError, que o script pode tratar com '?'.
+func (n *Nox) handle(function, pattern string, handler http.HandlerFunc) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintln(w, "Internal Server Error: ", r)
+			message, _, _ := strings.Cut(fmt.Sprint(r), "\n")
+			message = strings.TrimPrefix(registeredAt.ReplaceAllString(message, ""), "http: ")
+			message = strings.TrimSuffix(message, ":")
+			n.ReportRuntimeError(nil, fmt.Sprintf("%s: %s", function, message))
 		}
 	}()
+	n.mux.HandleFunc(pattern, handler)
+}
 
-	reqMap := NewMapInstance(map[string]any{
-		"method": r.Method,
-		"url":    r.URL.String(),
-		"query": NewMapInstance(func() map[string]any {
-			query := make(map[string]any)
-			for k, v := range r.URL.Query() {
-				query[k] = strings.Join(v, ",")
-			}
-			return query
-		}()),
-		"params": NewMapInstance(func() map[string]any {
-			params := make(map[string]any)
-			for k, v := range r.URL.Query() {
-				params[k] = strings.Join(v, ",")
-			}
-			return params
-		}()),
-		"headers": NewMapInstance(func() map[string]any {
-			headers := make(map[string]any)
-			for k, v := range r.Header {
-				headers[k] = strings.Join(v, ",")
-			}
-			return headers
-		}()),
-		"body": func() string {
-			if r.Body == nil {
-				return ""
+func safeHttpHandlerCall(i *Interpreter, w http.ResponseWriter, r *http.Request, fn Callable) {
+	i, done := i.handler()
+	defer done()
+
+	started := false // depois que a resposta começou, um erro não vira mais 500
+	defer func() {
+		if r := recover(); r != nil {
+			if exit, ok := r.(*ExitError); ok {
+				i.exitServer(exit)
+				return
 			}
-			defer r.Body.Close()
-			bodyBytes, err := io.ReadAll(r.Body)
-			if err != nil {
-				i.Runtime.ReportRuntimeError(nil, "Failed to read request body: "+err.Error())
-				return ""
+			i.reportHandlerError("HTTP", r)
+			if !started {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			}
-			return string(bodyBytes)
-		}(),
-	})
+		}
+	}()
+
+	reqMap := newRequestObject(i, r)
 
 	respMap := NewMapInstance(map[string]any{
 		"write": &BuiltinFunction{
 			ArityValue: 1,
 			CallFunc: func(_ *Interpreter, args []any) any {
 				if len(args) == 1 {
-					fmt.Fprint(w, args[0])
+					started = true
+					text := fmt.Sprint(args[0])
+					i.released(func() { fmt.Fprint(w, text) })
 				}
 				return nil
 			},
@@ -143,6 +173,13 @@ func safeHttpHandlerCall(i *Interpreter, w http.ResponseWriter, r *http.Request,
 				return nil
 			},
 		},
+		"stream": &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				started = true
+				return newEventStream(i, w, r)
+			},
+		},
 		"set_status": &BuiltinFunction{
 			ArityValue: 1,
 			CallFunc: func(_ *Interpreter, args []any) any {
@@ -151,6 +188,7 @@ func safeHttpHandlerCall(i *Interpreter, w http.ResponseWriter, r *http.Request,
 					i.Runtime.ReportRuntimeError(nil, "http.set_status expects a status code")
 					return nil
 				}
+				started = true
 				w.WriteHeader(int(status))
 				return nil
 			},
@@ -159,6 +197,105 @@ func safeHttpHandlerCall(i *Interpreter, w http.ResponseWriter, r *http.Request,
 
 	result := fn.Call(i, []any{reqMap, respMap})
 	if str, ok := result.(string); ok {
-		fmt.Fprint(w, str)
+		i.released(func() { fmt.Fprint(w, str) })
+	}
+}
+
+// handler prepara a execução de um handler de http.serve na goroutine da
+// requisição. Como SSE e WebSocket mantêm handlers rodando por muito tempo,
+// cada um recebe um fork do interpretador, com escopo e pilha próprios, e
+// os forks se revezam em Runtime.handlers: só um executa código Nox por
+// vez. A trava é liberada nas esperas e escritas na rede (ver released) e,
+// num laço sem esperas, a cada limitCheckInterval instruções (ver yield). done a
+// libera no fim do handler.
+func (i *Interpreter) handler() (forked *Interpreter, done func()) {
+	fork := *i
+	fork.environment = i.globals
+	fork.frames = nil // o traceback começa na função do handler
+	fork.handlerLock = &i.Runtime.handlers
+	fork.handlerLock.Lock()
+	return &fork, fork.handlerLock.Unlock
+}
+
+// released executa wait, uma operação que pode bloquear, como time.sleep,
+// ws.receive, stream.send ou process.run, sem segurar Runtime.handlers, para
+// que outros handlers andem enquanto isso. wait não pode tocar em valores
+// Nox: outro handler pode estar alterando-os.
+func (i *Interpreter) released(wait func()) {
+	if i.handlerLock == nil {
+		wait()
+		return
+	}
+	i.handlerLock.Unlock()
+	defer i.handlerLock.Lock()
+	wait()
+}
+
+// yield cede Runtime.handlers a outro handler que esteja esperando, para
+// que um handler ocupado (um laço de SSE que só calcula, por exemplo) não
+// trave o servidor até terminar.
+func (i *Interpreter) yield() {
+	i.handlerLock.Unlock()
+	goruntime.Gosched()
+	i.handlerLock.Lock()
+}
+
+// reportHandlerError escreve em stderr o erro que encerrou um handler. O
+// servidor continua atendendo, e o cliente não recebe os detalhes do erro.
+func (i *Interpreter) reportHandlerError(kind string, r any) {
+	if err, ok := r.(*RuntimeError); ok {
+		i.captureStack(err)
+		fmt.Fprintf(i.Runtime.stderr(), "%s handler error:\n%s\n", kind, err.Traceback())
+		return
+	}
+	fmt.Fprintln(i.Runtime.stderr(), kind+" handler error:", r)
+}
+
+func newRequestObject(i *Interpreter, r *http.Request) *MapInstance {
+	return NewMapInstance(map[string]any{
+		"method": r.Method,
+		"url":    r.URL.String(),
+		"query": NewMapInstance(func() map[string]any {
+			query := make(map[string]any)
+			for k, v := range r.URL.Query() {
+				query[k] = strings.Join(v, ",")
+			}
+			return query
+		}()),
+		"params": NewMapInstance(func() map[string]any {
+			params := make(map[string]any)
+			for k, v := range r.URL.Query() {
+				params[k] = strings.Join(v, ",")
+			}
+			return params
+		}()),
+		"headers": NewMapInstance(func() map[string]any {
+			headers := make(map[string]any)
+			for k, v := range r.Header {
+				headers[k] = strings.Join(v, ",")
+			}
+			return headers
+		}()),
+		"body": func() string {
+			if r.Body == nil {
+				return ""
+			}
+			defer r.Body.Close()
+			bodyBytes, err := io.ReadAll(r.Body)
+			if err != nil {
+				i.Runtime.ReportRuntimeError(nil, "Failed to read request body: "+err.Error())
+				return ""
+			}
+			return string(bodyBytes)
+		}(),
+	})
+}
+
+// exitServer pede que http.serve termine com o os.exit chamado por um
+// handler. Só o primeiro pedido vale.
+func (i *Interpreter) exitServer(exit *ExitError) {
+	select {
+	case i.serverExit <- exit:
+	default:
 	}
 }
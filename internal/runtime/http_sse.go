@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// newEventStream prepara a resposta para Server-Sent Events e retorna o objeto
+// de stream com write, send, event, flush e closed.
+func newEventStream(i *Interpreter, w http.ResponseWriter, r *http.Request) *MapInstance {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		i.Runtime.ReportRuntimeError(nil, "res.stream: streaming not supported by this connection")
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	closed := func() bool {
+		select {
+		case <-r.Context().Done():
+			return true
+		default:
+			return false
+		}
+	}
+
+	// a escrita espera o cliente; o texto é montado antes, ainda com a trava
+	writeEvent := func(i *Interpreter, event string, data any) {
+		var sb strings.Builder
+		if event != "" {
+			sb.WriteString("event: " + event + "\n")
+		}
+		text, ok := data.(string)
+		if !ok {
+			text = StringifyCompact(data)
+		}
+		for _, line := range strings.Split(text, "\n") {
+			sb.WriteString("data: " + line + "\n")
+		}
+		sb.WriteString("\n")
+		i.released(func() {
+			fmt.Fprint(w, sb.String())
+			flusher.Flush()
+		})
+	}
+
+	return NewMapInstance(map[string]any{
+		"write": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				text := fmt.Sprint(args[0])
+				i.released(func() { fmt.Fprint(w, text) })
+				return nil
+			},
+		},
+		"send": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				writeEvent(i, "", args[0])
+				return nil
+			},
+		},
+		"event": &BuiltinFunction{
+			ArityValue: 2,
+			CallFunc: func(i *Interpreter, args []any) any {
+				name, ok := args[0].(string)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "stream.event(name, data) expects a string name")
+					return nil
+				}
+				writeEvent(i, name, args[1])
+				return nil
+			},
+		},
+		"flush": &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				i.released(flusher.Flush)
+				return nil
+			},
+		},
+		"closed": &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(_ *Interpreter, args []any) any {
+				return closed()
+			},
+		},
+	})
+}
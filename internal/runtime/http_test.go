@@ -0,0 +1,470 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer é o Stderr dos testes: os handlers escrevem nele de outras
+// goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// serveScript executa source, que registra rotas com http.route e
+// http.websocket, e serve com httptest as rotas registradas no mux do Nox.
+func serveScript(t *testing.T, source string) (*httptest.Server, *syncBuffer) {
+	t.Helper()
+	n := NewNox()
+	stderr := &syncBuffer{}
+	n.Stdout, n.Stderr = io.Discard, stderr
+	if err := n.Run(source, NewInterpreter(n, false)); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, stderr)
+	}
+	server := httptest.NewServer(n.mux)
+	t.Cleanup(server.Close)
+	return server, stderr
+}
+
+// Cada Nox tem suas rotas: dois scripts registram o mesmo caminho sem
+// conflito, e repetir uma rota no mesmo script é um RuntimeError, não um
+// pânico do ServeMux.
+func TestRouteRegistration(t *testing.T) {
+	source := `
+func ok(req, res) {
+    return "ok";
+}
+http.route("/test/route", ok);
+`
+	serveScript(t, source)
+	serveScript(t, source)
+
+	err := runError(t, source+`http.route("/test/route", ok);`)
+	if want := `http.route: pattern "/test/route" conflicts with pattern "/test/route"`; err.Message != want {
+		t.Errorf("error = %q, want %q", err.Message, want)
+	}
+	err = runError(t, `func ok(req, res) {
+    return "ok";
+}
+http.websocket("", ok);`)
+	if want := "http.websocket: invalid pattern"; err.Message != want {
+		t.Errorf("error = %q, want %q", err.Message, want)
+	}
+
+	if got := runOutput(t, source+`print ?http.route("/test/route", ok);
+print "after";`); got != "<nil>\nafter" {
+		t.Errorf("output = %q, want the conflict caught by '?'", got)
+	}
+}
+
+func TestSSEFraming(t *testing.T) {
+	server, _ := serveScript(t, `
+func events(req, res) {
+    let stream = res.stream();
+    stream.event("tick", "1");
+    stream.send("first
+second");
+}
+http.route("/test/sse", events);
+`)
+	resp, err := http.Get(server.URL + "/test/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "event: tick\ndata: 1\n\ndata: first\ndata: second\n\n"
+	if string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestSSEHandlerErrorGoesToStderr(t *testing.T) {
+	server, stderr := serveScript(t, `
+func failing(req, res) {
+    let stream = res.stream();
+    stream.send("ok");
+    let x = nil;
+    print x.field;
+}
+http.route("/test/sse-error", failing);
+`)
+	resp, err := http.Get(server.URL + "/test/sse-error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "data: ok\n\n" {
+		t.Errorf("body = %q, want only the event sent before the error", body)
+	}
+	if log := stderr.String(); !strings.Contains(log, "HTTP handler error") || !strings.Contains(log, "in failing") {
+		t.Errorf("stderr = %q, want the handler traceback", log)
+	}
+}
+
+func TestHandlerErrorBeforeResponse(t *testing.T) {
+	server, stderr := serveScript(t, `
+func failing(req, res) {
+    let x = nil;
+    return x.field;
+}
+http.route("/test/error", failing);
+`)
+	resp, err := http.Get(server.URL + "/test/error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || strings.Contains(string(body), "RuntimeError") {
+		t.Errorf("got %d %q, want a plain 500", resp.StatusCode, body)
+	}
+	if !strings.Contains(stderr.String(), "RuntimeError") {
+		t.Errorf("stderr = %q, want the error", stderr)
+	}
+}
+
+// Enquanto um stream espera em time.sleep, outras requisições são atendidas.
+func TestHandlersRunWhileStreamSleeps(t *testing.T) {
+	server, _ := serveScript(t, `
+import "time" as t;
+let ticks = 0;
+func slow(req, res) {
+    let stream = res.stream();
+    for i in range(20) {
+        ticks = ticks + 1;
+        stream.send(ticks);
+        t.sleep(0.05);
+    }
+}
+func count(req, res) {
+    return fmt("{}", ticks);
+}
+http.route("/test/slow", slow);
+http.route("/test/count", count);
+`)
+	resp, err := http.Get(server.URL + "/test/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != "data: 1\n" {
+		t.Fatalf("first event = %q, %v", line, err)
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(server.URL + "/test/count")
+		if err != nil {
+			done <- err.Error()
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		done <- string(body)
+	}()
+	select {
+	case body := <-done:
+		if body == "" || body == "20" {
+			t.Errorf("count = %q, want a value read while the stream runs", body)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("request blocked by the running stream")
+	}
+}
+
+// Um handler que só calcula, sem esperas, cede a vez aos outros.
+func TestBusyHandlerDoesNotBlockServer(t *testing.T) {
+	server, _ := serveScript(t, `
+let running = true;
+func busy(req, res) {
+    let stream = res.stream();
+    stream.send("started");
+    let n = 0;
+    for {
+        if running == false {
+            break;
+        }
+        n = n + 1;
+    }
+    stream.send("stopped");
+}
+func stop(req, res) {
+    running = false;
+    return "ok";
+}
+http.route("/test/busy", busy);
+http.route("/test/stop", stop);
+`)
+	resp, err := http.Get(server.URL + "/test/busy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != "data: started\n" {
+		t.Fatalf("first event = %q, %v", line, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(server.URL + "/test/stop")
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request blocked by the busy handler")
+	}
+	reader.ReadString('\n') // linha em branco do evento
+	if line, err := reader.ReadString('\n'); err != nil || line != "data: stopped\n" {
+		t.Errorf("last event = %q, %v", line, err)
+	}
+}
+
+// wsClient é um cliente WebSocket mínimo sobre uma conexão TCP.
+type wsClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+const wsTestKey = "dGhlIHNhbXBsZSBub25jZQ==" // exemplo da RFC 6455
+
+func dialWebSocket(t *testing.T, server *httptest.Server, path string) (*wsClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request := "GET " + path + " HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + wsTestKey + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := io.WriteString(conn, request); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wsClient{conn: conn, reader: reader}, resp
+}
+
+// send escreve um frame mascarado, como a RFC exige dos clientes.
+func (c *wsClient) send(t *testing.T, opcode byte, payload string) {
+	t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for idx := 0; idx < len(payload); idx++ {
+		frame = append(frame, payload[idx]^mask[idx%4])
+	}
+	c.write(t, frame)
+}
+
+func (c *wsClient) write(t *testing.T, frame []byte) {
+	t.Helper()
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *wsClient) receive(t *testing.T) (byte, string) {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		t.Fatal(err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("server frames must not be masked")
+	}
+	payload := make([]byte, header[1]&0x7F)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0F, string(payload)
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	server, _ := serveScript(t, `
+func idle(req, ws) {
+    ws.close();
+}
+http.websocket("/test/ws-handshake", idle);
+`)
+	_, resp := dialWebSocket(t, server, "/test/ws-handshake")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Sec-WebSocket-Accept = %q", got)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		t.Errorf("Upgrade = %q", resp.Header.Get("Upgrade"))
+	}
+
+	plain, err := http.Get(server.URL + "/test/ws-handshake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain.Body.Close()
+	if plain.StatusCode != http.StatusBadRequest {
+		t.Errorf("request without upgrade: status = %d, want 400", plain.StatusCode)
+	}
+}
+
+func TestWebSocketEchoAndClose(t *testing.T) {
+	server, stderr := serveScript(t, `
+func echo(req, ws) {
+    for {
+        let message = ws.receive();
+        if message == nil {
+            break;
+        }
+        ws.send("echo: " + message);
+    }
+}
+http.websocket("/test/ws-echo", echo);
+`)
+	client, resp := dialWebSocket(t, server, "/test/ws-echo")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	for _, message := range []string{"hello", "nox"} {
+		client.send(t, wsOpText, message)
+		if opcode, payload := client.receive(t); opcode != wsOpText || payload != "echo: "+message {
+			t.Errorf("got opcode %d %q", opcode, payload)
+		}
+	}
+
+	client.send(t, wsOpClose, "\x03\xe8")
+	opcode, payload := client.receive(t)
+	if opcode != wsOpClose || len(payload) != 2 || binary.BigEndian.Uint16([]byte(payload)) != 1000 {
+		t.Errorf("close reply = opcode %d %q, want close 1000", opcode, payload)
+	}
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Errorf("connection still open after close: %v", err)
+	}
+	if log := stderr.String(); log != "" {
+		t.Errorf("stderr = %q", log)
+	}
+}
+
+func TestWebSocketPingPong(t *testing.T) {
+	server, _ := serveScript(t, `
+class Echo {
+    receive(ws, message) {
+        ws.send(message);
+    }
+}
+http.websocket("/test/ws-ping", Echo());
+`)
+	client, _ := dialWebSocket(t, server, "/test/ws-ping")
+
+	client.send(t, wsOpPing, "are you there")
+	if opcode, payload := client.receive(t); opcode != wsOpPong || payload != "are you there" {
+		t.Errorf("ping reply = opcode %d %q, want pong with the same payload", opcode, payload)
+	}
+
+	// o ping não interrompe as mensagens normais
+	client.send(t, wsOpText, "after ping")
+	if opcode, payload := client.receive(t); opcode != wsOpText || payload != "after ping" {
+		t.Errorf("got opcode %d %q", opcode, payload)
+	}
+}
+
+// Um frame de cliente sem máscara encerra a conexão com 1002.
+func TestWebSocketUnmaskedFrame(t *testing.T) {
+	server, _ := serveScript(t, `
+let last = "none";
+func echo(req, ws) {
+    for {
+        let message = ws.receive();
+        if message == nil {
+            break;
+        }
+        ws.send(message);
+    }
+    last = ws.closed();
+}
+func closed(req, res) {
+    return fmt("{}", last);
+}
+http.websocket("/test/ws-unmasked", echo);
+http.route("/test/ws-closed", closed);
+`)
+	client, _ := dialWebSocket(t, server, "/test/ws-unmasked")
+	client.write(t, []byte{0x80 | wsOpText, 5, 'h', 'e', 'l', 'l', 'o'})
+
+	opcode, payload := client.receive(t)
+	if opcode != wsOpClose || len(payload) != 2 || binary.BigEndian.Uint16([]byte(payload)) != 1002 {
+		t.Errorf("reply = opcode %d %q, want close 1002", opcode, payload)
+	}
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Errorf("connection still open after the protocol error: %v", err)
+	}
+
+	// o handler vê a conexão fechada
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := http.Get(server.URL + "/test/ws-closed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) == "true" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ws.closed() = %q, want true", body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,353 @@
+package runtime
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// GUID definido pela RFC 6455 para o cálculo do Sec-WebSocket-Accept
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// Tamanho máximo aceito para uma mensagem recebida (16 MiB)
+const wsMaxMessageSize = 16 << 20
+
+// Códigos de fechamento da RFC 6455 usados pelo servidor
+const (
+	wsCloseNormal        = 1000
+	wsCloseProtocolError = 1002
+	wsCloseTooBig        = 1009
+)
+
+var errWebSocketClosed = errors.New("websocket closed")
+
+// wsProtocolError é uma violação do protocolo pelo cliente. A conexão é
+// encerrada com o código de fechamento correspondente.
+type wsProtocolError struct {
+	code    uint16
+	message string
+}
+
+func (e *wsProtocolError) Error() string { return e.message }
+
+type WebSocketConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+	closed  atomic.Bool // lido por closed() e escrito pelo fechamento, de goroutines diferentes
+}
+
+// upgradeWebSocket realiza o handshake do protocolo WebSocket sobre a conexão HTTP.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocketConn, error) {
+	if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, errors.New("missing websocket upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("connection does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &WebSocketConn{conn: conn, reader: rw.Reader}, nil
+}
+
+func websocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContainsToken(h http.Header, name, value string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage lê a próxima mensagem de dados, respondendo pings e fechamentos.
+func (c *WebSocketConn) ReadMessage() (string, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			var protocol *wsProtocolError
+			if errors.As(err, &protocol) {
+				c.closeWith(protocol.code)
+			} else if c.closed.CompareAndSwap(false, true) {
+				c.conn.Close() // o cliente sumiu: não há a quem enviar o fechamento
+			}
+			return "", err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return "", err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			c.Close()
+			return "", errWebSocketClosed
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			message = append(message, payload...)
+			if len(message) > wsMaxMessageSize {
+				c.closeWith(wsCloseTooBig)
+				return "", errors.New("websocket message too large")
+			}
+			if fin {
+				return string(message), nil
+			}
+		default:
+			c.closeWith(wsCloseProtocolError)
+			return "", fmt.Errorf("unknown websocket opcode %d", opcode)
+		}
+	}
+}
+
+func (c *WebSocketConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	if length > wsMaxMessageSize {
+		return false, 0, nil, &wsProtocolError{wsCloseTooBig, "websocket frame too large"}
+	}
+	// a RFC 6455 (seção 5.1) exige que todo frame do cliente seja mascarado
+	if !masked {
+		return false, 0, nil, &wsProtocolError{wsCloseProtocolError, "websocket client frame is not masked"}
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for idx := range payload {
+		payload[idx] ^= mask[idx%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func (c *WebSocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		ext := make([]byte, 8)
+		binary.BigEndian.PutUint64(ext, uint64(n))
+		header = append(header, 127)
+		header = append(header, ext...)
+	}
+
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// WriteMessage envia uma mensagem de texto para o cliente.
+func (c *WebSocketConn) WriteMessage(message string) error {
+	if c.closed.Load() {
+		return errWebSocketClosed
+	}
+	return c.writeFrame(wsOpText, []byte(message))
+}
+
+// Close envia o frame de fechamento (se possível) e encerra a conexão.
+func (c *WebSocketConn) Close() error {
+	return c.closeWith(wsCloseNormal)
+}
+
+// closeWith envia o frame de fechamento com code e encerra a conexão. Só o
+// primeiro fechamento tem efeito.
+func (c *WebSocketConn) closeWith(code uint16) error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, code)
+	c.writeFrame(wsOpClose, payload)
+	return c.conn.Close()
+}
+
+// newWebSocketObject expõe a conexão para scripts Nox com send, receive e close.
+func newWebSocketObject(i *Interpreter, c *WebSocketConn) *MapInstance {
+	return NewMapInstance(map[string]any{
+		"send": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				message, ok := args[0].(string)
+				if !ok {
+					message = StringifyCompact(args[0])
+				}
+				var err error
+				i.released(func() { err = c.WriteMessage(message) })
+				if err != nil {
+					i.Runtime.ReportRuntimeError(nil, "websocket.send: "+err.Error())
+				}
+				return nil
+			},
+		},
+		"receive": &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				var message string
+				var err error
+				i.released(func() { message, err = c.ReadMessage() })
+				if err != nil {
+					return nil // conexão encerrada
+				}
+				return message
+			},
+		},
+		"close": &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				i.released(func() { c.Close() })
+				return nil
+			},
+		},
+		"closed": &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				return c.closed.Load()
+			},
+		},
+	})
+}
+
+// handleWebSocket faz o upgrade e despacha para o handler Nox.
+//
+// Um *Function recebe (req, ws) e controla a conexão diretamente.
+// Uma *Instance recebe callbacks: open(ws), receive(ws, message) e close(ws).
+func handleWebSocket(i *Interpreter, w http.ResponseWriter, r *http.Request, handler any) {
+	i, done := i.handler()
+	defer done()
+
+	reqMap := newRequestObject(i, r)
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	ws := newWebSocketObject(i, conn)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			if exit, ok := rec.(*ExitError); ok {
+				i.exitServer(exit)
+				return
+			}
+			i.reportHandlerError("WebSocket", rec)
+		}
+	}()
+
+	switch h := handler.(type) {
+	case *Function:
+		h.Call(i, []any{reqMap, ws})
+
+	case *Instance:
+		callback := func(name string, args ...any) {
+			if method, ok := h.Class.FindMethod(name); ok {
+				method.Bind(h).Call(i, args)
+			}
+		}
+
+		callback("open", ws)
+		for {
+			var message string
+			var err error
+			i.released(func() { message, err = conn.ReadMessage() })
+			if err != nil {
+				break
+			}
+			callback("receive", ws, message)
+		}
+		callback("close", ws)
+	}
+}
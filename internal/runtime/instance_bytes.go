@@ -0,0 +1,107 @@
+package runtime
+
+import (
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// BytesInstance guarda dados binários, como os lidos por file.read_bytes().
+// Indexar devolve o byte como número (0 a 255).
+type BytesInstance struct {
+	Data []byte
+}
+
+func NewBytesInstance(data []byte) *BytesInstance {
+	return &BytesInstance{Data: data}
+}
+
+func (b *BytesInstance) String() string {
+	return "b" + fmt.Sprintf("%q", b.Data)
+}
+
+func (b *BytesInstance) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "decode":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			if !utf8.Valid(b.Data) {
+				i.Runtime.ReportRuntimeError(name, "bytes.decode: data is not valid UTF-8.")
+				return nil
+			}
+			return string(b.Data)
+		}}
+	case "hex":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			return hex.EncodeToString(b.Data)
+		}}
+	case "to_list":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			values := make([]any, len(b.Data))
+			for idx, c := range b.Data {
+				values[idx] = float64(c)
+			}
+			return NewListInstance(values)
+		}}
+	case "slice":
+		return &BuiltinFunction{ArityValue: -1, CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) < 1 || len(args) > 2 {
+				i.Runtime.ReportRuntimeError(name, "bytes.slice(start, end?) expects 1 or 2 arguments.")
+				return nil
+			}
+			start, ok1 := args[0].(float64)
+			end, ok2 := float64(len(b.Data)), true
+			if len(args) == 2 {
+				end, ok2 = args[1].(float64)
+			}
+			if !ok1 || !ok2 {
+				i.Runtime.ReportRuntimeError(name, "bytes.slice(start, end?) expects numbers.")
+				return nil
+			}
+			if start < 0 || end > float64(len(b.Data)) || start > end {
+				i.Runtime.ReportRuntimeError(name, fmt.Sprintf("bytes.slice: range %d..%d out of bounds for %d bytes.", int(start), int(end), len(b.Data)))
+				return nil
+			}
+			return NewBytesInstance(append([]byte(nil), b.Data[int(start):int(end)]...))
+		}}
+	}
+	return nil
+}
+
+// bytesFrom converte o argumento de bytes(value) e write_bytes: strings
+// viram UTF-8 e listas precisam ter números de 0 a 255.
+func bytesFrom(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case *BytesInstance:
+		return v.Data, nil
+	case string:
+		return []byte(v), nil
+	case *ListInstance:
+		data := make([]byte, len(v.Elements))
+		for idx, el := range v.Elements {
+			n, ok := el.(float64)
+			if !ok || n < 0 || n > 255 || n != float64(int(n)) {
+				return nil, fmt.Errorf("item %d is not a number from 0 to 255", idx)
+			}
+			data[idx] = byte(n)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("expected bytes, a string or a list of numbers, got %s", TypeOf(value))
+}
+
+// RegisterBytesBuiltin cria bytes(value), que copia value para um bytes.
+func RegisterBytesBuiltin(i *Interpreter) *BuiltinFunction {
+	return &BuiltinFunction{
+		ArityValue: 1,
+		CallFunc: func(i *Interpreter, args []any) any {
+			data, err := bytesFrom(args[0])
+			if err != nil {
+				i.Runtime.ReportRuntimeError(nil, "bytes(value): "+err.Error()+".")
+				return nil
+			}
+			return NewBytesInstance(append([]byte(nil), data...))
+		},
+	}
+}
@@ -0,0 +1,223 @@
+package runtime
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// StreamObject é io.stdin, io.stdout ou io.stderr. O fluxo é lido de
+// Nox.Stdin, Nox.Stdout e Nox.Stderr a cada uso, então quem embute o
+// interpretador pode redirecioná-los.
+type StreamObject struct {
+	runtime *Nox
+	name    string // "stdin", "stdout" ou "stderr"
+}
+
+func (s *StreamObject) String() string {
+	return "<stream " + s.name + ">"
+}
+
+func (s *StreamObject) writer() io.Writer {
+	if s.name == "stderr" {
+		return s.runtime.stderr()
+	}
+	return s.runtime.stdout()
+}
+
+func (s *StreamObject) Get(name *token.Token) any {
+	if s.name == "stdin" {
+		return s.getReader(name)
+	}
+	return s.getWriter(name)
+}
+
+func (s *StreamObject) getReader(name *token.Token) any {
+	switch name.Lexeme {
+	case "read":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			var data []byte
+			var err error
+			i.readStdin(func(r *bufio.Reader) { data, err = io.ReadAll(r) })
+			if err != nil {
+				i.Runtime.ReportRuntimeError(name, "stdin.read: "+err.Error())
+				return nil
+			}
+			return string(data)
+		}}
+	case "readline":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			line, ok := s.readLine(i)
+			if !ok {
+				return nil // fim da entrada
+			}
+			return line
+		}}
+	case "readlines":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			var lines []any
+			for {
+				line, ok := s.readLine(i)
+				if !ok {
+					return NewListInstance(lines)
+				}
+				lines = append(lines, line)
+			}
+		}}
+	case "isatty":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			if s.runtime.Stdin == nil {
+				return isTerminal(os.Stdin)
+			}
+			return isTerminal(s.runtime.Stdin)
+		}}
+	}
+	return nil
+}
+
+func (s *StreamObject) getWriter(name *token.Token) any {
+	switch name.Lexeme {
+	case "write":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			data, ok := args[0].(string)
+			if !ok {
+				i.Runtime.ReportRuntimeError(name, s.name+".write(data) expects a string.")
+				return nil
+			}
+			if _, err := io.WriteString(s.writer(), data); err != nil {
+				i.Runtime.ReportRuntimeError(name, s.name+".write: "+err.Error())
+			}
+			return nil
+		}}
+	case "flush":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			if flusher, ok := s.writer().(interface{ Flush() error }); ok {
+				if err := flusher.Flush(); err != nil {
+					i.Runtime.ReportRuntimeError(name, s.name+".flush: "+err.Error())
+				}
+			}
+			return nil
+		}}
+	case "isatty":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			return isTerminal(s.writer())
+		}}
+	}
+	return nil
+}
+
+// readLine lê a próxima linha de stdin, sem a quebra de linha. ok é false
+// no fim da entrada.
+func (s *StreamObject) readLine(i *Interpreter) (line string, ok bool) {
+	var err error
+	i.readStdin(func(r *bufio.Reader) { line, ok, err = readLine(r) })
+	if err != nil {
+		i.Runtime.ReportRuntimeError(nil, "stdin.readline: "+err.Error())
+	}
+	return line, ok
+}
+
+// NextLine faz "for line in io.stdin" percorrer a entrada linha a linha.
+func (s *StreamObject) NextLine(i *Interpreter) (string, bool) {
+	if s.name != "stdin" {
+		i.Runtime.ReportRuntimeError(nil, "Cannot iterate over "+s.name+": it is not readable.")
+	}
+	return s.readLine(i)
+}
+
+// LineIterator é implementado pelos objetos que "for line in obj" percorre
+// linha a linha.
+type LineIterator interface {
+	NextLine(i *Interpreter) (string, bool)
+}
+
+// readStdin executa read sobre o leitor de Nox.Stdin. A leitura espera o
+// usuário, então não segura a trava dos handlers de http.serve (ver
+// Interpreter.released); Nox.inputMu impede duas leituras ao mesmo tempo.
+func (i *Interpreter) readStdin(read func(r *bufio.Reader)) {
+	i.released(func() {
+		i.Runtime.inputMu.Lock()
+		defer i.Runtime.inputMu.Unlock()
+		read(i.Runtime.stdin())
+	})
+}
+
+// readLine lê uma linha de r sem "\n" nem "\r\n". A última linha pode não
+// ter quebra; depois dela ok é false.
+func readLine(r *bufio.Reader) (line string, ok bool, err error) {
+	line, err = r.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+		err = nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(line, "\r\n"), true, nil
+}
+
+// isTerminal diz se o fluxo é um terminal, e não um arquivo ou pipe.
+func isTerminal(stream any) bool {
+	file, ok := stream.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func NewIoModule(runtime *Nox) *MapInstance {
+	return NewMapInstance(map[string]any{
+		"stdin":  &StreamObject{runtime: runtime, name: "stdin"},
+		"stdout": &StreamObject{runtime: runtime, name: "stdout"},
+		"stderr": &StreamObject{runtime: runtime, name: "stderr"},
+	})
+}
+
+// RegisterInputBuiltin cria input(prompt?), que lê uma linha de stdin.
+func RegisterInputBuiltin(i *Interpreter) *BuiltinFunction {
+	return &BuiltinFunction{
+		ArityValue: -1,
+		CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) > 1 {
+				i.Runtime.ReportRuntimeError(nil, "input(prompt?) expects at most 1 argument.")
+				return nil
+			}
+			if len(args) == 1 {
+				io.WriteString(i.Runtime.stdout(), StringifyCompact(args[0]))
+			}
+			var line string
+			var ok bool
+			var err error
+			i.readStdin(func(r *bufio.Reader) { line, ok, err = readLine(r) })
+			if err != nil {
+				i.Runtime.ReportRuntimeError(nil, "input: "+err.Error())
+				return nil
+			}
+			if !ok {
+				return nil // fim da entrada
+			}
+			return line
+		},
+	}
+}
+
+// RegisterEprintBuiltin cria eprint(...), o print para stderr.
+func RegisterEprintBuiltin(i *Interpreter) *BuiltinFunction {
+	return &BuiltinFunction{
+		ArityValue: -1,
+		CallFunc: func(i *Interpreter, args []any) any {
+			parts := make([]string, len(args))
+			for idx, arg := range args {
+				parts[idx] = i.stringify(arg)
+			}
+			io.WriteString(i.Runtime.stderr(), strings.Join(parts, " ")+"\n")
+			return nil
+		},
+	}
+}
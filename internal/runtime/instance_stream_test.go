@@ -0,0 +1,150 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConsoleIO(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdin  string
+		source string
+		stdout string
+		stderr string
+		err    string // trecho do erro de execução; vazio quando o script termina
+	}{
+		{
+			name:   "input",
+			stdin:  "ann\nbob\n",
+			source: "print input();\nprint input();\nprint input();",
+			stdout: "ann\nbob\n<nil>\n",
+		},
+		{
+			name:  "input with prompt",
+			stdin: "ann\n",
+			source: `let name = input("name: ");
+print "hi " + name;`,
+			stdout: "name: hi ann\n",
+		},
+		{
+			name:   "input without a final newline",
+			stdin:  "a\r\nb",
+			source: "print input() + input();",
+			stdout: "ab\n",
+		},
+		{
+			name:   "input with too many arguments",
+			source: `input("a", "b");`,
+			err:    "input(prompt?) expects at most 1 argument.",
+		},
+		{
+			name: "eprint",
+			source: `eprint("a", 1, true);
+eprint();`,
+			stderr: "a 1 true\n\n",
+		},
+		{
+			name:  "read",
+			stdin: "x\ny\n",
+			source: `print io.stdin.read() == "x
+y
+";
+print io.stdin.read() == "";`,
+			stdout: "true\ntrue\n",
+		},
+		{
+			name:   "readline",
+			stdin:  "one\r\ntwo",
+			source: "print io.stdin.readline();\nprint io.stdin.readline();\nprint io.stdin.readline();",
+			stdout: "one\ntwo\n<nil>\n",
+		},
+		{
+			name:   "readlines",
+			stdin:  "a\nb\n",
+			source: "let lines = io.stdin.readlines();\nprint len(lines);\nprint lines[0] + lines[1];\nprint len(io.stdin.readlines());",
+			stdout: "2\nab\n0\n",
+		},
+		{
+			name:   "readers share the input",
+			stdin:  "1\n2\n3\n4\n",
+			source: "print input();\nprint io.stdin.readline();\nprint len(io.stdin.readlines());",
+			stdout: "1\n2\n2\n",
+		},
+		{
+			name:  "for line in io.stdin",
+			stdin: "a\nb\n\nc",
+			source: `for line in io.stdin {
+    print "> " + line;
+}`,
+			stdout: "> a\n> b\n> \n> c\n",
+		},
+		{
+			name:  "for after readline",
+			stdin: "header\nrow\n",
+			source: `let header = io.stdin.readline();
+for line in io.stdin {
+    print header + ": " + line;
+}`,
+			stdout: "header: row\n",
+		},
+		{
+			name:   "for over an empty stdin",
+			source: "for line in io.stdin {\n    print line;\n}\nprint \"done\";",
+			stdout: "done\n",
+		},
+		{
+			name:   "for over stdout",
+			source: "for line in io.stdout {\n}",
+			err:    "Cannot iterate over stdout: it is not readable.",
+		},
+		{
+			name: "stdout.write",
+			source: `io.stdout.write("a");
+io.stdout.write("b
+");
+io.stdout.flush();`,
+			stdout: "ab\n",
+		},
+		{
+			name: "stderr.write",
+			source: `io.stderr.write("e");
+print "out";`,
+			stdout: "out\n",
+			stderr: "e",
+		},
+		{
+			name:   "write expects a string",
+			source: "io.stdout.write(1);",
+			err:    "stdout.write(data) expects a string.",
+		},
+		{
+			name:   "isatty",
+			source: "print io.stdin.isatty();\nprint io.stdout.isatty();\nprint io.stderr.isatty();",
+			stdout: "false\nfalse\nfalse\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNox()
+			var out, errOut strings.Builder
+			n.Stdout, n.Stderr, n.Stdin = &out, &errOut, strings.NewReader(tt.stdin)
+			err := n.run(tt.source, NewInterpreter(n, false))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("script failed: %v", err)
+			}
+			if out.String() != tt.stdout {
+				t.Errorf("stdout = %q, want %q", out.String(), tt.stdout)
+			}
+			if errOut.String() != tt.stderr {
+				t.Errorf("stderr = %q, want %q", errOut.String(), tt.stderr)
+			}
+		})
+	}
+}
@@ -107,6 +107,21 @@ func (s *StringInstance) GetMethod(name string) any {
 				return strings.Contains(s.Value, substr)
 			},
 		}
+	case "matches":
+		return &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(interpreter *Interpreter, args []any) any {
+				if len(args) != 1 {
+					interpreter.Runtime.ReportRuntimeError(nil, "String.matches expects 1 argument.")
+					return nil
+				}
+				re := compileRegex(interpreter, "String.matches", args[0])
+				if re == nil {
+					return nil
+				}
+				return re.Regexp.MatchString(s.Value)
+			},
+		}
 	case "index_of":
 		return &BuiltinFunction{
 			ArityValue: 1,
@@ -0,0 +1,479 @@
+package runtime
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // garante timezones mesmo sem base do sistema (ex: Windows)
+
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// ===== Instant =====
+
+type TimeInstance struct {
+	Time time.Time
+}
+
+func NewTimeInstance(t time.Time) *TimeInstance {
+	return &TimeInstance{Time: t}
+}
+
+func (t *TimeInstance) String() string {
+	return t.Time.Format(time.RFC3339Nano)
+}
+
+func (t *TimeInstance) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "year":
+		return timeGetter(func() any { return float64(t.Time.Year()) })
+	case "month":
+		return timeGetter(func() any { return float64(t.Time.Month()) })
+	case "day":
+		return timeGetter(func() any { return float64(t.Time.Day()) })
+	case "hour":
+		return timeGetter(func() any { return float64(t.Time.Hour()) })
+	case "minute":
+		return timeGetter(func() any { return float64(t.Time.Minute()) })
+	case "second":
+		return timeGetter(func() any { return float64(t.Time.Second()) })
+	case "nanosecond":
+		return timeGetter(func() any { return float64(t.Time.Nanosecond()) })
+	case "weekday":
+		return timeGetter(func() any { return t.Time.Weekday().String() })
+	case "yearday":
+		return timeGetter(func() any { return float64(t.Time.YearDay()) })
+	case "zone":
+		return timeGetter(func() any { return t.Time.Location().String() })
+	case "unix":
+		return timeGetter(func() any { return float64(t.Time.UnixNano()) / 1e9 })
+	case "unix_ms":
+		return timeGetter(func() any { return float64(t.Time.UnixMilli()) })
+	case "utc":
+		return timeGetter(func() any { return NewTimeInstance(t.Time.UTC()) })
+	case "local":
+		return timeGetter(func() any { return NewTimeInstance(t.Time.Local()) })
+	case "format":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) != 1 {
+				i.Runtime.ReportRuntimeError(name, "time.format(layout) expects 1 argument.")
+				return nil
+			}
+			layout, ok := args[0].(string)
+			if !ok {
+				i.Runtime.ReportRuntimeError(name, "time.format(layout) expects a string layout.")
+				return nil
+			}
+			return t.Time.Format(layout)
+		}}
+	case "in_zone":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) != 1 {
+				i.Runtime.ReportRuntimeError(name, "time.in_zone(zone) expects 1 argument.")
+				return nil
+			}
+			loc := loadLocation(i, name, args[0])
+			if loc == nil {
+				return nil
+			}
+			return NewTimeInstance(t.Time.In(loc))
+		}}
+	case "add":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) != 1 {
+				i.Runtime.ReportRuntimeError(name, "time.add(duration) expects 1 argument.")
+				return nil
+			}
+			d, ok := toDuration(args[0])
+			if !ok {
+				i.Runtime.ReportRuntimeError(name, "time.add(duration) expects a duration or a number of seconds.")
+				return nil
+			}
+			return NewTimeInstance(t.Time.Add(d))
+		}}
+	case "sub":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) != 1 {
+				i.Runtime.ReportRuntimeError(name, "time.sub(value) expects 1 argument.")
+				return nil
+			}
+			if other, ok := args[0].(*TimeInstance); ok {
+				return NewDurationInstance(t.Time.Sub(other.Time))
+			}
+			d, ok := toDuration(args[0])
+			if !ok {
+				i.Runtime.ReportRuntimeError(name, "time.sub(value) expects a time, a duration or a number of seconds.")
+				return nil
+			}
+			return NewTimeInstance(t.Time.Add(-d))
+		}}
+	case "before", "after", "equal":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) != 1 {
+				i.Runtime.ReportRuntimeError(name, fmt.Sprintf("time.%s(other) expects 1 argument.", name.Lexeme))
+				return nil
+			}
+			other, ok := args[0].(*TimeInstance)
+			if !ok {
+				i.Runtime.ReportRuntimeError(name, fmt.Sprintf("time.%s(other) expects a time.", name.Lexeme))
+				return nil
+			}
+			switch name.Lexeme {
+			case "before":
+				return t.Time.Before(other.Time)
+			case "after":
+				return t.Time.After(other.Time)
+			default:
+				return t.Time.Equal(other.Time)
+			}
+		}}
+	default:
+		return nil
+	}
+}
+
+// ===== Duration =====
+
+type DurationInstance struct {
+	Duration time.Duration
+}
+
+func NewDurationInstance(d time.Duration) *DurationInstance {
+	return &DurationInstance{Duration: d}
+}
+
+func (d *DurationInstance) String() string {
+	return d.Duration.String()
+}
+
+func (d *DurationInstance) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "hours":
+		return timeGetter(func() any { return d.Duration.Hours() })
+	case "minutes":
+		return timeGetter(func() any { return d.Duration.Minutes() })
+	case "seconds":
+		return timeGetter(func() any { return d.Duration.Seconds() })
+	case "milliseconds":
+		return timeGetter(func() any { return float64(d.Duration.Milliseconds()) })
+	case "nanoseconds":
+		return timeGetter(func() any { return float64(d.Duration.Nanoseconds()) })
+	case "abs":
+		return timeGetter(func() any { return NewDurationInstance(d.Duration.Abs()) })
+	default:
+		return nil
+	}
+}
+
+// ===== Helpers =====
+
+func timeGetter(fn func() any) *BuiltinFunction {
+	return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+		return fn()
+	}}
+}
+
+// toDuration aceita durações ou números (em segundos).
+func toDuration(v any) (time.Duration, bool) {
+	switch val := v.(type) {
+	case *DurationInstance:
+		return val.Duration, true
+	case float64:
+		return time.Duration(val * float64(time.Second)), true
+	default:
+		return 0, false
+	}
+}
+
+func loadLocation(i *Interpreter, t *token.Token, v any) *time.Location {
+	name, ok := v.(string)
+	if !ok {
+		i.Runtime.ReportRuntimeError(t, "Timezone must be a string, e.g. \"UTC\" or \"America/Sao_Paulo\".")
+		return nil
+	}
+	if name == "local" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		i.Runtime.ReportRuntimeError(t, "Unknown timezone: "+name)
+		return nil
+	}
+	return loc
+}
+
+// timeBinary implementa aritmética e comparação entre instantes e durações.
+// Retorna false quando os operandos não são valores de tempo.
+func (i *Interpreter) timeBinary(op *token.Token, left, right any) (any, bool) {
+	switch l := left.(type) {
+	case *TimeInstance:
+		switch r := right.(type) {
+		case *TimeInstance:
+			switch op.Type {
+			case token.TokenType_MINUS:
+				return NewDurationInstance(l.Time.Sub(r.Time)), true
+			case token.TokenType_LESS:
+				return l.Time.Before(r.Time), true
+			case token.TokenType_LESS_EQUAL:
+				return !l.Time.After(r.Time), true
+			case token.TokenType_GREATER:
+				return l.Time.After(r.Time), true
+			case token.TokenType_GREATER_EQUAL:
+				return !l.Time.Before(r.Time), true
+			case token.TokenType_EQUAL_EQUAL:
+				return l.Time.Equal(r.Time), true
+			case token.TokenType_BANG_EQUAL:
+				return !l.Time.Equal(r.Time), true
+			}
+		case *DurationInstance:
+			switch op.Type {
+			case token.TokenType_PLUS:
+				return NewTimeInstance(l.Time.Add(r.Duration)), true
+			case token.TokenType_MINUS:
+				return NewTimeInstance(l.Time.Add(-r.Duration)), true
+			}
+		}
+
+	case *DurationInstance:
+		switch r := right.(type) {
+		case *DurationInstance:
+			switch op.Type {
+			case token.TokenType_PLUS:
+				return NewDurationInstance(l.Duration + r.Duration), true
+			case token.TokenType_MINUS:
+				return NewDurationInstance(l.Duration - r.Duration), true
+			case token.TokenType_SLASH:
+				if r.Duration == 0 {
+					i.Runtime.ReportRuntimeError(op, "Division by zero.")
+					return nil, true
+				}
+				return float64(l.Duration) / float64(r.Duration), true
+			case token.TokenType_LESS:
+				return l.Duration < r.Duration, true
+			case token.TokenType_LESS_EQUAL:
+				return l.Duration <= r.Duration, true
+			case token.TokenType_GREATER:
+				return l.Duration > r.Duration, true
+			case token.TokenType_GREATER_EQUAL:
+				return l.Duration >= r.Duration, true
+			}
+		case *TimeInstance:
+			if op.Type == token.TokenType_PLUS {
+				return NewTimeInstance(r.Time.Add(l.Duration)), true
+			}
+		case float64:
+			switch op.Type {
+			case token.TokenType_STAR:
+				return NewDurationInstance(time.Duration(float64(l.Duration) * r)), true
+			case token.TokenType_SLASH:
+				if r == 0 {
+					i.Runtime.ReportRuntimeError(op, "Division by zero.")
+					return nil, true
+				}
+				return NewDurationInstance(time.Duration(float64(l.Duration) / r)), true
+			}
+		}
+
+	case float64:
+		if r, ok := right.(*DurationInstance); ok && op.Type == token.TokenType_STAR {
+			return NewDurationInstance(time.Duration(l * float64(r.Duration))), true
+		}
+	}
+
+	return nil, false
+}
+
+// ===== Module =====
+
+func NewTimeModule() *MapInstance {
+	return NewMapInstance(map[string]any{
+		"now": &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				return NewTimeInstance(time.Now())
+			},
+		},
+		"unix": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) != 1 {
+					i.Runtime.ReportRuntimeError(nil, "time.unix(seconds) expects 1 argument.")
+					return nil
+				}
+				secs, ok := args[0].(float64)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "time.unix(seconds) expects a number.")
+					return nil
+				}
+				return NewTimeInstance(time.Unix(0, int64(secs*1e9)))
+			},
+		},
+		"unix_ms": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) != 1 {
+					i.Runtime.ReportRuntimeError(nil, "time.unix_ms(milliseconds) expects 1 argument.")
+					return nil
+				}
+				ms, ok := args[0].(float64)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "time.unix_ms(milliseconds) expects a number.")
+					return nil
+				}
+				return NewTimeInstance(time.UnixMilli(int64(ms)))
+			},
+		},
+		"date": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) < 3 || len(args) > 7 {
+					i.Runtime.ReportRuntimeError(nil, "time.date(year, month, day, hour?, minute?, second?, zone?) expects 3 to 7 arguments.")
+					return nil
+				}
+				loc := time.Local
+				parts := [6]int{}
+				for idx, arg := range args {
+					if idx == 6 {
+						if loc = loadLocation(i, nil, arg); loc == nil {
+							return nil
+						}
+						break
+					}
+					n, ok := arg.(float64)
+					if !ok {
+						i.Runtime.ReportRuntimeError(nil, "time.date expects numeric date components.")
+						return nil
+					}
+					parts[idx] = int(n)
+				}
+				return NewTimeInstance(time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, loc))
+			},
+		},
+		"parse": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) < 1 || len(args) > 3 {
+					i.Runtime.ReportRuntimeError(nil, "time.parse(value, layout?, zone?) expects 1 to 3 arguments.")
+					return nil
+				}
+				value, ok := args[0].(string)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "time.parse expects a string value.")
+					return nil
+				}
+				layout := time.RFC3339
+				if len(args) >= 2 {
+					if layout, ok = args[1].(string); !ok {
+						i.Runtime.ReportRuntimeError(nil, "time.parse expects a string layout.")
+						return nil
+					}
+				}
+				loc := time.UTC
+				if len(args) == 3 {
+					if loc = loadLocation(i, nil, args[2]); loc == nil {
+						return nil
+					}
+				}
+				t, err := time.ParseInLocation(layout, value, loc)
+				if err != nil {
+					i.Runtime.ReportRuntimeError(nil, "time.parse: "+err.Error())
+					return nil
+				}
+				return NewTimeInstance(t)
+			},
+		},
+		"format": &BuiltinFunction{
+			ArityValue: 2,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) != 2 {
+					i.Runtime.ReportRuntimeError(nil, "time.format(time, layout) expects 2 arguments.")
+					return nil
+				}
+				t, ok1 := args[0].(*TimeInstance)
+				layout, ok2 := args[1].(string)
+				if !ok1 || !ok2 {
+					i.Runtime.ReportRuntimeError(nil, "time.format(time, layout) expects a time and a string layout.")
+					return nil
+				}
+				return t.Time.Format(layout)
+			},
+		},
+		"duration": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) != 1 {
+					i.Runtime.ReportRuntimeError(nil, "time.duration(value) expects 1 argument.")
+					return nil
+				}
+				if s, ok := args[0].(string); ok {
+					d, err := time.ParseDuration(s)
+					if err != nil {
+						i.Runtime.ReportRuntimeError(nil, "time.duration: "+err.Error())
+						return nil
+					}
+					return NewDurationInstance(d)
+				}
+				d, ok := toDuration(args[0])
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "time.duration(value) expects a number of seconds or a string like \"1h30m\".")
+					return nil
+				}
+				return NewDurationInstance(d)
+			},
+		},
+		"since": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) != 1 {
+					i.Runtime.ReportRuntimeError(nil, "time.since(time) expects 1 argument.")
+					return nil
+				}
+				t, ok := args[0].(*TimeInstance)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "time.since(time) expects a time.")
+					return nil
+				}
+				return NewDurationInstance(time.Since(t.Time))
+			},
+		},
+		"sleep": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) != 1 {
+					i.Runtime.ReportRuntimeError(nil, "time.sleep(duration) expects 1 argument.")
+					return nil
+				}
+				d, ok := toDuration(args[0])
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "time.sleep(duration) expects a duration or a number of seconds.")
+					return nil
+				}
+				// o cancelamento de Nox.Context interrompe a espera
+				i.released(func() {
+					select {
+					case <-time.After(d):
+					case <-i.context().Done():
+						panic(i.checkContext())
+					}
+				})
+				return nil
+			},
+		},
+
+		// Unidades de duração
+		"nanosecond":  NewDurationInstance(time.Nanosecond),
+		"millisecond": NewDurationInstance(time.Millisecond),
+		"second":      NewDurationInstance(time.Second),
+		"minute":      NewDurationInstance(time.Minute),
+		"hour":        NewDurationInstance(time.Hour),
+
+		// Layouts comuns (formato de referência do Go)
+		"RFC3339":     time.RFC3339,
+		"RFC3339Nano": time.RFC3339Nano,
+		"RFC1123":     time.RFC1123,
+		"RFC822":      time.RFC822,
+		"Kitchen":     time.Kitchen,
+		"DateTime":    time.DateTime,
+		"DateOnly":    time.DateOnly,
+		"TimeOnly":    time.TimeOnly,
+	})
+}
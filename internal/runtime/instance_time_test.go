@@ -0,0 +1,39 @@
+package runtime
+
+import "testing"
+
+func TestTimeArithmetic(t *testing.T) {
+	runExprTests(t, `
+import "time" as time;
+let t = time.date(2024, 1, 31, 10, 0, 0, "UTC");
+`, []exprTest{
+		{`t + time.hour * 2`, "2024-01-31T12:00:00Z"},
+		{`t - time.minute * 30`, "2024-01-31T09:30:00Z"},
+		{`(t + time.hour * 36).format(time.DateTime)`, "2024-02-01 22:00:00"},
+		{`(time.date(2024, 3, 1, 0, 0, 0, "UTC") - t).hours()`, "710"},
+		{`t.add(time.duration("1h30m")).minute()`, "30"},
+		{`t.sub(time.second * 10).second()`, "50"},
+		{`time.duration("1h30m") / time.minute`, "90"},
+		{`(time.hour + time.minute * 30).minutes()`, "90"},
+		{`(time.second * 3 / 2).milliseconds()`, "1500"},
+		{`(time.second - time.minute).abs().seconds()`, "59"},
+		{`t < t + time.second, t >= t, t == time.parse("2024-01-31T10:00:00Z")`, "true true true"},
+		{`t.before(t + time.second), t.after(t + time.second)`, "true false"},
+		{`t.weekday(), t.yearday()`, "Wednesday 31"},
+		{`time.unix(0).utc()`, "1970-01-01T00:00:00Z"},
+		{`time.parse("31/01/2024 10:00", "02/01/2006 15:04") == t`, "true"},
+		{`t.in_zone("America/Sao_Paulo").hour()`, "7"},
+	})
+}
+
+func TestTimeJSON(t *testing.T) {
+	runExprTests(t, `
+import "time" as time;
+let t = time.date(2024, 1, 31, 10, 0, 0, "UTC");
+`, []exprTest{
+		{`json.encode({"at": t})`, "{\n  \"at\": \"2024-01-31T10:00:00Z\"\n}"},
+		{`json.encode([time.minute * 90, time.millisecond * 250])`, "[\n  5400,\n  0.25\n]"},
+		// decodificar devolve o texto, que time.parse converte de volta
+		{`time.parse(json.decode(json.encode({"at": t}))["at"]) == t`, "true"},
+	})
+}
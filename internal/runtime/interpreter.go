@@ -3,7 +3,9 @@ package runtime
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/MichelLacerda/nox/internal/ast"
 	"github.com/MichelLacerda/nox/internal/token"
@@ -11,12 +13,24 @@ import (
 
 type Interpreter struct {
 	Runtime      *Nox
-	globals      *Environment
+	builtins     *Environment // funções e módulos builtin, compartilhados pelos módulos
+	globals      *Environment // escopo de topo do script
 	locals       map[ast.Expr]int
 	environment  *Environment
 	silentErrors bool
 	debug        bool // Modo de depuração
 	Colored      bool // Se deve usar cores na saída
+	frames       []*StackFrame
+	currentFile  string          // arquivo em execução, usado nos frames e tracebacks
+	currentDir   string          // pasta do módulo em execução, base dos imports relativos
+	debugger     *Debugger       // nil fora do "nox debug"
+	profiler     *Profiler       // nil fora do "nox run --profile"
+	coverage     *Coverage       // nil fora do "nox run --cover"
+	sandboxErr   error           // configuração inválida de Runtime.Sandbox, devolvida por Run
+	steps        int64           // instruções executadas, para Runtime.Limits
+	heapBase     int64           // tamanho do heap na criação, base de Limits.MaxMemory
+	serverExit   chan *ExitError // os.exit chamado por um handler de http.serve
+	handlerLock  *sync.Mutex     // Runtime.handlers, quando este é o fork de um handler
 }
 
 type HasMethods interface {
@@ -24,18 +38,24 @@ type HasMethods interface {
 }
 
 func NewInterpreter(r *Nox, colored bool) *Interpreter {
+	builtins := NewEnvironment(r, nil)
 	interpreter := &Interpreter{
 		Runtime:      r,
-		globals:      NewEnvironment(r, nil),
+		builtins:     builtins,
+		globals:      newGlobals(r, builtins),
 		environment:  nil, // Inicialmente nil
 		locals:       map[ast.Expr]int{},
 		silentErrors: true,    // Inicialmente não silencioso
 		debug:        false,   // Modo de depuração desativado por padrão
 		Colored:      colored, // Cores ativadas por padrão
+		heapBase:     heapBytes(),
 	}
 	interpreter.environment = interpreter.globals // Aponta para o global no início
+	interpreter.resetStack("<script>", "")
 	RegisterBuiltins(interpreter)
-
+	if r.Sandbox != nil {
+		interpreter.sandboxErr = interpreter.applySandbox(r.Sandbox)
+	}
 	return interpreter
 }
 
@@ -46,6 +66,17 @@ func (i *Interpreter) Interpret(expr []ast.Stmt) {
 }
 
 func (i *Interpreter) execute(s ast.Stmt) error {
+	i.markLine(stmtLine(s))
+	i.tick(s)
+	if i.debugger != nil {
+		i.debugger.before(s)
+	}
+	if i.profiler != nil {
+		i.profiler.before(i, s)
+	}
+	if i.coverage != nil {
+		i.coverage.statement(s)
+	}
 	s.Accept(i)
 	return nil
 }
@@ -72,7 +103,7 @@ func (i *Interpreter) lookUpVariable(t *token.Token, expr ast.Expr) any {
 	if depth, ok := i.locals[expr]; ok {
 		return i.environment.GetAt(depth, t.Lexeme)
 	}
-	return i.globals.Get(t)
+	return i.environment.globals.Get(t)
 }
 
 // ===== Helpers =====
@@ -127,6 +158,18 @@ func (i *Interpreter) stringify(value any) string {
 	return StringifyCompact(value)
 }
 
+// Repr mostra um valor como ele seria escrito no código: strings entre
+// aspas e nil por extenso. Usado em mensagens de asserção e no depurador.
+func Repr(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	}
+	return StringifyCompact(value)
+}
+
 func StringifyCompact(value any) string {
 	switch v := value.(type) {
 	case *ListInstance:
@@ -186,3 +229,14 @@ func StringifyColor(value any, indent string) string {
 		}
 	}
 }
+
+// Globals expõe as declarações de topo do script.
+func (i *Interpreter) Globals() map[string]any {
+	return i.globals.Values
+}
+
+// Builtins expõe as funções e módulos builtin, usado por ferramentas como o
+// lint.
+func (i *Interpreter) Builtins() map[string]any {
+	return i.builtins.Values
+}
@@ -5,18 +5,26 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
 	"github.com/MichelLacerda/nox/internal/token"
 )
 
+// FileObject é um arquivo aberto por open(). Todas as leituras passam por
+// Reader, então read, readline e "for line in file" podem ser misturados;
+// antes de escrever ou mudar de posição o que está no buffer é descartado
+// e o arquivo volta para a posição lógica.
 type FileObject struct {
 	File   *os.File
 	Reader *bufio.Reader
+	closed bool
 }
 
 var _ HasMethods = (*FileObject)(nil)
 
+func NewFileObject(file *os.File) *FileObject {
+	return &FileObject{File: file, Reader: bufio.NewReader(file)}
+}
+
 func (f *FileObject) TypeName() string {
 	return "File"
 }
@@ -25,13 +33,80 @@ func (f *FileObject) String() string {
 	return fmt.Sprintf("<file %s>", f.File.Name())
 }
 
+// reader devolve o leitor do arquivo, criado se o FileObject foi montado
+// sem ele.
+func (f *FileObject) reader() *bufio.Reader {
+	if f.Reader == nil {
+		f.Reader = bufio.NewReader(f.File)
+	}
+	return f.Reader
+}
+
+// unread desfaz a leitura antecipada do buffer, para que o arquivo fique na
+// posição que o script já leu.
+func (f *FileObject) unread() error {
+	if f.Reader == nil {
+		return nil
+	}
+	if buffered := f.Reader.Buffered(); buffered > 0 {
+		if _, err := f.File.Seek(-int64(buffered), io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+	f.Reader.Reset(f.File)
+	return nil
+}
+
+// tell devolve a posição lógica: a do arquivo menos o que está no buffer.
+func (f *FileObject) tell() (int64, error) {
+	pos, err := f.File.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if f.Reader != nil {
+		pos -= int64(f.Reader.Buffered())
+	}
+	return pos, nil
+}
+
+// read lê size bytes, ou tudo o que resta quando size é negativo.
+func (f *FileObject) read(size int64) ([]byte, error) {
+	if size < 0 {
+		return io.ReadAll(f.reader())
+	}
+	return io.ReadAll(io.LimitReader(f.reader(), size))
+}
+
+// NextLine faz "for line in file" percorrer o arquivo linha a linha.
+func (f *FileObject) NextLine(i *Interpreter) (string, bool) {
+	line, ok, err := readLine(f.reader())
+	if err != nil {
+		i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "readline"}, "readline error: "+err.Error())
+	}
+	return line, ok
+}
+
+// readSize lê o argumento opcional de read(size?) e read_bytes(size?).
+func readSize(i *Interpreter, method string, args []any) int64 {
+	if len(args) == 0 || args[0] == nil {
+		return -1
+	}
+	size, ok := args[0].(float64)
+	if len(args) > 1 || !ok || size < 0 {
+		i.Runtime.ReportRuntimeError(&token.Token{Lexeme: method}, method+"(size?) expects a non-negative number")
+	}
+	return int64(size)
+}
+
 func (f *FileObject) GetMethod(name string) any {
 	switch name {
+	case "name":
+		return f.File.Name()
 	case "read":
 		return &BuiltinFunction{
-			ArityValue: 0,
+			ArityValue: -1,
 			CallFunc: func(i *Interpreter, args []any) any {
-				data, err := io.ReadAll(f.File)
+				data, err := f.read(readSize(i, "read", args))
 				if err != nil {
 					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "read"}, "read error: "+err.Error())
 					return nil
@@ -41,39 +116,39 @@ func (f *FileObject) GetMethod(name string) any {
 		}
 	case "read_bytes":
 		return &BuiltinFunction{
-			ArityValue: 0,
+			ArityValue: -1,
 			CallFunc: func(i *Interpreter, args []any) any {
-				data, err := io.ReadAll(f.File)
+				data, err := f.read(readSize(i, "read_bytes", args))
 				if err != nil {
 					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "read_bytes"}, "read_bytes error: "+err.Error())
 					return nil
 				}
-				return data
+				return NewBytesInstance(data)
 			},
 		}
 	case "readline":
 		return &BuiltinFunction{
 			ArityValue: 0,
 			CallFunc: func(i *Interpreter, args []any) any {
-				if f.Reader == nil {
-					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "readline"}, "File not readable.")
-					return nil
+				line, ok := f.NextLine(i)
+				if !ok {
+					return nil // Fim de arquivo
 				}
-
-				line, err := f.Reader.ReadString('\n')
-				if err != nil {
-					if err == io.EOF {
-						if line == "" {
-							return nil // Fim de arquivo
-						}
-						// Última linha sem \n
-						return strings.TrimRight(line, "\n\r")
+				return line
+			},
+		}
+	case "readlines":
+		return &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				var lines []any
+				for {
+					line, ok := f.NextLine(i)
+					if !ok {
+						return NewListInstance(lines)
 					}
-					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "readline"}, "readline error: "+err.Error())
-					return nil
+					lines = append(lines, line)
 				}
-
-				return strings.TrimRight(line, "\n\r")
 			},
 		}
 
@@ -86,11 +161,7 @@ func (f *FileObject) GetMethod(name string) any {
 					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "write"}, "write() expects a string")
 					return nil
 				}
-				_, err := f.File.WriteString(str)
-				if err != nil {
-					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "write"}, "write error: "+err.Error())
-					return nil
-				}
+				f.write(i, "write", []byte(str))
 				return nil
 			},
 		}
@@ -98,21 +169,35 @@ func (f *FileObject) GetMethod(name string) any {
 		return &BuiltinFunction{
 			ArityValue: 1,
 			CallFunc: func(i *Interpreter, args []any) any {
-				str, ok := args[0].(string)
-				if !ok {
-					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "write_bytes"}, "write_bytes() expects a string")
+				data, err := bytesFrom(args[0])
+				if err != nil {
+					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "write_bytes"}, "write_bytes() "+err.Error())
 					return nil
 				}
-				bytes := []byte(str)
+				f.write(i, "write_bytes", data)
+				return nil
+			},
+		}
+	case "writelines":
+		return &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				list, ok := args[0].(*ListInstance)
 				if !ok {
-					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "write_bytes"}, "write_bytes() expects a byte slice")
+					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "writelines"}, "writelines() expects a list of strings")
 					return nil
 				}
-				_, err := f.File.Write(bytes)
-				if err != nil {
-					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "write_bytes"}, "write_bytes error: "+err.Error())
-					return nil
+				// o inverso de readlines: cada linha ganha a quebra de linha
+				var data []byte
+				for _, el := range list.Elements {
+					line, ok := el.(string)
+					if !ok {
+						i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "writelines"}, "writelines() expects a list of strings")
+						return nil
+					}
+					data = append(append(data, line...), '\n')
 				}
+				f.write(i, "writelines", data)
 				return nil
 			},
 		}
@@ -132,30 +217,87 @@ func (f *FileObject) GetMethod(name string) any {
 		return &BuiltinFunction{
 			ArityValue: 0,
 			CallFunc: func(i *Interpreter, args []any) any {
-				pos, err := f.File.Seek(0, io.SeekCurrent)
+				pos, err := f.tell()
 				if err != nil {
 					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "tell"}, "tell error: "+err.Error())
 					return nil
 				}
-				return pos
+				return float64(pos)
 			},
 		}
 	case "seek":
 		return &BuiltinFunction{
-			ArityValue: 2,
+			ArityValue: -1,
 			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) < 1 || len(args) > 2 {
+					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "seek"}, "seek(offset, whence?) expects 1 or 2 arguments")
+					return nil
+				}
 				offset, ok1 := args[0].(float64)
-				whence, ok2 := args[1].(float64)
+				whence, ok2 := float64(io.SeekStart), true
+				if len(args) == 2 {
+					whence, ok2 = args[1].(float64)
+				}
 				if !ok1 || !ok2 {
-					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "seek"}, "seek(offset, whence) expects numbers")
+					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "seek"}, "seek(offset, whence?) expects numbers")
 					return nil
 				}
+				if whence == io.SeekCurrent {
+					// a posição atual do script é a lógica, não a do arquivo
+					pos, err := f.tell()
+					if err != nil {
+						i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "seek"}, "seek error: "+err.Error())
+						return nil
+					}
+					offset, whence = float64(pos)+offset, io.SeekStart
+				}
+				if f.Reader != nil {
+					f.Reader.Reset(f.File)
+				}
 				pos, err := f.File.Seek(int64(offset), int(whence))
 				if err != nil {
 					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "seek"}, "seek error: "+err.Error())
 					return nil
 				}
-				return pos
+				return float64(pos)
+			},
+		}
+	case "truncate":
+		return &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if err := f.unread(); err != nil {
+					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "truncate"}, "truncate error: "+err.Error())
+					return nil
+				}
+				size, err := f.tell()
+				if len(args) > 0 {
+					n, ok := args[0].(float64)
+					if len(args) > 1 || !ok || n < 0 {
+						i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "truncate"}, "truncate(size?) expects a non-negative number")
+						return nil
+					}
+					size = int64(n)
+				}
+				if err == nil {
+					err = f.File.Truncate(size)
+				}
+				if err != nil {
+					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "truncate"}, "truncate error: "+err.Error())
+				}
+				return nil
+			},
+		}
+	case "stat":
+		return &BuiltinFunction{
+			ArityValue: 0,
+			CallFunc: func(i *Interpreter, args []any) any {
+				info, err := f.File.Stat()
+				if err != nil {
+					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "stat"}, "stat error: "+err.Error())
+					return nil
+				}
+				return fileInfoDict(info)
 			},
 		}
 	case "exists":
@@ -176,6 +318,10 @@ func (f *FileObject) GetMethod(name string) any {
 		return &BuiltinFunction{
 			ArityValue: 0,
 			CallFunc: func(i *Interpreter, args []any) any {
+				if f.closed {
+					return nil // fechar de novo, ex: dentro de "with", não é erro
+				}
+				f.closed = true
 				err := f.File.Close()
 				if err != nil {
 					i.Runtime.ReportRuntimeError(&token.Token{Lexeme: "close"}, "close error: "+err.Error())
@@ -187,3 +333,28 @@ func (f *FileObject) GetMethod(name string) any {
 		return nil
 	}
 }
+
+// write grava data na posição lógica do arquivo.
+func (f *FileObject) write(i *Interpreter, method string, data []byte) {
+	err := f.unread()
+	if err == nil {
+		_, err = f.File.Write(data)
+	}
+	if err != nil {
+		i.Runtime.ReportRuntimeError(&token.Token{Lexeme: method}, method+" error: "+err.Error())
+	}
+}
+
+// fileInfoDict descreve um arquivo para os.info e file.stat().
+func fileInfoDict(info os.FileInfo) *DictInstance {
+	return NewDictInstance(map[string]any{
+		"name":        info.Name(),
+		"size":        float64(info.Size()),
+		"mode":        float64(info.Mode()),
+		"mod_time":    NewTimeInstance(info.ModTime()),
+		"is_dir":      info.IsDir(),
+		"is_file":     !info.IsDir(),
+		"is_symlink":  info.Mode()&os.ModeSymlink != 0,
+		"permissions": fmt.Sprintf("%04o", info.Mode().Perm()),
+	})
+}
@@ -0,0 +1,383 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fileTest é um script que trabalha sobre arquivos de uma pasta temporária.
+type fileTest struct {
+	name   string
+	files  map[string]string // conteúdo inicial, por nome
+	source string            // "DIR/" vira a pasta temporária
+	want   string            // saída esperada
+	after  map[string]string // conteúdo final esperado, por nome
+	err    string            // trecho do erro de execução; vazio quando o script termina
+}
+
+func runFileTests(t *testing.T, tests []fileTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			source := strings.ReplaceAll(tt.source, "DIR/", filepath.ToSlash(dir)+"/")
+			out, err := runSource(source)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Fatalf("script failed: %v", err)
+			} else if out != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+			for name, want := range tt.after {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != want {
+					t.Errorf("%s = %q, want %q", name, data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileReadAndPosition(t *testing.T) {
+	runFileTests(t, []fileTest{
+		{
+			name:  "mixed reads share the buffer",
+			files: map[string]string{"data.txt": "one\ntwo\nthree\nfour\n"},
+			source: `let f = open("DIR/data.txt", "r");
+print f.readline();
+print f.read(2);
+print f.tell();
+for line in f {
+    print "> " + line;
+}
+print f.readline();
+f.close();`,
+			want: "one\ntw\n6\n> o\n> three\n> four\n<nil>",
+		},
+		{
+			name:  "readlines after read",
+			files: map[string]string{"data.txt": "ab\ncd\n"},
+			source: `let f = open("DIR/data.txt", "r");
+print f.read(1);
+print len(f.readlines());
+print f.read() == "";
+f.close();`,
+			want: "a\n2\ntrue",
+		},
+		{
+			name:  "seek",
+			files: map[string]string{"data.txt": "abcdefgh"},
+			source: `let f = open("DIR/data.txt", "r");
+print f.read(2);
+print f.seek(0);
+print f.read(4);
+print f.seek(-2, 2);
+print f.read();
+f.seek(1);
+print f.seek(2, 1);
+print f.read(1);
+f.close();`,
+			want: "ab\n0\nabcd\n6\ngh\n3\nd",
+		},
+		{
+			name:  "seek from the current position counts only what was read",
+			files: map[string]string{"data.txt": "abcdefgh"},
+			source: `let f = open("DIR/data.txt", "r");
+f.read(1);
+print f.seek(1, 1);
+print f.read(1);
+print f.tell();
+f.close();`,
+			want: "2\nc\n3",
+		},
+		{
+			name:  "seek with invalid arguments",
+			files: map[string]string{"data.txt": ""},
+			source: `let f = open("DIR/data.txt", "r");
+f.seek("a");`,
+			err: "seek(offset, whence?) expects numbers",
+		},
+		{
+			name:  "write after a buffered read",
+			files: map[string]string{"data.txt": "abcdefgh"},
+			source: `let f = open("DIR/data.txt", "r+");
+print f.read(3);
+f.write("XY");
+print f.tell();
+print f.read();
+f.close();`,
+			want:  "abc\n5\nfgh",
+			after: map[string]string{"data.txt": "abcXYfgh"},
+		},
+		{
+			name:  "truncate at the logical position",
+			files: map[string]string{"data.txt": "abcdefgh"},
+			source: `let f = open("DIR/data.txt", "r+");
+f.read(3);
+f.truncate();
+print f.stat()["size"];
+f.write("Z");
+f.close();`,
+			want:  "3",
+			after: map[string]string{"data.txt": "abcZ"},
+		},
+		{
+			name:  "truncate to a size",
+			files: map[string]string{"data.txt": "abcdefgh"},
+			source: `let f = open("DIR/data.txt", "r+");
+f.truncate(2);
+print f.read();
+f.close();`,
+			want:  "ab",
+			after: map[string]string{"data.txt": "ab"},
+		},
+		{
+			name:  "truncate to a negative size",
+			files: map[string]string{"data.txt": "abc"},
+			source: `let f = open("DIR/data.txt", "r+");
+f.truncate(-1);`,
+			err:   "truncate(size?) expects a non-negative number",
+			after: map[string]string{"data.txt": "abc"},
+		},
+		{
+			name:  "read with a negative size",
+			files: map[string]string{"data.txt": "abc"},
+			source: `let f = open("DIR/data.txt", "r");
+f.read(-1);`,
+			err: "read(size?) expects a non-negative number",
+		},
+		{
+			name: "writelines round-trips readlines",
+			source: `let f = open("DIR/out.txt", "w+");
+f.writelines(["a", "b"]);
+f.seek(0);
+let lines = f.readlines();
+print len(lines);
+print lines[0] + lines[1];
+f.close();`,
+			want:  "2\nab",
+			after: map[string]string{"out.txt": "a\nb\n"},
+		},
+	})
+}
+
+func TestBytes(t *testing.T) {
+	runFileTests(t, []fileTest{
+		{
+			name:  "indexing",
+			files: map[string]string{"data.bin": "\x00\x01\xff"},
+			source: `let f = open("DIR/data.bin", "r");
+let b = f.read_bytes();
+f.close();
+print type.of(b);
+print len(b);
+print b[0];
+print b[2];`,
+			want: "bytes\n3\n0\n255",
+		},
+		{
+			name:   "index out of range",
+			source: "let b = bytes([1, 2]);\nprint b[2];",
+			err:    "Bytes index out of range: 2",
+		},
+		{
+			name:   "negative index",
+			source: "let b = bytes([1, 2]);\nprint b[-1];",
+			err:    "Bytes index out of range: -1",
+		},
+		{
+			name:   "index that is not a number",
+			source: "let b = bytes([1, 2]);\nprint b[\"0\"];",
+			err:    "Bytes index must be a number.",
+		},
+		{
+			name: "slice",
+			source: `let b = bytes("hello");
+print b.slice(1).decode();
+print b.slice(1, 3).decode();
+print len(b.slice(5));
+print b.slice(0, 2) == bytes("he");`,
+			want: "ello\nel\n0\ntrue",
+		},
+		{
+			name:   "slice out of bounds",
+			source: "bytes(\"abc\").slice(1, 4);",
+			err:    "bytes.slice: range 1..4 out of bounds for 3 bytes.",
+		},
+		{
+			name:   "slice with the range reversed",
+			source: "bytes(\"abc\").slice(2, 1);",
+			err:    "bytes.slice: range 2..1 out of bounds for 3 bytes.",
+		},
+		{
+			name:   "slice without arguments",
+			source: "bytes(\"abc\").slice();",
+			err:    "bytes.slice(start, end?) expects 1 or 2 arguments.",
+		},
+		{
+			name:  "read_bytes with a size",
+			files: map[string]string{"data.bin": "abcdef"},
+			source: `let f = open("DIR/data.bin", "r");
+print f.read_bytes(2).hex();
+print f.read(2);
+print f.read_bytes().decode();
+f.close();`,
+			want: "6162\ncd\nef",
+		},
+	})
+}
+
+func TestWriteBytes(t *testing.T) {
+	runFileTests(t, []fileTest{
+		{
+			name: "accepted values",
+			source: `let f = open("DIR/out.bin", "w");
+f.write_bytes([104, 105]);
+f.write_bytes(" there");
+f.write_bytes(bytes([33, 10]));
+f.close();`,
+			after: map[string]string{"out.bin": "hi there!\n"},
+		},
+		{
+			name: "number above 255",
+			source: `let f = open("DIR/out.bin", "w");
+f.write_bytes([1, 256]);`,
+			err:   "write_bytes() item 1 is not a number from 0 to 255",
+			after: map[string]string{"out.bin": ""},
+		},
+		{
+			name: "negative number",
+			source: `let f = open("DIR/out.bin", "w");
+f.write_bytes([-1]);`,
+			err: "write_bytes() item 0 is not a number from 0 to 255",
+		},
+		{
+			name: "fraction",
+			source: `let f = open("DIR/out.bin", "w");
+f.write_bytes([1.5]);`,
+			err: "write_bytes() item 0 is not a number from 0 to 255",
+		},
+		{
+			name: "string in the list",
+			source: `let f = open("DIR/out.bin", "w");
+f.write_bytes(["a"]);`,
+			err: "write_bytes() item 0 is not a number from 0 to 255",
+		},
+		{
+			name: "other types",
+			source: `let f = open("DIR/out.bin", "w");
+f.write_bytes(1);`,
+			err: "write_bytes() expected bytes, a string or a list of numbers, got number",
+		},
+	})
+}
+
+func TestWithClose(t *testing.T) {
+	const resource = `class Resource {
+    init(name) {
+        self.name = name;
+    }
+    close() {
+        print "close " + self.name;
+    }
+}
+`
+	runFileTests(t, []fileTest{
+		{
+			name: "class instance",
+			source: resource + `with Resource("a") as r {
+    print "body " + r.name;
+}
+print "after";`,
+			want: "body a\nclose a\nafter",
+		},
+		{
+			name: "error in the body",
+			source: resource + `func use() {
+    with Resource("b") as r {
+        let x = nil;
+        return x.y;
+    }
+}
+print ?use();
+print "after";`,
+			want: "close b\n<nil>\nafter",
+		},
+		{
+			name: "return, break and continue",
+			source: resource + `func first() {
+    with Resource("c") as r {
+        return 1;
+    }
+}
+print first();
+for i in range(2) {
+    with Resource(fmt("{}", i)) as r {
+        if i == 0 {
+            continue;
+        }
+        break;
+    }
+}`,
+			want: "close c\n1\nclose 0\nclose 1",
+		},
+		{
+			name: "error in close does not hide the body error",
+			source: `class Broken {
+    close() {
+        let missing = nil;
+        return missing.close_failed;
+    }
+}
+with Broken() as b {
+    let body = nil;
+    print body.body_failed;
+}`,
+			err: "'body_failed'",
+		},
+		{
+			name: "error in close after a normal end",
+			source: `class Broken {
+    close() {
+        let missing = nil;
+        return missing.close_failed;
+    }
+}
+with Broken() as b {
+    print "body";
+}`,
+			err: "'close_failed'",
+		},
+		{
+			name: "file",
+			source: `let f = open("DIR/out.txt", "w");
+with f as g {
+    g.write("x");
+}
+print ?f.write("y");
+f.close();`,
+			want:  "<nil>",
+			after: map[string]string{"out.txt": "x"},
+		},
+		{
+			name: "value without close",
+			source: `with [1, 2] as l {
+    print len(l);
+}`,
+			want: "2",
+		},
+	})
+}
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"encoding/json"
+	"time"
 )
 
 func NewJsonModule() *MapInstance {
@@ -54,6 +55,10 @@ func toGoValue(v any) any {
 			m[k] = toGoValue(v)
 		}
 		return m
+	case *TimeInstance:
+		return val.Time.Format(time.RFC3339Nano)
+	case *DurationInstance:
+		return val.Duration.Seconds()
 	default:
 		return val
 	}
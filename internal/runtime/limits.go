@@ -0,0 +1,156 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	goruntime "runtime"
+	"runtime/metrics"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+)
+
+// DefaultMaxDepth é a profundidade máxima de chamadas quando Limits.MaxDepth
+// é zero. Cada chamada Nox ocupa várias chamadas Go, e estourar a pilha do
+// Go derruba o processo sem chance de tratamento.
+const DefaultMaxDepth = 10000
+
+// Limits limita a execução de scripts, por exemplo os enviados por usuários.
+// Valores zero desativam o limite (MaxDepth usa DefaultMaxDepth). Junto com
+// Nox.Context, que cancela a execução, cada limite gera um erro com Kind
+// próprio, que "?expr" não captura.
+//
+// MaxMemory não é contado por interpretador: é o crescimento do heap de todo
+// o processo desde a criação do interpretador. Com vários scripts rodando ao
+// mesmo tempo no mesmo processo, o que um aloca conta para o limite de
+// todos; para isolar a memória de cada script, rode-os em processos
+// separados (como "nox run --max-memory").
+type Limits struct {
+	MaxSteps  int64 // instruções executadas
+	MaxDepth  int   // chamadas de função aninhadas
+	MaxMemory int64 // bytes a mais no heap do processo (não do script), aproximado
+}
+
+// limitCheckInterval é a cada quantas instruções o cancelamento e a memória
+// são verificados, e um handler de http.serve cede a vez aos outros: tudo
+// isso custa mais que um contador.
+const limitCheckInterval = 1024
+
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// tick conta a instrução stmt e verifica os limites da execução. O erro
+// aponta para a linha de stmt, que é onde o script foi interrompido.
+func (i *Interpreter) tick(stmt ast.Stmt) {
+	i.steps++
+	limits := &i.Runtime.Limits
+	var err *RuntimeError
+	if limits.MaxSteps > 0 && i.steps > limits.MaxSteps {
+		err = &RuntimeError{
+			Message: fmt.Sprintf("Step limit exceeded: more than %d instructions executed.", limits.MaxSteps),
+			Kind:    ErrorStepLimit,
+		}
+	} else if i.steps%limitCheckInterval == 0 {
+		if i.handlerLock != nil {
+			i.yield() // handler de http.serve: cede a vez aos outros
+		}
+		err = i.checkContext()
+		if err == nil && limits.MaxMemory > 0 {
+			err = i.checkMemory(limits.MaxMemory)
+		}
+	}
+	if err != nil {
+		i.markLine(limitLine(stmt))
+		panic(err)
+	}
+}
+
+// limitLine devolve a linha de stmt ou, para blocos e laços sem posição
+// própria, a do primeiro comando dentro deles. Um bloco vazio fica na linha
+// da sua chave e um laço sem comandos ("for { }"), na do 'for'.
+func limitLine(stmt ast.Stmt) int {
+	if line := stmtLine(stmt); line > 0 {
+		return line
+	}
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		if len(s.Statements) > 0 {
+			if line := limitLine(s.Statements[0]); line > 0 {
+				return line
+			}
+		}
+		if s.Brace != nil {
+			return s.Brace.Line
+		}
+	case *ast.ForInStmt:
+		if line := limitLine(s.Body); line > 0 {
+			return line
+		}
+		if s.Keyword != nil {
+			return s.Keyword.Line
+		}
+	}
+	return 0
+}
+
+// checkContext devolve o erro de cancelamento de Nox.Context, se houver.
+// Builtins que bloqueiam (time.sleep) também o usam.
+func (i *Interpreter) checkContext() *RuntimeError {
+	ctx := i.Runtime.Context
+	if ctx == nil || ctx.Err() == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &RuntimeError{Message: "Execution timed out.", Kind: ErrorTimeout}
+	}
+	return &RuntimeError{Message: "Execution canceled.", Kind: ErrorCanceled}
+}
+
+// context devolve Nox.Context, ou um contexto que nunca é cancelado.
+func (i *Interpreter) context() context.Context {
+	if i.Runtime.Context != nil {
+		return i.Runtime.Context
+	}
+	return context.Background()
+}
+
+// checkDepth impede que a recursão passe de Limits.MaxDepth chamadas.
+func (i *Interpreter) checkDepth() {
+	limit := i.Runtime.Limits.MaxDepth
+	if limit <= 0 {
+		limit = DefaultMaxDepth
+	}
+	if len(i.frames) > limit {
+		panic(&RuntimeError{
+			Message: fmt.Sprintf("Stack overflow: more than %d nested calls.", limit),
+			Kind:    ErrorStackOverflow,
+		})
+	}
+}
+
+// heapBytes lê o tamanho dos objetos no heap, sem parar o programa como
+// runtime.ReadMemStats.
+func heapBytes() int64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return int64(sample[0].Value.Uint64())
+}
+
+// checkMemory compara o crescimento do heap com o limite. O heap é o do
+// processo inteiro, com o que outras goroutines alocaram, e inclui lixo
+// ainda não coletado, então antes de falhar uma coleta é forçada.
+func (i *Interpreter) checkMemory(limit int64) *RuntimeError {
+	if heapBytes()-i.heapBase <= limit {
+		return nil
+	}
+	goruntime.GC()
+	if used := heapBytes() - i.heapBase; used > limit {
+		return &RuntimeError{
+			Message: fmt.Sprintf("Memory limit exceeded: about %d bytes allocated, limit is %d.", used, limit),
+			Kind:    ErrorMemoryLimit,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// limitTest é um limite e um script que passa dele.
+type limitTest struct {
+	name    string
+	setup   func(n *Nox) (cleanup func())
+	loop    string // corpo da função run, que estoura o limite
+	kind    ErrorKind
+	message string
+	line    int // linha de limits.nox onde o script para
+}
+
+func withContext(ctx context.Context, cancel context.CancelFunc) func(*Nox) func() {
+	return func(n *Nox) func() {
+		n.Context = ctx
+		return cancel
+	}
+}
+
+func limitTests() []limitTest {
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	return []limitTest{
+		{
+			name:    "steps",
+			setup:   func(n *Nox) func() { n.Limits.MaxSteps = 200; return func() {} },
+			loop:    "let n = 0;\n    for {\n        n = n + 1;\n    }",
+			kind:    ErrorStepLimit,
+			message: "Step limit exceeded: more than 200 instructions executed.",
+			line:    10,
+		},
+		{
+			name:    "timeout",
+			setup:   withContext(timeout, cancelTimeout),
+			loop:    "let n = 0;\n    for {\n    }",
+			kind:    ErrorTimeout,
+			message: "Execution timed out.",
+			line:    9, // o 'for' de um laço vazio
+		},
+		{
+			name:    "canceled",
+			setup:   withContext(canceled, cancel),
+			loop:    "let n = 0;\n    for {\n        n = n + 1;\n    }",
+			kind:    ErrorCanceled,
+			message: "Execution canceled.",
+			line:    10,
+		},
+		{
+			name:    "depth",
+			setup:   func(n *Nox) func() { n.Limits.MaxDepth = 50; return func() {} },
+			loop:    "return deep(0);",
+			kind:    ErrorStackOverflow,
+			message: "Stack overflow: more than 50 nested calls.",
+			line:    3, // a declaração de deep, onde a última chamada começou
+		},
+		{
+			name:    "memory",
+			setup:   func(n *Nox) func() { n.Limits.MaxMemory = 4 << 20; return func() {} },
+			loop:    "let l = [];\n    for {\n        l.append([1, 2, 3, 4, 5, 6, 7, 8]);\n    }",
+			kind:    ErrorMemoryLimit,
+			message: "Memory limit exceeded:",
+			line:    10,
+		},
+	}
+}
+
+// runLimited executa o script de tt, com run chamada por call, e devolve o
+// erro e o que foi impresso.
+func runLimited(t *testing.T, tt limitTest, call string) (*RuntimeError, string) {
+	t.Helper()
+	n := NewNox()
+	var out strings.Builder
+	n.Stdout, n.Stderr = &out, io.Discard
+	defer tt.setup(n)()
+
+	source := `import "test" as test;
+
+func deep(n) {
+    return deep(n + 1);
+}
+
+func run() {
+    ` + tt.loop + `
+}
+` + call + `
+print "after";
+`
+	interpreter := NewInterpreter(n, false)
+	interpreter.resetStack("<script>", "limits.nox")
+	var err *RuntimeError
+	if !errors.As(n.Run(source, interpreter), &err) {
+		t.Fatalf("script did not fail; output %q", out.String())
+	}
+	return err, out.String()
+}
+
+func TestLimits(t *testing.T) {
+	for _, tt := range limitTests() {
+		t.Run(tt.name, func(t *testing.T) {
+			err, _ := runLimited(t, tt, "run();")
+			if err.Kind != tt.kind || !strings.HasPrefix(err.Message, tt.message) {
+				t.Fatalf("error = %v (kind %d), want %q", err, err.Kind, tt.message)
+			}
+			top := err.Stack[len(err.Stack)-1]
+			if top.File != "limits.nox" || top.Line != tt.line {
+				t.Errorf("stopped at %s:%d, want limits.nox:%d\n%s", top.File, top.Line, tt.line, err.Traceback())
+			}
+			for _, frame := range err.Stack {
+				if frame.Line == 0 {
+					t.Errorf("frame without a line:\n%s", err.Traceback())
+					break
+				}
+			}
+		})
+	}
+}
+
+// Limites e cancelamento não são erros do script: "?expr" e test.raises
+// não os capturam.
+func TestLimitsEscapeHandlers(t *testing.T) {
+	for _, call := range []string{"print ?run();", "test.raises(run);"} {
+		for _, tt := range limitTests() {
+			t.Run(tt.name+" "+call, func(t *testing.T) {
+				err, out := runLimited(t, tt, call)
+				if err.Kind != tt.kind {
+					t.Errorf("error = %v (kind %d), want kind %d", err, err.Kind, tt.kind)
+				}
+				if out != "" {
+					t.Errorf("script continued after the limit: %q", out)
+				}
+			})
+		}
+	}
+}
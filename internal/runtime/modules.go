@@ -0,0 +1,201 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MichelLacerda/nox/internal/manifest"
+)
+
+const (
+	// ModuleExt é a extensão dos arquivos de módulo, opcional nos imports.
+	ModuleExt = ".nox"
+	// PackageIndex é o arquivo carregado quando um import aponta para uma pasta.
+	PackageIndex = "index.nox"
+)
+
+// ProjectMarkers são os arquivos que marcam a raiz de um projeto.
+var ProjectMarkers = manifest.Files
+
+// ModuleNotFoundError é devolvido por FindModule com os caminhos tentados.
+type ModuleNotFoundError struct {
+	Path     string
+	Searched []string
+	Hint     string // sugestão exibida ao final, ex: rodar "nox mod"
+}
+
+func (e *ModuleNotFoundError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Module '%s' not found. Searched:", e.Path)
+	for _, path := range e.Searched {
+		sb.WriteString("\n  " + path)
+	}
+	if e.Hint != "" {
+		sb.WriteString("\n" + e.Hint)
+	}
+	return sb.String()
+}
+
+// IsRelativeImport diz se o import é relativo ao arquivo que importa
+// ("./util", "../shared/log").
+func IsRelativeImport(path string) bool {
+	return path == "." || path == ".." ||
+		strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../")
+}
+
+// ProjectRoot devolve a pasta mais próxima, a partir de dir e subindo, que
+// contém um dos ProjectMarkers, ou "" quando não há nenhuma.
+func ProjectRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, marker := range ProjectMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// StdlibDir devolve a pasta da biblioteca padrão: $NOXSTDLIB, ou lib/nox
+// ao lado da pasta do executável (~/.local/bin/nox → ~/.local/lib/nox).
+func StdlibDir() string {
+	if dir := os.Getenv("NOXSTDLIB"); dir != "" {
+		return dir
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Join(filepath.Dir(filepath.Dir(exe)), "lib", "nox")
+}
+
+// importRoot devolve a raiz do projeto que contém dir ou, fora de um
+// projeto, a pasta do script principal.
+func importRoot(dir, scriptDir string) string {
+	if root := ProjectRoot(dir); root != "" {
+		return root
+	}
+	root, _ := filepath.Abs(scriptDir)
+	return root
+}
+
+// vendorDir devolve a pasta onde estão as dependências do projeto em root.
+// Dependências instaladas ficam todas em nox_modules do projeto principal,
+// inclusive as de outras dependências.
+func vendorDir(root string) string {
+	if filepath.Base(filepath.Dir(root)) == manifest.VendorDir {
+		return filepath.Dir(root)
+	}
+	return filepath.Join(root, manifest.VendorDir)
+}
+
+// ModuleSearchPath devolve, em ordem, as pastas onde os imports não
+// relativos de um arquivo da pasta dir são procurados: a raiz do projeto
+// que o contém (ou scriptDir, fora de um projeto), as dependências
+// instaladas em nox_modules, as pastas de $NOXPATH e a biblioteca padrão.
+func ModuleSearchPath(dir, scriptDir string) []string {
+	root := importRoot(dir, scriptDir)
+	dirs := []string{root, vendorDir(root)}
+	for _, entry := range filepath.SplitList(os.Getenv("NOXPATH")) {
+		if entry != "" {
+			dirs = append(dirs, entry)
+		}
+	}
+	if stdlib := StdlibDir(); stdlib != "" {
+		dirs = append(dirs, stdlib)
+	}
+	return dirs
+}
+
+// FindModule resolve o caminho de um import feito por um arquivo da pasta
+// fromDir, num programa cujo script principal está em scriptDir. Imports
+// relativos partem de fromDir. Se o primeiro segmento do caminho é uma
+// dependência declarada no manifesto do projeto, ela só é procurada em
+// nox_modules; os demais imports usam ModuleSearchPath. Em cada pasta vale
+// primeiro o arquivo ("util" → util.nox) e depois o pacote
+// ("util" → util/index.nox).
+func FindModule(importPath, fromDir, scriptDir string) (string, error) {
+	dirs := []string{fromDir}
+	hint := ""
+	if !IsRelativeImport(importPath) {
+		dirs = ModuleSearchPath(fromDir, scriptDir)
+		root := importRoot(fromDir, scriptDir)
+		if m, err := manifest.Find(root); err != nil {
+			return "", err
+		} else if m != nil {
+			name, _, _ := strings.Cut(importPath, "/")
+			if _, ok := m.Dependencies[name]; ok {
+				dirs = []string{vendorDir(root)}
+				if _, err := os.Stat(filepath.Join(dirs[0], name)); err != nil {
+					hint = fmt.Sprintf("'%s' is a dependency in %s but is not installed; run 'nox mod'.", name, filepath.Base(m.File))
+				}
+			}
+		}
+	}
+
+	var searched []string
+	for _, dir := range dirs {
+		base := filepath.Join(dir, filepath.FromSlash(importPath))
+		candidates := []string{base + ModuleExt, filepath.Join(base, PackageIndex)}
+		if strings.HasSuffix(importPath, ModuleExt) {
+			candidates = []string{base}
+		}
+		for _, candidate := range candidates {
+			abs, err := filepath.Abs(candidate)
+			if err != nil {
+				continue
+			}
+			if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+				return abs, nil
+			}
+			searched = append(searched, abs)
+		}
+	}
+	return "", &ModuleNotFoundError{Path: importPath, Searched: searched, Hint: hint}
+}
+
+// builtinModule devolve o módulo builtin chamado name. Esses nomes são
+// reservados: "import \"math\"" sempre se refere ao builtin, mesmo que o
+// script tenha declarado outro math, e um arquivo math.nox precisa ser
+// importado como "./math".
+func (i *Interpreter) builtinModule(name string) (*MapInstance, bool) {
+	if strings.ContainsAny(name, "/\\.") {
+		return nil, false
+	}
+	module, ok := i.builtins.Values[name].(*MapInstance)
+	return module, ok
+}
+
+// enterScript marca o script principal como em carregamento, como
+// loadModule faz com os módulos: um import de volta para ele (a -> b -> a)
+// é circular, em vez de executar o topo de a uma segunda vez. A função
+// devolvida desfaz a marcação.
+func (n *Nox) enterScript(path string) func() {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return func() {}
+	}
+	n.loading = append(n.loading, absPath)
+	return func() { n.loading = n.loading[:len(n.loading)-1] }
+}
+
+// importDir é a pasta do arquivo em execução, base dos imports relativos.
+func (i *Interpreter) importDir() string {
+	if i.currentDir != "" {
+		return i.currentDir
+	}
+	return i.Runtime.WorkingDir
+}
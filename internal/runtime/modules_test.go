@@ -0,0 +1,375 @@
+package runtime
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// moduleTree é um projeto com as pastas de busca de módulos: src tem o
+// script principal, e NOXPATH e NOXSTDLIB apontam para pastas próprias.
+type moduleTree struct {
+	project, src, vendor, noxpath, stdlib string
+}
+
+func newModuleTree(t *testing.T) moduleTree {
+	t.Helper()
+	root := t.TempDir()
+	tree := moduleTree{
+		project: filepath.Join(root, "project"),
+		src:     filepath.Join(root, "project", "src"),
+		vendor:  filepath.Join(root, "project", "nox_modules"),
+		noxpath: filepath.Join(root, "noxpath"),
+		stdlib:  filepath.Join(root, "stdlib"),
+	}
+	for _, dir := range []string{tree.src, tree.vendor, tree.noxpath, tree.stdlib} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeModule(t, filepath.Join(tree.project, "nox.toml"), "[package]\nname = \"app\"\n")
+	t.Setenv("NOXPATH", tree.noxpath)
+	t.Setenv("NOXSTDLIB", tree.stdlib)
+	return tree
+}
+
+func writeModule(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestModuleSearchPath(t *testing.T) {
+	tree := newModuleTree(t)
+	other := t.TempDir()
+	t.Setenv("NOXPATH", tree.noxpath+string(os.PathListSeparator)+string(os.PathListSeparator)+other)
+
+	want := []string{tree.project, tree.vendor, tree.noxpath, other, tree.stdlib}
+	if got := ModuleSearchPath(filepath.Join(tree.src, "deep"), tree.src); !slices.Equal(got, want) {
+		t.Errorf("inside a project = %v, want %v", got, want)
+	}
+
+	// fora de um projeto, a pasta do script principal ocupa o lugar da raiz
+	script := t.TempDir()
+	want = []string{script, filepath.Join(script, "nox_modules"), tree.noxpath, other, tree.stdlib}
+	if got := ModuleSearchPath(script, script); !slices.Equal(got, want) {
+		t.Errorf("outside a project = %v, want %v", got, want)
+	}
+
+	// uma dependência instalada usa o nox_modules do projeto principal
+	lib := filepath.Join(tree.vendor, "lib")
+	writeModule(t, filepath.Join(lib, "nox.toml"), "[package]\nname = \"lib\"\n")
+	want = []string{lib, tree.vendor, tree.noxpath, other, tree.stdlib}
+	if got := ModuleSearchPath(lib, tree.src); !slices.Equal(got, want) {
+		t.Errorf("inside a dependency = %v, want %v", got, want)
+	}
+}
+
+func TestFindModuleOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string // pastas com util.nox
+		want string
+	}{
+		{"project first", []string{"project", "vendor", "noxpath", "stdlib"}, "project"},
+		{"then nox_modules", []string{"vendor", "noxpath", "stdlib"}, "vendor"},
+		{"then NOXPATH", []string{"noxpath", "stdlib"}, "noxpath"},
+		{"then the stdlib", []string{"stdlib"}, "stdlib"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := newModuleTree(t)
+			dirs := map[string]string{"project": tree.project, "vendor": tree.vendor, "noxpath": tree.noxpath, "stdlib": tree.stdlib}
+			for _, name := range tt.in {
+				writeModule(t, filepath.Join(dirs[name], "util.nox"), "")
+			}
+			got, err := FindModule("util", tree.src, tree.src)
+			if want := filepath.Join(dirs[tt.want], "util.nox"); err != nil || got != want {
+				t.Errorf("FindModule = %s, %v; want %s", got, err, want)
+			}
+		})
+	}
+}
+
+func TestFindModuleFileBeforePackage(t *testing.T) {
+	tree := newModuleTree(t)
+	file := filepath.Join(tree.project, "util.nox")
+	index := filepath.Join(tree.project, "util", "index.nox")
+	writeModule(t, file, "")
+	writeModule(t, index, "")
+
+	if got, err := FindModule("util", tree.src, tree.src); err != nil || got != file {
+		t.Errorf("file and package = %s, %v; want %s", got, err, file)
+	}
+	os.Remove(file)
+	if got, err := FindModule("util", tree.src, tree.src); err != nil || got != index {
+		t.Errorf("package only = %s, %v; want %s", got, err, index)
+	}
+
+	// a ordem das pastas vem antes: o pacote do projeto ganha do arquivo em
+	// nox_modules
+	writeModule(t, filepath.Join(tree.vendor, "util.nox"), "")
+	if got, err := FindModule("util", tree.src, tree.src); err != nil || got != index {
+		t.Errorf("project package and vendored file = %s, %v; want %s", got, err, index)
+	}
+
+	// com a extensão, só o arquivo vale
+	if _, err := FindModule("util/index", tree.src, tree.src); err != nil {
+		t.Errorf("path to the index = %v", err)
+	}
+	if got, err := FindModule("util.nox", tree.src, tree.src); err != nil || got != filepath.Join(tree.vendor, "util.nox") {
+		t.Errorf("util.nox = %s, %v; want the vendored file", got, err)
+	}
+}
+
+func TestFindModuleRelative(t *testing.T) {
+	tree := newModuleTree(t)
+	nested := filepath.Join(tree.src, "app", "models")
+	writeModule(t, filepath.Join(nested, "user.nox"), "")
+	writeModule(t, filepath.Join(tree.src, "app", "db", "index.nox"), "")
+	writeModule(t, filepath.Join(tree.project, "shared.nox"), "")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"./user", filepath.Join(nested, "user.nox")},
+		{"./user.nox", filepath.Join(nested, "user.nox")},
+		{"../db", filepath.Join(tree.src, "app", "db", "index.nox")},
+		{"../../../shared", filepath.Join(tree.project, "shared.nox")},
+	}
+	for _, tt := range tests {
+		if got, err := FindModule(tt.path, nested, tree.src); err != nil || got != tt.want {
+			t.Errorf("FindModule(%s) = %s, %v; want %s", tt.path, got, err, tt.want)
+		}
+	}
+
+	// imports relativos não usam as pastas de busca
+	if _, err := FindModule("./shared", nested, tree.src); err == nil {
+		t.Error("./shared found outside the importing directory")
+	}
+}
+
+func TestFindModuleNotFound(t *testing.T) {
+	tree := newModuleTree(t)
+	_, err := FindModule("missing", tree.src, tree.src)
+	var notFound *ModuleNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("err = %v, want *ModuleNotFoundError", err)
+	}
+	var want []string
+	for _, dir := range []string{tree.project, tree.vendor, tree.noxpath, tree.stdlib} {
+		want = append(want, filepath.Join(dir, "missing.nox"), filepath.Join(dir, "missing", "index.nox"))
+	}
+	if !slices.Equal(notFound.Searched, want) {
+		t.Errorf("searched:\n%s\nwant:\n%s", strings.Join(notFound.Searched, "\n"), strings.Join(want, "\n"))
+	}
+	if message := err.Error(); message != "Module 'missing' not found. Searched:\n  "+strings.Join(want, "\n  ") {
+		t.Errorf("message = %q", message)
+	}
+
+	_, err = FindModule("./missing.nox", tree.src, tree.src)
+	if !errors.As(err, &notFound) || !slices.Equal(notFound.Searched, []string{filepath.Join(tree.src, "missing.nox")}) {
+		t.Errorf("relative import searched %v, want only the importing directory", err)
+	}
+
+	// uma dependência declarada só é procurada em nox_modules
+	writeModule(t, filepath.Join(tree.project, "nox.toml"), "[dependencies]\nlib = { path = \"../lib\" }\n")
+	writeModule(t, filepath.Join(tree.project, "lib.nox"), "")
+	_, err = FindModule("lib", tree.src, tree.src)
+	if !errors.As(err, &notFound) {
+		t.Fatalf("err = %v, want *ModuleNotFoundError", err)
+	}
+	want = []string{filepath.Join(tree.vendor, "lib.nox"), filepath.Join(tree.vendor, "lib", "index.nox")}
+	if !slices.Equal(notFound.Searched, want) || !strings.Contains(notFound.Hint, "run 'nox mod'") {
+		t.Errorf("dependency searched %v with hint %q", notFound.Searched, notFound.Hint)
+	}
+}
+
+// runFile executa o script em path e devolve o que ele imprimiu, sem a
+// quebra de linha final, e o erro de RunFile.
+func runFile(t *testing.T, path string) (string, error) {
+	t.Helper()
+	n := NewNox()
+	var out strings.Builder
+	n.Stdout, n.Stderr = &out, io.Discard
+	err := n.RunFile(path)
+	return strings.TrimSuffix(out.String(), "\n"), err
+}
+
+// Um import relativo parte da pasta do módulo que importa, não do script
+// principal.
+func TestNestedRelativeImports(t *testing.T) {
+	tree := newModuleTree(t)
+	writeModule(t, filepath.Join(tree.src, "main.nox"), `import "./app/models/user" as user;
+print user.describe();`)
+	writeModule(t, filepath.Join(tree.src, "name.nox"), `export let value = "src";`)
+	writeModule(t, filepath.Join(tree.src, "app", "models", "user.nox"), `import "./name" as name;
+import "../db" as db;
+export func describe() {
+    return name.value + " " + db.kind;
+}`)
+	writeModule(t, filepath.Join(tree.src, "app", "models", "name.nox"), `export let value = "models";`)
+	writeModule(t, filepath.Join(tree.src, "app", "db", "index.nox"), `import "../../name" as name;
+export let kind = "db from " + name.value;`)
+
+	out, err := runFile(t, filepath.Join(tree.src, "main.nox"))
+	if err != nil || out != "models db from src" {
+		t.Errorf("output = %q, %v; want %q", out, err, "models db from src")
+	}
+}
+
+// O código de topo de um módulo roda uma só vez, mesmo importado por vários
+// arquivos, e todos recebem o mesmo módulo.
+func TestModuleRunsOnce(t *testing.T) {
+	tree := newModuleTree(t)
+	writeModule(t, filepath.Join(tree.src, "main.nox"), `import "./counter" as counter;
+import "./other" as other;
+import "./counter" as again;
+counter.bump();
+print other.read();
+print again.count;`)
+	writeModule(t, filepath.Join(tree.src, "counter.nox"), `print "loading counter";
+export let count = 0;
+export func bump() {
+    count = count + 1;
+}`)
+	writeModule(t, filepath.Join(tree.src, "other.nox"), `import "./counter" as counter;
+print "loading other";
+export func read() {
+    return counter.count;
+}`)
+
+	out, err := runFile(t, filepath.Join(tree.src, "main.nox"))
+	if want := "loading counter\nloading other\n1\n1"; err != nil || out != want {
+		t.Errorf("output = %q, %v; want %q", out, err, want)
+	}
+}
+
+// Só os nomes declarados com "export" ficam visíveis para quem importa.
+func TestModuleHidesUnexported(t *testing.T) {
+	tree := newModuleTree(t)
+	writeModule(t, filepath.Join(tree.src, "lib.nox"), `let secret = 1;
+func helper() {
+    return secret;
+}
+export let public = helper() + 1;`)
+
+	tests := []struct {
+		name   string
+		source string
+		want   string // saída esperada
+		err    string // trecho do erro de execução; vazio quando o script termina
+	}{
+		{"exported through the alias", `import "./lib" as lib;
+print lib.public;`, "2", ""},
+		{"variable through the alias", `import "./lib" as lib;
+print lib.secret;`, "", "Undefined property 'secret' in module."},
+		{"function through the alias", `import "./lib" as lib;
+lib.helper();`, "", "Undefined property 'helper' in module."},
+		{"import without alias", `import "./lib";
+print public;
+print secret;`, "", "Undefined variable: secret"},
+		{"import by name", `import { secret } from "./lib";`, "", "Module './lib' has no export 'secret'."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tree.src, "main.nox")
+			writeModule(t, path, tt.source)
+			out, err := runFile(t, path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || out != tt.want {
+				t.Errorf("output = %q, %v; want %q", out, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircularImport(t *testing.T) {
+	tree := newModuleTree(t)
+	writeModule(t, filepath.Join(tree.src, "main.nox"), `import "./a" as a;`)
+	writeModule(t, filepath.Join(tree.src, "a.nox"), `import "./b" as b;
+export let name = "a";`)
+	writeModule(t, filepath.Join(tree.src, "b.nox"), `import "./a" as a;
+export let name = "b";`)
+
+	_, err := runFile(t, filepath.Join(tree.src, "main.nox"))
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("err = %v, want a runtime error", err)
+	}
+	if want := "Circular import: a.nox -> b.nox -> a.nox."; runtimeErr.Message != want {
+		t.Errorf("message = %q, want %q", runtimeErr.Message, want)
+	}
+
+	// um import que falhou não deixa o módulo marcado como carregando
+	writeModule(t, filepath.Join(tree.src, "main.nox"), `print ?import_a();
+func import_a() {
+    import "./b" as b;
+}
+import "./b" as b;`)
+	if _, err := runFile(t, filepath.Join(tree.src, "main.nox")); err == nil || !strings.Contains(err.Error(), "Circular import: b.nox -> a.nox -> b.nox.") {
+		t.Errorf("second import = %v, want the same cycle", err)
+	}
+}
+
+func TestImportNames(t *testing.T) {
+	tree := newModuleTree(t)
+	writeModule(t, filepath.Join(tree.src, "shapes.nox"), `export let sides = 4;
+export func area(w, h) {
+    return w * h;
+}
+export class Square {
+    init(side) {
+        self.side = side;
+    }
+}
+let hidden = 0;`)
+
+	tests := []struct {
+		name   string
+		source string
+		want   string // saída esperada
+		err    string // trecho do erro de execução; vazio quando o script termina
+	}{
+		{"names and alias", `import { sides, area as rect, Square } from "./shapes";
+print sides;
+print rect(2, 3);
+print Square(5).side;`, "4\n6\n5", ""},
+		{"alias hides the original name", `import { area as rect } from "./shapes";
+print area(1, 1);`, "", "Undefined variable: area"},
+		{"builtin module", `import { sqrt } from "math";
+print sqrt(9);`, "3", ""},
+		{"missing export", `import { sides, perimeter } from "./shapes";`, "", "Module './shapes' has no export 'perimeter'."},
+		{"missing export in a builtin module", `import { nope } from "math";`, "", "Module 'math' has no export 'nope'."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tree.src, "main.nox")
+			writeModule(t, path, tt.source)
+			out, err := runFile(t, path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || out != tt.want {
+				t.Errorf("output = %q, %v; want %q", out, err, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,16 @@ package runtime
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
-	"github.com/MichelLacerda/nox/internal/parser"
-	"github.com/MichelLacerda/nox/internal/scanner"
+	"github.com/MichelLacerda/nox/internal/diagnostic"
 	"github.com/MichelLacerda/nox/internal/signal"
 	"github.com/MichelLacerda/nox/internal/token"
 )
@@ -18,8 +20,24 @@ type Nox struct {
 	HadError        bool
 	HadRuntimeError bool
 	Interpreter     *Interpreter
-	WorkingDir      string         // pasta onde o script foi carregado ou "." no REPL
-	Modules         map[string]any // cache de módulos importados
+	WorkingDir      string          // pasta onde o script foi carregado ou "." no REPL
+	Modules         map[string]any  // cache de módulos importados
+	Args            []string        // script seguido dos argumentos da linha de comando (os.args)
+	Debugger        *Debugger       // quando definido, ExecFile executa sob o depurador
+	Profiler        *Profiler       // quando definido, ExecFile mede o tempo de execução
+	Coverage        *Coverage       // quando definido, ExecFile registra a cobertura
+	Sandbox         *Sandbox        // quando definido, restringe arquivos, processos, rede e ambiente
+	Context         context.Context // quando definido, cancelá-lo interrompe a execução
+	Limits          Limits          // limites de instruções, recursão e memória
+	Stdout          io.Writer       // saída de print e do REPL; nil usa os.Stdout
+	Stderr          io.Writer       // erros, tracebacks e avisos; nil usa os.Stderr
+	Stdin           io.Reader       // entrada do REPL; nil usa os.Stdin
+
+	loading  []string       // módulos sendo carregados, para detectar imports circulares
+	input    *bufio.Reader  // leitor de Stdin, compartilhado para não perder o que já foi lido
+	inputMu  sync.Mutex     // uma leitura de Stdin por vez; ver Interpreter.readStdin
+	handlers sync.Mutex     // um handler de http.serve executa por vez; ver Interpreter.handler
+	mux      *http.ServeMux // rotas de http.route e http.websocket desta execução
 }
 
 func NewNox() *Nox {
@@ -29,80 +47,127 @@ func NewNox() *Nox {
 		Interpreter:     nil,
 		WorkingDir:      ".",
 		Modules:         map[string]any{},
+		Stdout:          os.Stdout,
+		Stderr:          os.Stderr,
+		Stdin:           os.Stdin,
+		mux:             http.NewServeMux(),
 	}
 	return r
 }
 
+func (n *Nox) stdout() io.Writer {
+	if n.Stdout != nil {
+		return n.Stdout
+	}
+	return os.Stdout
+}
+
+func (n *Nox) stderr() io.Writer {
+	if n.Stderr != nil {
+		return n.Stderr
+	}
+	return os.Stderr
+}
+
+// stdin devolve o leitor de Nox.Stdin, criado na primeira leitura.
+func (n *Nox) stdin() *bufio.Reader {
+	if n.input == nil {
+		var in io.Reader = os.Stdin
+		if n.Stdin != nil {
+			in = n.Stdin
+		}
+		n.input = bufio.NewReader(in)
+	}
+	return n.input
+}
+
 func (n *Nox) ReportRuntimeError(t *token.Token, message string) {
 	panic(&RuntimeError{Token: t, Message: message}) // ← usa &
 }
 
+// RunFile executa um script como o comando "nox <script>". Além dos erros
+// de ExecFile, devolve *ExitError quando o script chama os.exit.
 func (n *Nox) RunFile(path string) error {
+	err := n.ExecFile(path)
+	if _, ok := err.(*os.PathError); ok {
+		return fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	if err == nil {
+		n.HadError = false
+	}
+	return err
+}
+
+// ExecFile executa um script sem encerrar o processo. Erros de sintaxe e de
+// execução são exibidos e devolvidos (diagnostic.List ou *RuntimeError);
+// os.exit devolve *ExitError.
+func (n *Nox) ExecFile(path string) error {
 	source, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", path, err)
+		return err
 	}
 
 	if absDir, err := filepath.Abs(filepath.Dir(path)); err == nil {
 		n.WorkingDir = absDir
 	}
 
-	fmt.Println("Running file:", path, " ", len(source), "bytes")
+	// aviso em stderr, para não se misturar à saída do programa
+	fmt.Fprintln(n.stderr(), "Running file:", path, " ", len(source), "bytes")
 
 	interpreter := NewInterpreter(n, false)
+	interpreter.resetStack("<script>", path)
+	if n.Debugger != nil {
+		interpreter.AttachDebugger(n.Debugger)
+	}
+	if n.Profiler != nil {
+		interpreter.AttachProfiler(n.Profiler)
+	}
+	if n.Coverage != nil {
+		interpreter.AttachCoverage(n.Coverage)
+	}
 
+	leave := n.enterScript(path)
 	err = n.Run(string(source), interpreter)
-	if err != nil {
-		// fmt.Printf("Error: %v\n", err)
-		switch err := err.(type) {
-		case parser.ParserError:
-			interpreter.ErrorAt(err.Token.Line, err.Message)
-			os.Exit(65)
-		case *RuntimeError:
-			// já foi exibido em Run()
-			os.Exit(70)
-		default:
-			os.Exit(1)
-		}
-	} else {
-		n.HadError = false
+	leave()
+	if diags, ok := err.(diagnostic.List); ok {
+		fmt.Fprintln(n.stderr(), diags.Render(string(source)))
 	}
-
-	return nil
+	return err
 }
 
-func (n *Nox) RunPrompt() {
+// RunPrompt executa o REPL até o fim da entrada ou um comando de saída.
+// os.exit encerra o REPL devolvendo *ExitError.
+func (n *Nox) RunPrompt() error {
 	currentPath, err := os.Getwd()
 	if err != nil {
-		fmt.Println("Error getting current working directory:", err)
-		os.Exit(1)
+		return fmt.Errorf("getting current working directory: %w", err)
 	}
 	n.WorkingDir = currentPath // Define o diretório de trabalho como atual
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("Welcome to Nox! Type 'exit', 'quit' or '\\q' to exit.")
-	fmt.Println("Press ENTER twice to execute multiline input.")
+	reader, out := n.stdin(), n.stdout()
+	fmt.Fprintln(out, "Welcome to Nox! Type 'exit', 'quit' or '\\q' to exit.")
+	fmt.Fprintln(out, "Press ENTER twice to execute multiline input.")
 
 	interpreter := NewInterpreter(n, true)
+	interpreter.resetStack("<repl>", "<stdin>")
 
 	for {
 		var lines []string
 		for {
-			fmt.Print(">> ")
+			fmt.Fprint(out, ">> ")
 			line, err := reader.ReadString('\n')
 			if err != nil {
 				if err == io.EOF {
-					fmt.Println("Exiting Nox.")
-					return
+					fmt.Fprintln(out, "Exiting Nox.")
+					return nil
 				}
-				fmt.Println("Error reading input:", err)
-				return
+				return fmt.Errorf("reading input: %w", err)
 			}
 
 			text := strings.TrimSpace(line)
 
 			if text == "exit" || text == "quit" || text == "\\q" {
-				fmt.Println("Exiting Nox.")
-				return
+				fmt.Fprintln(out, "Exiting Nox.")
+				return nil
 			}
 
 			// Break if the user hits enter twice
@@ -115,9 +180,13 @@ func (n *Nox) RunPrompt() {
 
 		src := strings.Join(lines, "")
 		if err := n.Run(src, interpreter); err != nil {
-			if _, ok := err.(*RuntimeError); !ok {
+			if diags, ok := err.(diagnostic.List); ok {
+				fmt.Fprintln(n.stderr(), diags.Render(src))
+			} else if _, ok := err.(*ExitError); ok {
+				return err
+			} else if _, ok := err.(*RuntimeError); !ok {
 				// Só imprime erros que NÃO são RuntimeError (ex: ParserError, etc.)
-				fmt.Printf("Error: %v\n", err)
+				fmt.Fprintf(n.stderr(), "Error: %v\n", err)
 			}
 		} else {
 			n.HadError = false
@@ -125,14 +194,28 @@ func (n *Nox) RunPrompt() {
 	}
 }
 
-func (n *Nox) Run(source string, interpreter *Interpreter) (err error) {
+// Run executa source e, se a execução falha, imprime o traceback em Stderr.
+func (n *Nox) Run(source string, interpreter *Interpreter) error {
+	err := n.run(source, interpreter)
+	if runtimeErr, ok := err.(*RuntimeError); ok {
+		fmt.Fprintln(n.stderr(), runtimeErr.Traceback())
+	}
+	return err
+}
+
+// run executa source como Run, mas só devolve o erro de execução, para quem
+// o apresenta de outro jeito (ver RunTests).
+func (n *Nox) run(source string, interpreter *Interpreter) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if runtimeErr, ok := r.(*RuntimeError); ok {
-				fmt.Println(runtimeErr.Error()) // só a mensagem bonita
+				interpreter.captureStack(runtimeErr)
 				n.HadRuntimeError = true
 				err = runtimeErr // permite tratamento externo se necessário
 				return
+			} else if exitErr, ok := r.(*ExitError); ok {
+				err = exitErr
+				return
 			} else {
 				panic(r)
 			}
@@ -140,24 +223,25 @@ func (n *Nox) Run(source string, interpreter *Interpreter) (err error) {
 		}
 	}()
 
-	scanner := scanner.NewScanner([]rune(source))
-	tokens, err := scanner.ScanTokens()
-	if err != nil {
-		n.HadError = true
-		return fmt.Errorf("failed to scan tokens: %w", err)
+	if interpreter.sandboxErr != nil {
+		return interpreter.sandboxErr
 	}
 
-	parser := parser.NewParser(tokens)
-	statements, err := parser.Parse()
-	if err != nil {
-		return err
+	statements, diags := ParseSource(interpreter.currentFile, source)
+	if len(diags) > 0 {
+		n.HadError = true
+		return diags
 	}
 
 	resolver := NewResolver(interpreter)
 	resolver.ResolveStatements(statements)
+	if len(resolver.Errors) > 0 {
+		n.HadError = true
+		return syntaxDiagnostics(interpreter.currentFile, resolver.Errors)
+	}
 
-	if n.HadError {
-		return fmt.Errorf("parsing failed with errors")
+	if interpreter.coverage != nil {
+		interpreter.coverage.register(interpreter.currentFile, statements)
 	}
 
 	defer func() {
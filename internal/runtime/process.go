@@ -0,0 +1,518 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+// processOptions representa o dict opcional aceito por process.run/spawn/stream.
+type processOptions struct {
+	env     []string
+	cwd     string
+	stdin   *string
+	timeout time.Duration
+}
+
+// outputBuffer acumula a saída do processo e permite leitura linha a linha
+// enquanto ele ainda está em execução.
+type outputBuffer struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newOutputBuffer() *outputBuffer {
+	b := &outputBuffer{}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n, err := b.buf.Write(p)
+	b.cond.Broadcast()
+	return n, err
+}
+
+func (b *outputBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+}
+
+// ReadLine bloqueia até haver uma linha completa ou o processo terminar.
+func (b *outputBuffer) ReadLine() (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		if idx := bytes.IndexByte(b.buf.Bytes(), '\n'); idx >= 0 {
+			line := string(b.buf.Next(idx + 1))
+			return strings.TrimRight(line, "\r\n"), true
+		}
+		if b.closed {
+			if b.buf.Len() > 0 {
+				return string(b.buf.Next(b.buf.Len())), true
+			}
+			return "", false
+		}
+		b.cond.Wait()
+	}
+}
+
+// Rest retorna o que ainda não foi lido.
+func (b *outputBuffer) Rest() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf.Next(b.buf.Len()))
+}
+
+// ProcessInstance é um processo em segundo plano criado por process.spawn.
+type ProcessInstance struct {
+	cmd     *exec.Cmd
+	ctx     context.Context
+	stdin   io.WriteCloser
+	stdout  *outputBuffer
+	stderr  *outputBuffer
+	done    chan struct{}
+	err     error
+	command string
+}
+
+func (p *ProcessInstance) String() string {
+	return fmt.Sprintf("<process %d %s>", p.cmd.Process.Pid, p.command)
+}
+
+func (p *ProcessInstance) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "pid":
+		return float64(p.cmd.Process.Pid)
+	case "readline":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			var line string
+			var ok bool
+			i.released(func() { line, ok = p.stdout.ReadLine() })
+			i.stopIfCanceled()
+			if !ok {
+				return nil // fim da saída
+			}
+			return line
+		}}
+	case "write":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			data, ok := args[0].(string)
+			if !ok {
+				i.Runtime.ReportRuntimeError(name, "process.write(data) expects a string.")
+				return nil
+			}
+			if p.stdin == nil {
+				i.Runtime.ReportRuntimeError(name, "process stdin is closed.")
+				return nil
+			}
+			var err error
+			i.released(func() { _, err = io.WriteString(p.stdin, data) })
+			if err != nil {
+				i.Runtime.ReportRuntimeError(name, "process.write: "+err.Error())
+			}
+			return nil
+		}}
+	case "close_stdin":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			p.closeStdin()
+			return nil
+		}}
+	case "wait":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			p.closeStdin()
+			i.await(p.done)
+			return processResult(p.ctx, p.err, p.stdout.Rest(), p.stderr.Rest())
+		}}
+	case "kill":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			select {
+			case <-p.done:
+				return false
+			default:
+			}
+			return p.cmd.Process.Kill() == nil
+		}}
+	case "running":
+		return &BuiltinFunction{ArityValue: 0, CallFunc: func(i *Interpreter, args []any) any {
+			select {
+			case <-p.done:
+				return false
+			default:
+				return true
+			}
+		}}
+	default:
+		return nil
+	}
+}
+
+func (p *ProcessInstance) closeStdin() {
+	if p.stdin != nil {
+		p.stdin.Close()
+		p.stdin = nil
+	}
+}
+
+// await espera done sem segurar a trava dos handlers de http.serve (ver
+// Interpreter.released). O cancelamento de Nox.Context interrompe a espera.
+func (i *Interpreter) await(done <-chan struct{}) {
+	i.released(func() {
+		select {
+		case <-done:
+		case <-i.context().Done():
+		}
+	})
+	i.stopIfCanceled()
+}
+
+// stopIfCanceled interrompe o script depois de uma espera se Nox.Context foi
+// cancelado durante ela. Os processos, ligados ao mesmo contexto, já foram
+// encerrados; sem isso o script seguiria com o resultado deles até o
+// próximo tick.
+func (i *Interpreter) stopIfCanceled() {
+	if err := i.checkContext(); err != nil {
+		panic(err)
+	}
+}
+
+func parseProcessArgs(i *Interpreter, fn string, v any) ([]string, bool) {
+	switch args := v.(type) {
+	case string:
+		return []string{args}, true
+	case *ListInstance:
+		if len(args.Elements) == 0 {
+			i.Runtime.ReportRuntimeError(nil, fn+" expects a non-empty argument list.")
+			return nil, false
+		}
+		argv := make([]string, len(args.Elements))
+		for idx, el := range args.Elements {
+			s, ok := el.(string)
+			if !ok {
+				i.Runtime.ReportRuntimeError(nil, fn+" expects all arguments to be strings.")
+				return nil, false
+			}
+			argv[idx] = s
+		}
+		return argv, true
+	default:
+		i.Runtime.ReportRuntimeError(nil, fn+" expects a list of arguments, e.g. [\"ls\", \"-la\"].")
+		return nil, false
+	}
+}
+
+func parseProcessOptions(i *Interpreter, fn string, v any) (*processOptions, bool) {
+	opts := &processOptions{}
+	if v == nil {
+		return opts, true
+	}
+	dict, ok := v.(*DictInstance)
+	if !ok {
+		i.Runtime.ReportRuntimeError(nil, fn+" expects options to be a dict.")
+		return nil, false
+	}
+
+	for key, value := range dict.Entries {
+		switch key {
+		case "env":
+			env, ok := value.(*DictInstance)
+			if !ok {
+				i.Runtime.ReportRuntimeError(nil, fn+": option 'env' must be a dict.")
+				return nil, false
+			}
+			opts.env = os.Environ()
+			for k, val := range env.Entries {
+				opts.env = append(opts.env, k+"="+StringifyCompact(val))
+			}
+		case "cwd":
+			cwd, ok := value.(string)
+			if !ok {
+				i.Runtime.ReportRuntimeError(nil, fn+": option 'cwd' must be a string.")
+				return nil, false
+			}
+			opts.cwd = cwd
+		case "stdin":
+			input, ok := value.(string)
+			if !ok {
+				i.Runtime.ReportRuntimeError(nil, fn+": option 'stdin' must be a string.")
+				return nil, false
+			}
+			opts.stdin = &input
+		case "timeout":
+			d, ok := toDuration(value)
+			if !ok {
+				i.Runtime.ReportRuntimeError(nil, fn+": option 'timeout' must be a number of seconds or a duration.")
+				return nil, false
+			}
+			opts.timeout = d
+		default:
+			i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("%s: unknown option '%s'.", fn, key))
+			return nil, false
+		}
+	}
+	return opts, true
+}
+
+// processWaitDelay é quanto Wait espera pela saída de um processo encerrado
+// pelo contexto: um neto que herdou stdout não segura o script para sempre.
+const processWaitDelay = time.Second
+
+// newCommand cria o processo ligado ao contexto do interpretador: cancelar
+// Nox.Context também encerra os processos filhos.
+func newCommand(parent context.Context, argv []string, opts *processOptions) (*exec.Cmd, context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if opts.timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, opts.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.WaitDelay = processWaitDelay
+	cmd.Env = opts.env
+	cmd.Dir = opts.cwd
+	if opts.stdin != nil {
+		cmd.Stdin = strings.NewReader(*opts.stdin)
+	}
+	return cmd, ctx, cancel
+}
+
+// processResult monta o dict de resultado. Códigos de saída diferentes de zero
+// não são erros; apenas falhas ao iniciar o processo geram RuntimeError.
+func processResult(ctx context.Context, err error, stdout, stderr string) *DictInstance {
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else {
+			code = -1
+		}
+	}
+	return NewDictInstance(map[string]any{
+		"code":      float64(code),
+		"ok":        code == 0,
+		"stdout":    stdout,
+		"stderr":    stderr,
+		"timed_out": errors.Is(ctx.Err(), context.DeadlineExceeded),
+	})
+}
+
+// processCallArgs valida os argumentos (args, options?).
+func processCallArgs(i *Interpreter, fn string, args []any) ([]string, *processOptions, bool) {
+	if len(args) < 1 || len(args) > 2 {
+		i.Runtime.ReportRuntimeError(nil, fn+" expects 1 or 2 arguments.")
+		return nil, nil, false
+	}
+	argv, ok := parseProcessArgs(i, fn, args[0])
+	if !ok {
+		return nil, nil, false
+	}
+	var rawOpts any
+	if len(args) == 2 {
+		rawOpts = args[1]
+	}
+	opts, ok := parseProcessOptions(i, fn, rawOpts)
+	if !ok {
+		return nil, nil, false
+	}
+	return argv, opts, true
+}
+
+func NewProcessModule() *MapInstance {
+	return NewMapInstance(map[string]any{
+		"run": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				argv, opts, ok := processCallArgs(i, "process.run(args, options?)", args)
+				if !ok {
+					return nil
+				}
+				cmd, ctx, cancel := newCommand(i.context(), argv, opts)
+				defer cancel()
+
+				var stdout, stderr bytes.Buffer
+				cmd.Stdout = &stdout
+				cmd.Stderr = &stderr
+
+				var err error
+				i.released(func() { err = cmd.Run() })
+				i.stopIfCanceled()
+				if err != nil && cmd.ProcessState == nil && ctx.Err() == nil {
+					i.Runtime.ReportRuntimeError(nil, "process.run: "+err.Error())
+					return nil
+				}
+				return processResult(ctx, err, stdout.String(), stderr.String())
+			},
+		},
+		"stream": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) < 2 || len(args) > 3 {
+					i.Runtime.ReportRuntimeError(nil, "process.stream(args, callback, options?) expects 2 or 3 arguments.")
+					return nil
+				}
+				callback, ok := args[1].(Callable)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "process.stream callback must be a function.")
+					return nil
+				}
+				argv, opts, ok := processCallArgs(i, "process.stream(args, callback, options?)", append([]any{args[0]}, args[2:]...))
+				if !ok {
+					return nil
+				}
+
+				cmd, ctx, cancel := newCommand(i.context(), argv, opts)
+				defer cancel()
+
+				stdoutPipe, err := cmd.StdoutPipe()
+				if err != nil {
+					i.Runtime.ReportRuntimeError(nil, "process.stream: "+err.Error())
+					return nil
+				}
+				stderrPipe, err := cmd.StderrPipe()
+				if err != nil {
+					i.Runtime.ReportRuntimeError(nil, "process.stream: "+err.Error())
+					return nil
+				}
+				if err := cmd.Start(); err != nil {
+					i.Runtime.ReportRuntimeError(nil, "process.stream: "+err.Error())
+					return nil
+				}
+
+				// As linhas são lidas em goroutines, mas o callback roda sempre
+				// na goroutine do interpretador.
+				// Não há limite de tamanho de linha; se a leitura falhar, o resto da
+				// saída é descartado para que o processo não bloqueie escrevendo.
+				type line struct{ text, source string }
+				lines := make(chan line)
+				var wg sync.WaitGroup
+				var readErr error
+				var readErrOnce sync.Once
+				scan := func(r io.Reader, source string) {
+					defer wg.Done()
+					reader := bufio.NewReader(r)
+					for {
+						text, err := reader.ReadString('\n')
+						if text != "" {
+							text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+							lines <- line{text, source}
+						}
+						if err != nil {
+							if err != io.EOF {
+								readErrOnce.Do(func() { readErr = fmt.Errorf("reading %s: %w", source, err) })
+								io.Copy(io.Discard, r)
+							}
+							return
+						}
+					}
+				}
+				wg.Add(2)
+				go scan(stdoutPipe, "stdout")
+				go scan(stderrPipe, "stderr")
+				go func() {
+					wg.Wait()
+					close(lines)
+				}()
+
+				var stdout, stderr strings.Builder
+				func() {
+					// Se o callback falhar, mata o processo antes de propagar o erro
+					defer func() {
+						if r := recover(); r != nil {
+							cmd.Process.Kill()
+							for range lines {
+							}
+							cmd.Wait()
+							panic(r)
+						}
+					}()
+					for {
+						var l line
+						var more bool
+						i.released(func() { l, more = <-lines })
+						if !more {
+							break
+						}
+						if l.source == "stdout" {
+							stdout.WriteString(l.text + "\n")
+						} else {
+							stderr.WriteString(l.text + "\n")
+						}
+						callback.Call(i, []any{l.text, l.source})
+					}
+				}()
+
+				i.released(func() { err = cmd.Wait() })
+				i.stopIfCanceled()
+				if readErr != nil {
+					i.Runtime.ReportRuntimeError(nil, "process.stream: "+readErr.Error())
+					return nil
+				}
+				return processResult(ctx, err, stdout.String(), stderr.String())
+			},
+		},
+		"spawn": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				argv, opts, ok := processCallArgs(i, "process.spawn(args, options?)", args)
+				if !ok {
+					return nil
+				}
+				cmd, ctx, cancel := newCommand(i.context(), argv, opts)
+				proc := &ProcessInstance{
+					cmd:     cmd,
+					ctx:     ctx,
+					stdout:  newOutputBuffer(),
+					stderr:  newOutputBuffer(),
+					done:    make(chan struct{}),
+					command: strings.Join(argv, " "),
+				}
+				cmd.Stdout = proc.stdout
+				cmd.Stderr = proc.stderr
+
+				if opts.stdin == nil {
+					stdin, err := cmd.StdinPipe()
+					if err != nil {
+						cancel()
+						i.Runtime.ReportRuntimeError(nil, "process.spawn: "+err.Error())
+						return nil
+					}
+					proc.stdin = stdin
+				}
+
+				if err := cmd.Start(); err != nil {
+					cancel()
+					i.Runtime.ReportRuntimeError(nil, "process.spawn: "+err.Error())
+					return nil
+				}
+
+				go func() {
+					proc.err = cmd.Wait()
+					proc.stdout.Close()
+					proc.stderr.Close()
+					cancel()
+					close(proc.done)
+				}()
+
+				return proc
+			},
+		},
+	})
+}
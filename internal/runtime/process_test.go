@@ -0,0 +1,126 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func requireCommand(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skip(name + " not found")
+	}
+}
+
+// runCanceled executa source com um Nox.Context cancelado depois de 100ms
+// e devolve o erro e quanto a execução levou.
+func runCanceled(t *testing.T, source string) (error, time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	n.Context = ctx
+	start := time.Now()
+	err := n.Run(source, NewInterpreter(n, false))
+	return err, time.Since(start)
+}
+
+func TestProcessWaitsStopOnCancel(t *testing.T) {
+	requireCommand(t, "sleep")
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"run", `process.run(["sleep", "10"]);`},
+		{"stream", `func ignore(line, source) {} process.stream(["sleep", "10"], ignore);`},
+		{"wait", `let p = process.spawn(["sleep", "10"]); p.wait();`},
+		{"readline", `let p = process.spawn(["sleep", "10"]); p.readline();`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, elapsed := runCanceled(t, tt.source+"\nprint \"after\";")
+			var runtimeErr *RuntimeError
+			if !errors.As(err, &runtimeErr) || runtimeErr.Kind != ErrorTimeout {
+				t.Errorf("err = %v, want the timeout error", err)
+			}
+			if elapsed > 5*time.Second {
+				t.Errorf("took %v, want the wait to stop with the context", elapsed)
+			}
+		})
+	}
+}
+
+// process.run espera o processo sem travar os outros handlers.
+func TestProcessRunInHandlerDoesNotBlockServer(t *testing.T) {
+	requireCommand(t, "sleep")
+	server, _ := serveScript(t, `
+func slow(req, res) {
+    process.run(["sleep", "2"]);
+    return "slow";
+}
+func fast(req, res) {
+    return "fast";
+}
+http.route("/test/process-slow", slow);
+http.route("/test/process-fast", fast);
+`)
+	go func() {
+		if resp, err := http.Get(server.URL + "/test/process-slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(200 * time.Millisecond) // o handler lento já está em process.run
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/test/process-fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "fast" {
+		t.Errorf("body = %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fast handler took %v while another handler ran a process", elapsed)
+	}
+}
+
+// Linhas maiores que o buffer padrão de leitura chegam inteiras ao
+// callback, e a saída seguinte continua sendo lida.
+func TestProcessStreamLongLine(t *testing.T) {
+	requireCommand(t, "sh")
+	requireCommand(t, "head")
+	requireCommand(t, "tr")
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	interpreter := NewInterpreter(n, false)
+	done := make(chan error, 1)
+	go func() {
+		done <- n.Run(`
+let sizes = [];
+func collect(line, source) {
+    sizes.append(len(line));
+}
+let result = process.stream(["sh", "-c", "head -c 2000000 /dev/zero | tr '\\0' x; echo; echo end"], collect);
+`, interpreter)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("process.stream did not return")
+	}
+	sizes := interpreter.Globals()["sizes"].(*ListInstance).Elements
+	if len(sizes) != 2 || sizes[0] != 2000000.0 || sizes[1] != 3.0 {
+		t.Errorf("line sizes = %v, want [2000000 3]", sizes)
+	}
+}
@@ -0,0 +1,287 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/pprof"
+)
+
+// Profiler mede o tempo gasto em cada função e linha de um programa. Em vez
+// de amostrar em intervalos, o interpretador avisa a cada comando executado
+// e a cada entrada e saída de função; o tempo decorrido desde o último aviso
+// é atribuído à pilha de chamadas vigente. Builtins não têm frame próprio e
+// entram na conta da linha que os chamou, assim como o tempo entre a entrada
+// em uma função e o primeiro comando do corpo dela. Os avisos vêm do
+// interpretador que está executando, que pode ser um fork de http.serve com
+// pilha própria.
+type Profiler struct {
+	running *Interpreter // o interpretador do último aviso
+
+	mu         sync.Mutex // Stop pode ser chamado de outra goroutine (Ctrl+C)
+	start      time.Time
+	last       time.Time
+	duration   time.Duration
+	stopped    bool
+	entering   bool // um frame foi empilhado e ainda não executou comandos
+	statements int64
+	samples    map[string]*profileSample
+}
+
+type profileLocation struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// profileSample acumula o tempo e os comandos de uma pilha, da raiz à folha.
+type profileSample struct {
+	stack []profileLocation
+	count int64
+	nanos int64
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{samples: map[string]*profileSample{}}
+}
+
+// AttachProfiler liga o profiler ao interpretador. A medição começa no
+// primeiro comando executado.
+func (i *Interpreter) AttachProfiler(p *Profiler) {
+	i.profiler = p
+}
+
+// before é chamado pelo interpretador i antes de cada comando.
+func (p *Profiler) before(i *Interpreter, stmt ast.Stmt) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	p.record(i)
+	p.entering = false
+	p.statements++
+	p.sample(i.frames).count++
+}
+
+// enterOrLeave fecha o intervalo da pilha atual antes de um frame ser
+// empilhado (enter) ou desempilhado.
+func (p *Profiler) enterOrLeave(i *Interpreter, enter bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.stopped {
+		p.record(i)
+		p.entering = enter
+	}
+}
+
+// record atribui o tempo desde o último aviso à pilha atual de i. Um frame
+// que ainda não executou comandos está na linha da declaração da função, que
+// não é código executado: o tempo vai para a linha que chamou a função.
+func (p *Profiler) record(i *Interpreter) {
+	p.running = i
+	now := time.Now()
+	if p.last.IsZero() {
+		p.start = now
+	} else {
+		frames := i.frames
+		if p.entering && len(frames) > 1 {
+			frames = frames[:len(frames)-1]
+		}
+		p.sample(frames).nanos += int64(now.Sub(p.last))
+	}
+	p.last = now
+}
+
+// sample devolve a amostra da pilha frames, criando-a se preciso.
+func (p *Profiler) sample(frames []*StackFrame) *profileSample {
+	var key strings.Builder
+	for _, frame := range frames {
+		fmt.Fprintf(&key, "%s\x00%s\x00%d\x00", frame.Function, frame.File, frame.Line)
+	}
+
+	sample, ok := p.samples[key.String()]
+	if !ok {
+		sample = &profileSample{stack: make([]profileLocation, len(frames))}
+		for idx, frame := range frames {
+			sample.stack[idx] = profileLocation{frame.Function, frame.File, frame.Line}
+		}
+		p.samples[key.String()] = sample
+	}
+	return sample
+}
+
+// Stop encerra a medição. Pode ser chamado mais de uma vez.
+func (p *Profiler) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	if p.running != nil && !p.last.IsZero() {
+		p.record(p.running)
+		p.duration = p.last.Sub(p.start)
+	}
+	p.stopped = true
+}
+
+// profileEntry é uma linha do relatório: uma função ou uma linha de código.
+type profileEntry struct {
+	name  string
+	flat  int64
+	cum   int64
+	count int64
+}
+
+// Report escreve os tempos próprios (flat) e acumulados (cum) por função e
+// por linha, dos mais custosos para os menos, limitados a top entradas.
+func (p *Profiler) Report(w io.Writer, top int) {
+	p.Stop()
+
+	cwd, _ := os.Getwd()
+	functions := map[string]*profileEntry{}
+	lines := map[string]*profileEntry{}
+	var total int64
+
+	add := func(entries map[string]*profileEntry, name string, seen map[string]bool, sample *profileSample, leaf bool) {
+		entry, ok := entries[name]
+		if !ok {
+			entry = &profileEntry{name: name}
+			entries[name] = entry
+		}
+		if leaf {
+			entry.flat += sample.nanos
+			entry.count += sample.count
+		}
+		// Em recursões a mesma função aparece várias vezes na pilha, mas o
+		// tempo acumulado só conta uma
+		if !seen[name] {
+			seen[name] = true
+			entry.cum += sample.nanos
+		}
+	}
+
+	for _, sample := range p.samples {
+		total += sample.nanos
+		seen := map[string]bool{}
+		for idx, loc := range sample.stack {
+			leaf := idx == len(sample.stack)-1
+			file := displayPath(cwd, loc.File)
+			add(functions, fmt.Sprintf("%s (%s)", loc.Function, file), seen, sample, leaf)
+			add(lines, fmt.Sprintf("%s:%d (%s)", file, loc.Line, loc.Function), seen, sample, leaf)
+		}
+	}
+
+	fmt.Fprintf(w, "Profile: %s total, %d statements\n", formatNanos(int64(p.duration)), p.statements)
+	writeProfileTable(w, "function", functions, total, top)
+	writeProfileTable(w, "line", lines, total, top)
+}
+
+func writeProfileTable(w io.Writer, title string, entries map[string]*profileEntry, total int64, top int) {
+	sorted := make([]*profileEntry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].flat != sorted[b].flat {
+			return sorted[a].flat > sorted[b].flat
+		}
+		if sorted[a].cum != sorted[b].cum {
+			return sorted[a].cum > sorted[b].cum
+		}
+		return sorted[a].name < sorted[b].name
+	})
+
+	percent := func(n int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(n) * 100 / float64(total)
+	}
+
+	fmt.Fprintf(w, "\n%10s %6s %10s %6s %10s  %s\n", "flat", "flat%", "cum", "cum%", "statements", title)
+	for idx, entry := range sorted {
+		if top > 0 && idx == top {
+			fmt.Fprintf(w, "  ... %d more\n", len(sorted)-top)
+			break
+		}
+		fmt.Fprintf(w, "%10s %5.1f%% %10s %5.1f%% %10d  %s\n",
+			formatNanos(entry.flat), percent(entry.flat),
+			formatNanos(entry.cum), percent(entry.cum),
+			entry.count, entry.name)
+	}
+}
+
+func formatNanos(nanos int64) string {
+	d := time.Duration(nanos)
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	}
+	return d.String()
+}
+
+// WritePprof grava o perfil no formato do pprof, com duas medidas por
+// amostra: comandos executados e tempo em nanossegundos.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	p.Stop()
+
+	profile := &pprof.Profile{
+		SampleTypes: []pprof.ValueType{{Type: "statements", Unit: "count"}, {Type: "time", Unit: "nanoseconds"}},
+		Start:       p.start,
+		Duration:    p.duration,
+		PeriodType:  pprof.ValueType{Type: "time", Unit: "nanoseconds"},
+		Period:      1,
+	}
+
+	functionIDs := map[[2]string]uint64{}
+	locationIDs := map[profileLocation]uint64{}
+	locationID := func(loc profileLocation) uint64 {
+		if id, ok := locationIDs[loc]; ok {
+			return id
+		}
+		key := [2]string{loc.Function, loc.File}
+		fn, ok := functionIDs[key]
+		if !ok {
+			fn = uint64(len(profile.Functions) + 1)
+			functionIDs[key] = fn
+			// O pprof descarta trechos entre "<>" ao exibir nomes, como faz com
+			// templates de C++; "<script>" e "<module>" perderiam o nome todo
+			name := strings.Trim(loc.Function, "<>")
+			profile.Functions = append(profile.Functions, pprof.Function{ID: fn, Name: name, File: loc.File})
+		}
+		id := uint64(len(profile.Locations) + 1)
+		locationIDs[loc] = id
+		profile.Locations = append(profile.Locations, pprof.Location{ID: id, Function: fn, Line: int64(loc.Line)})
+		return id
+	}
+
+	// Ordem determinística, para que perfis iguais gerem arquivos iguais
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sample := p.samples[key]
+		locations := make([]uint64, len(sample.stack))
+		for idx, loc := range sample.stack {
+			locations[len(sample.stack)-1-idx] = locationID(loc) // pprof lista da folha para a raiz
+		}
+		profile.Samples = append(profile.Samples, pprof.Sample{
+			Locations: locations,
+			Values:    []int64{sample.count, sample.nanos},
+		})
+	}
+
+	return profile.Write(w)
+}
@@ -0,0 +1,103 @@
+package runtime
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// O tempo de uma função vai para as linhas do corpo, nunca para a linha da
+// declaração, e cada linha do relatório executou algum comando.
+func TestProfilerReport(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "prof.nox")
+	source := `func work(n) {
+    let total = 0;
+    for i in range(n) {
+        total = total + i;
+    }
+    return total;
+}
+func empty() {}
+for k in range(20) {
+    work(50);
+    empty();
+}
+`
+	if err := os.WriteFile(script, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	n.Profiler = NewProfiler()
+	if err := n.ExecFile(script); err != nil {
+		t.Fatal(err)
+	}
+
+	var report strings.Builder
+	n.Profiler.Report(&report, 0)
+	got := report.String()
+	if !strings.Contains(got, "statements  line") || !strings.Contains(got, "statements  function") {
+		t.Errorf("report columns are not labelled as statements:\n%s", got)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if strings.Contains(line, "prof.nox:1 (work)") || strings.Contains(line, "prof.nox:8 (empty)") {
+			t.Errorf("time charged to a declaration line: %q", line)
+		}
+		if fields := strings.Fields(line); len(fields) == 6 && fields[4] == "0" {
+			t.Errorf("entry without statements: %q", line)
+		}
+	}
+	if !strings.Contains(got, "prof.nox:4 (work)") {
+		t.Errorf("report is missing the loop body line:\n%s", got)
+	}
+}
+
+// Handlers de http.serve rodam em forks do interpretador, com pilha
+// própria; o tempo deles vai para as funções do handler, não para <script>.
+func TestProfilerHandlerFork(t *testing.T) {
+	source := `func slow() {
+    let total = 0;
+    for i in range(200) {
+        total = total + i;
+    }
+    return total;
+}
+func handle(req, res) {
+    return slow();
+}
+http.route("/test/profile", handle);
+`
+	n := NewNox()
+	n.Stdout, n.Stderr = io.Discard, io.Discard
+	n.Profiler = NewProfiler()
+	interpreter := NewInterpreter(n, false)
+	interpreter.resetStack("<script>", "srv.nox")
+	interpreter.AttachProfiler(n.Profiler)
+	if err := n.Run(source, interpreter); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(n.mux)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/test/profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	var report strings.Builder
+	n.Profiler.Report(&report, 0)
+	got := report.String()
+	for _, want := range []string{"slow (srv.nox)", "handle (srv.nox)", "srv.nox:4 (slow)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "srv.nox:4 (<script>)") {
+		t.Errorf("handler line charged to <script>:\n%s", got)
+	}
+}
@@ -0,0 +1,235 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+type RegexInstance struct {
+	Regexp *regexp.Regexp
+}
+
+func NewRegexInstance(re *regexp.Regexp) *RegexInstance {
+	return &RegexInstance{Regexp: re}
+}
+
+func (r *RegexInstance) String() string {
+	return fmt.Sprintf("<regex %q>", r.Regexp.String())
+}
+
+func (r *RegexInstance) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "pattern":
+		return r.Regexp.String()
+	case "groups":
+		names := []any{}
+		for _, n := range r.Regexp.SubexpNames()[1:] {
+			names = append(names, n)
+		}
+		return NewListInstance(names)
+	case "match":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			s, ok := regexSubject(i, "regex.match", args, 1)
+			if !ok {
+				return nil
+			}
+			return r.Regexp.MatchString(s)
+		}}
+	case "find":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			s, ok := regexSubject(i, "regex.find", args, 1)
+			if !ok {
+				return nil
+			}
+			loc := r.Regexp.FindStringSubmatchIndex(s)
+			if loc == nil {
+				return nil
+			}
+			return r.matchDict(s, loc)
+		}}
+	case "find_all":
+		return &BuiltinFunction{ArityValue: 1, CallFunc: func(i *Interpreter, args []any) any {
+			s, ok := regexSubject(i, "regex.find_all", args, 1)
+			if !ok {
+				return nil
+			}
+			matches := []any{}
+			for _, loc := range r.Regexp.FindAllStringSubmatchIndex(s, -1) {
+				matches = append(matches, r.matchDict(s, loc))
+			}
+			return NewListInstance(matches)
+		}}
+	case "replace":
+		return &BuiltinFunction{ArityValue: 2, CallFunc: func(i *Interpreter, args []any) any {
+			s, ok := regexSubject(i, "regex.replace", args, 2)
+			if !ok {
+				return nil
+			}
+			return r.replace(i, s, args[1])
+		}}
+	case "split":
+		return &BuiltinFunction{ArityValue: -1, CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) < 1 || len(args) > 2 {
+				i.Runtime.ReportRuntimeError(name, "regex.split(string, limit?) expects 1 or 2 arguments.")
+				return nil
+			}
+			s, ok := args[0].(string)
+			if !ok {
+				i.Runtime.ReportRuntimeError(name, "regex.split expects a string.")
+				return nil
+			}
+			limit := -1
+			if len(args) == 2 {
+				n, ok := args[1].(float64)
+				if !ok {
+					i.Runtime.ReportRuntimeError(name, "regex.split limit must be a number.")
+					return nil
+				}
+				limit = int(n)
+			}
+			parts := []any{}
+			for _, part := range r.Regexp.Split(s, limit) {
+				parts = append(parts, part)
+			}
+			return NewListInstance(parts)
+		}}
+	default:
+		return nil
+	}
+}
+
+// matchDict converte uma correspondência em um dict com o texto, posições,
+// grupos por índice e grupos nomeados.
+func (r *RegexInstance) matchDict(s string, loc []int) *DictInstance {
+	groups := []any{}
+	named := map[string]any{}
+	names := r.Regexp.SubexpNames()
+
+	for g := 1; g < len(loc)/2; g++ {
+		var value any
+		if loc[2*g] >= 0 {
+			value = s[loc[2*g]:loc[2*g+1]]
+		}
+		groups = append(groups, value)
+		if names[g] != "" {
+			named[names[g]] = value
+		}
+	}
+
+	return NewDictInstance(map[string]any{
+		"text":   s[loc[0]:loc[1]],
+		"start":  float64(loc[0]),
+		"end":    float64(loc[1]),
+		"groups": NewListInstance(groups),
+		"named":  NewDictInstance(named),
+	})
+}
+
+// replace substitui cada correspondência por uma string (com $1, ${name}) ou
+// pelo resultado de uma função que recebe o dict da correspondência.
+func (r *RegexInstance) replace(i *Interpreter, s string, repl any) any {
+	switch fn := repl.(type) {
+	case string:
+		return r.Regexp.ReplaceAllString(s, fn)
+	case Callable:
+		result := []byte{}
+		last := 0
+		for _, loc := range r.Regexp.FindAllStringSubmatchIndex(s, -1) {
+			result = append(result, s[last:loc[0]]...)
+			value := fn.Call(i, []any{r.matchDict(s, loc)})
+			if str, ok := value.(string); ok {
+				result = append(result, str...)
+			} else {
+				result = append(result, StringifyCompact(value)...)
+			}
+			last = loc[1]
+		}
+		result = append(result, s[last:]...)
+		return string(result)
+	default:
+		i.Runtime.ReportRuntimeError(nil, "regex.replace expects a string or a function as replacement.")
+		return nil
+	}
+}
+
+func regexSubject(i *Interpreter, fn string, args []any, expected int) (string, bool) {
+	if len(args) != expected {
+		i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("%s expects %d argument(s).", fn, expected))
+		return "", false
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		i.Runtime.ReportRuntimeError(nil, fn+" expects a string.")
+		return "", false
+	}
+	return s, true
+}
+
+func compileRegex(i *Interpreter, fn string, pattern any) *RegexInstance {
+	switch p := pattern.(type) {
+	case *RegexInstance:
+		return p
+	case string:
+		re, err := regexp.Compile(p)
+		if err != nil {
+			i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("%s: invalid pattern: %v", fn, err))
+			return nil
+		}
+		return NewRegexInstance(re)
+	default:
+		i.Runtime.ReportRuntimeError(nil, fn+" expects a pattern string.")
+		return nil
+	}
+}
+
+// regexShortcut expõe um método de RegexInstance como função do módulo,
+// recebendo o padrão como primeiro argumento.
+func regexShortcut(method string, arity int) *BuiltinFunction {
+	return &BuiltinFunction{
+		ArityValue: arity,
+		CallFunc: func(i *Interpreter, args []any) any {
+			if len(args) < 2 {
+				i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("regex.%s(pattern, string, ...) expects at least 2 arguments.", method))
+				return nil
+			}
+			re := compileRegex(i, "regex."+method, args[0])
+			if re == nil {
+				return nil
+			}
+			fn := re.Get(&token.Token{Lexeme: method}).(*BuiltinFunction)
+			return fn.Call(i, args[1:])
+		},
+	}
+}
+
+func NewRegexModule() *MapInstance {
+	return NewMapInstance(map[string]any{
+		"compile": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if len(args) != 1 {
+					i.Runtime.ReportRuntimeError(nil, "regex.compile(pattern) expects 1 argument.")
+					return nil
+				}
+				return compileRegex(i, "regex.compile", args[0])
+			},
+		},
+		"escape": &BuiltinFunction{
+			ArityValue: 1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				s, ok := regexSubject(i, "regex.escape", args, 1)
+				if !ok {
+					return nil
+				}
+				return regexp.QuoteMeta(s)
+			},
+		},
+		"match":    regexShortcut("match", 2),
+		"find":     regexShortcut("find", 2),
+		"find_all": regexShortcut("find_all", 2),
+		"replace":  regexShortcut("replace", 3),
+		"split":    regexShortcut("split", -1),
+	})
+}
@@ -0,0 +1,51 @@
+package runtime
+
+import "testing"
+
+func TestRegexFind(t *testing.T) {
+	runExprTests(t, `
+import "regex" as regex;
+let email = regex.compile("(?P<user>\w+)@(?P<host>[a-z.]+)");
+let m = email.find("mail bob@example.com now");
+`, []exprTest{
+		{`m["text"]`, "bob@example.com"},
+		{`m["start"], m["end"]`, "5 20"},
+		{`m["groups"]`, "[bob, example.com]"},
+		{`m["named"]["user"], m["named"]["host"]`, "bob example.com"},
+		{`email.groups`, "[user, host]"},
+		{`email.pattern`, `(?P<user>\w+)@(?P<host>[a-z.]+)`},
+		{`regex.find("x", "abc")`, "<nil>"},
+		{`regex.find("(a)|(b)", "b")["groups"]`, "[<nil>, b]"},
+		{`len(regex.find_all("\d", "a1b2c3"))`, "3"},
+		{`regex.find_all("\d+", "a1b22")[1]["text"]`, "22"},
+		{`regex.match("^\d+$", "123"), regex.match("^\d+$", "12a")`, "true false"},
+		{`"abc".matches("b")`, "true"},
+	})
+}
+
+func TestRegexReplace(t *testing.T) {
+	runExprTests(t, `
+import "regex" as regex;
+func double(m) { return m["text"] + m["text"]; }
+func host(m) { return m["named"]["host"]; }
+`, []exprTest{
+		{`regex.replace("\d+", "a1b22", "#")`, "a#b#"},
+		{`regex.replace("(\d+)", "a1b22", "<$1>")`, "a<1>b<22>"},
+		{`regex.replace("(?P<n>\d+)", "a1", "[${n}]")`, "a[1]"},
+		{`regex.replace("\d+", "a1b22", double)`, "a11b2222"},
+		{`regex.replace("\w+@(?P<host>\w+)", "to bob@home", host)`, "to home"},
+		{`regex.split(",\s*", "a, b,c")`, "[a, b, c]"},
+		{`regex.split(",", "a,b,c", 2)`, "[a, b,c]"},
+		{`regex.escape("a.b*c")`, `a\.b\*c`},
+	})
+}
+
+func TestRegexInvalidPattern(t *testing.T) {
+	err := runError(t, `
+import "regex" as regex;
+regex.compile("(unclosed");
+`)
+	if err.Token == nil || err.Token.Lexeme != "compile" {
+		t.Errorf("error at %v, want the regex.compile call", err.Token)
+	}
+}
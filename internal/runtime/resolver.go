@@ -59,6 +59,7 @@ type Resolver struct {
 	currentFunction FunctionType
 	currentClass    ClassType
 	insideLoop      bool
+	Errors          parser.ParserErrors // erros encontrados; a resolução continua após cada um
 }
 
 func NewResolver(interpreter *Interpreter) *Resolver {
@@ -102,7 +103,7 @@ func (r *Resolver) Declare(name *token.Token) {
 	scope, _ := r.scopes.Peek()
 
 	if _, exists := scope[name.Lexeme]; exists {
-		r.interpreter.Runtime.ReportRuntimeError(name, "Variable already defined: "+name.Lexeme)
+		r.errorToken(name, "Variable already defined: "+name.Lexeme)
 		return
 	}
 
@@ -148,5 +149,5 @@ func (r *Resolver) ResolveFunction(stmt *ast.FunctionStmt, functionType Function
 }
 
 func (r *Resolver) errorToken(token *token.Token, message string) {
-	panic(parser.ParserError{Token: token, Message: message})
+	r.Errors = append(r.Errors, parser.ParserError{Token: token, Message: message})
 }
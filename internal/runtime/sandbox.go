@@ -0,0 +1,260 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Sandbox restringe o que um script pode fazer fora do interpretador. Com
+// Nox.Sandbox nil nada é restrito; com um Sandbox vazio o script não
+// acessa arquivos, processos, rede, variáveis de ambiente nem encerra o
+// processo. Violações geram erros de execução com Kind ErrorPermission,
+// que o script pode tratar com "?expr".
+type Sandbox struct {
+	ReadDirs  []string // pastas (e subpastas) cujos arquivos podem ser lidos
+	WriteDirs []string // pastas cujos arquivos podem ser criados, alterados e removidos (e lidos)
+	Exec      bool     // permite criar processos (os.exec e o módulo process)
+	Network   bool     // permite servir HTTP (módulo http)
+	Env       bool     // permite ler e alterar o ambiente do processo (os.getenv, os.setenv, os.chdir)
+	Exit      bool     // permite encerrar o processo (os.exit)
+	Disable   []string // módulos ("http") ou funções ("os.setenv", "open") desativados por completo
+}
+
+// Capacidades exigidas por um builtin.
+const (
+	capabilityExec    = "exec"
+	capabilityNetwork = "net"
+	capabilityEnv     = "env"
+	capabilityExit    = "exit"
+)
+
+// sandboxRule verifica uma chamada e devolve a mensagem de erro quando ela
+// não é permitida.
+type sandboxRule func(s *Sandbox, name string, args []any) string
+
+func needs(capability string) sandboxRule {
+	return func(s *Sandbox, name string, args []any) string {
+		if s.grants(capability) {
+			return ""
+		}
+		return fmt.Sprintf("%s needs the '%s' capability, which the sandbox does not grant.", name, capability)
+	}
+}
+
+func (s *Sandbox) grants(capability string) bool {
+	switch capability {
+	case capabilityExec:
+		return s.Exec
+	case capabilityNetwork:
+		return s.Network
+	case capabilityEnv:
+		return s.Env
+	case capabilityExit:
+		return s.Exit
+	}
+	return false
+}
+
+// reads e writes verificam o caminho passado no argumento de índice arg.
+func reads(arg int) sandboxRule {
+	return func(s *Sandbox, name string, args []any) string {
+		return s.checkPath(name, args, arg, false)
+	}
+}
+
+func writes(arg int) sandboxRule {
+	return func(s *Sandbox, name string, args []any) string {
+		return s.checkPath(name, args, arg, true)
+	}
+}
+
+// sandboxRules lista os builtins que saem do interpretador, pelo nome
+// completo ("os.exec") ou pelo módulo inteiro ("process").
+var sandboxRules = map[string]sandboxRule{
+	"open": func(s *Sandbox, name string, args []any) string {
+		mode, _ := argAt(args, 1).(string)
+		return s.checkPath(name, args, 0, strings.ContainsAny(mode, "wax+"))
+	},
+	"os.exit":     needs(capabilityExit),
+	"os.exec":     needs(capabilityExec),
+	"os.getenv":   needs(capabilityEnv),
+	"os.setenv":   needs(capabilityEnv),
+	"os.chdir":    needs(capabilityEnv),
+	"os.listdir":  reads(0),
+	"os.walk":     reads(0),
+	"os.info":     reads(0),
+	"os.chmod":    writes(0),
+	"os.mkdir":    writes(0),
+	"os.rmdir":    writes(0),
+	"path.exists": reads(0),
+	"path.size":   reads(0),
+	"path.time":   reads(0),
+	"path.isdir":  reads(0),
+	"path.isfile": reads(0),
+	"path.islink": reads(0),
+	"process":     needs(capabilityExec),
+	"http":        needs(capabilityNetwork),
+}
+
+func argAt(args []any, idx int) any {
+	if idx < len(args) {
+		return args[idx]
+	}
+	return nil
+}
+
+// checkPath verifica se o caminho em args[arg] está dentro das pastas
+// permitidas. Links simbólicos são seguidos antes da comparação.
+func (s *Sandbox) checkPath(name string, args []any, arg int, write bool) string {
+	path, ok := argAt(args, arg).(string)
+	if !ok {
+		return "" // o próprio builtin reporta o argumento inválido
+	}
+	dirs, action := s.WriteDirs, "write"
+	if !write {
+		dirs, action = append(slices.Clone(s.ReadDirs), s.WriteDirs...), "read"
+	}
+	if withinDirs(path, dirs) {
+		return ""
+	}
+	return fmt.Sprintf("%s cannot %s '%s': outside the directories allowed by the sandbox.", name, action, path)
+}
+
+// withinDirs diz se path está em uma das pastas dirs ou abaixo dela.
+func withinDirs(path string, dirs []string) bool {
+	resolved := resolvePath(path)
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(resolvePath(dir), resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath devolve o caminho absoluto com os links simbólicos da parte
+// que já existe resolvidos, para que "permitida/link -> /etc" não escape.
+func resolvePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rest := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if filepath.Dir(dir) == dir {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+func permissionError(message string) *RuntimeError {
+	return &RuntimeError{Message: "Permission denied: " + message, Kind: ErrorPermission}
+}
+
+// applySandbox envolve os builtins que saem do interpretador com a
+// verificação das regras e troca os desativados por funções que sempre
+// falham. Nomes desconhecidos em Disable são um erro de configuração.
+func (i *Interpreter) applySandbox(s *Sandbox) error {
+	guard := func(name, module string, fn *BuiltinFunction) *BuiltinFunction {
+		rule, ok := sandboxRules[name]
+		if !ok {
+			rule, ok = sandboxRules[module]
+		}
+		if !ok {
+			return fn
+		}
+		return &BuiltinFunction{
+			ArityValue: fn.ArityValue,
+			CallFunc: func(i *Interpreter, args []any) any {
+				if message := rule(s, name, args); message != "" {
+					panic(permissionError(message))
+				}
+				return fn.CallFunc(i, args)
+			},
+		}
+	}
+	disabled := func(name string, fn *BuiltinFunction) *BuiltinFunction {
+		return &BuiltinFunction{
+			ArityValue: fn.ArityValue,
+			CallFunc: func(i *Interpreter, args []any) any {
+				panic(permissionError(name + " is disabled in the sandbox."))
+			},
+		}
+	}
+
+	for name, value := range i.builtins.Values {
+		switch value := value.(type) {
+		case *BuiltinFunction:
+			i.builtins.Values[name] = guard(name, "", value)
+		case *MapInstance:
+			for key, entry := range value.Entries {
+				if fn, ok := entry.(*BuiltinFunction); ok {
+					value.Entries[key] = guard(name+"."+key, name, fn)
+				}
+			}
+		}
+	}
+
+	var unknown []string
+	for _, name := range s.Disable {
+		module, member, qualified := strings.Cut(name, ".")
+		switch value := i.builtins.Values[module].(type) {
+		case *BuiltinFunction:
+			if qualified {
+				unknown = append(unknown, name)
+				continue
+			}
+			i.builtins.Values[module] = disabled(name, value)
+		case *MapInstance:
+			if !qualified {
+				// o módulo continua existindo, para que "import" não procure
+				// um arquivo com o mesmo nome, mas sem nada utilizável
+				for key, entry := range value.Entries {
+					if fn, ok := entry.(*BuiltinFunction); ok {
+						value.Entries[key] = disabled(module+"."+key, fn)
+					} else {
+						delete(value.Entries, key)
+					}
+				}
+				continue
+			}
+			fn, ok := value.Entries[member].(*BuiltinFunction)
+			if !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+			value.Entries[member] = disabled(name, fn)
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("sandbox: unknown builtin(s) to disable: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// checkModuleAccess impede que um import leia arquivos fora das pastas de
+// módulos (projeto, nox_modules, NOXPATH e biblioteca padrão) e das pastas
+// de leitura do sandbox, por exemplo com "../../../etc/x.nox".
+func (i *Interpreter) checkModuleAccess(path string) *RuntimeError {
+	s := i.Runtime.Sandbox
+	if s == nil {
+		return nil
+	}
+	dirs := append(ModuleSearchPath(i.Runtime.WorkingDir, i.Runtime.WorkingDir), i.Runtime.WorkingDir)
+	dirs = append(dirs, s.ReadDirs...)
+	dirs = append(dirs, s.WriteDirs...)
+	if withinDirs(path, dirs) {
+		return nil
+	}
+	return permissionError(fmt.Sprintf("import cannot read '%s': outside the module and sandbox directories.", path))
+}
@@ -0,0 +1,261 @@
+package runtime
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runSandboxed executa source com o sandbox s e devolve a saída e o erro de
+// execução. dir é a pasta do script.
+func runSandboxed(t *testing.T, s *Sandbox, dir, source string) (string, error) {
+	t.Helper()
+	n := NewNox()
+	n.Sandbox = s
+	n.WorkingDir = dir
+	var out strings.Builder
+	n.Stdout, n.Stderr = &out, io.Discard
+	err := n.Run(source, NewInterpreter(n, false))
+	return strings.TrimSuffix(out.String(), "\n"), err
+}
+
+// permissionMessage devolve a mensagem de err se ele é um erro do sandbox.
+func permissionMessage(t *testing.T, err error) string {
+	t.Helper()
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Kind != ErrorPermission {
+		t.Fatalf("err = %v, want a permission error", err)
+	}
+	return runtimeErr.Message
+}
+
+func TestSandboxCapabilities(t *testing.T) {
+	t.Setenv("NOX_SANDBOX_TEST", "") // restaurada no fim, depois do os.setenv permitido
+	tests := []struct {
+		call       string
+		capability string
+		grant      func(*Sandbox)
+	}{
+		{`os.exit(1)`, "exit", func(s *Sandbox) { s.Exit = true }},
+		{`os.exec("true")`, "exec", func(s *Sandbox) { s.Exec = true }},
+		{`process.run("true")`, "exec", func(s *Sandbox) { s.Exec = true }},
+		{`os.getenv("HOME")`, "env", func(s *Sandbox) { s.Env = true }},
+		{`os.setenv("NOX_SANDBOX_TEST", "1")`, "env", func(s *Sandbox) { s.Env = true }},
+		{`os.chdir(".")`, "env", func(s *Sandbox) { s.Env = true }},
+		{`http.serve(-1)`, "net", func(s *Sandbox) { s.Network = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			dir := t.TempDir()
+			_, err := runSandboxed(t, &Sandbox{}, dir, tt.call+";")
+			name, _, _ := strings.Cut(tt.call, "(")
+			want := "Permission denied: " + name + " needs the '" + tt.capability + "' capability, which the sandbox does not grant."
+			if got := permissionMessage(t, err); got != want {
+				t.Errorf("error = %q, want %q", got, want)
+			}
+
+			// a negação é um erro comum, que '?' trata
+			out, err := runSandboxed(t, &Sandbox{}, dir, "print ?"+tt.call+";\nprint \"after\";")
+			if err != nil || out != "<nil>\nafter" {
+				t.Errorf("?%s = %q, %v; want nil and the script to continue", tt.call, out, err)
+			}
+
+			// com a capacidade, a chamada passa pelo sandbox
+			granted := &Sandbox{}
+			tt.grant(granted)
+			if _, err := runSandboxed(t, granted, dir, tt.call+";"); err != nil {
+				var runtimeErr *RuntimeError
+				if errors.As(err, &runtimeErr) && runtimeErr.Kind == ErrorPermission {
+					t.Errorf("granted call denied: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSandboxOpenModes(t *testing.T) {
+	readDir, writeDir, outside := t.TempDir(), t.TempDir(), t.TempDir()
+	for _, dir := range []string{readDir, writeDir, outside} {
+		if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &Sandbox{ReadDirs: []string{readDir}, WriteDirs: []string{writeDir}}
+	tests := []struct {
+		dir    string
+		mode   string
+		denied string // ação negada; vazio quando a chamada é permitida
+	}{
+		{readDir, "r", ""},
+		{readDir, "w", "write"},
+		{readDir, "a", "write"},
+		{readDir, "r+", "write"},
+		{readDir, "x", "write"},
+		{writeDir, "r", ""},
+		{writeDir, "w", ""},
+		{writeDir, "a", ""},
+		{outside, "r", "read"},
+		{outside, "w", "write"},
+	}
+	for _, tt := range tests {
+		path := filepath.ToSlash(filepath.Join(tt.dir, "f.txt"))
+		_, err := runSandboxed(t, s, t.TempDir(), `let f = open("`+path+`", "`+tt.mode+`");
+f.close();`)
+		if tt.denied == "" {
+			if err != nil {
+				t.Errorf("open(%s, %q) = %v, want it allowed", path, tt.mode, err)
+			}
+			continue
+		}
+		want := "Permission denied: open cannot " + tt.denied + " '" + path + "': outside the directories allowed by the sandbox."
+		if got := permissionMessage(t, err); got != want {
+			t.Errorf("open(%s, %q) error = %q, want %q", path, tt.mode, got, want)
+		}
+	}
+}
+
+func TestSandboxPaths(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "allowed")
+	secret := filepath.Join(root, "secret")
+	for _, dir := range []string{allowed, secret, filepath.Join(allowed, "sub")} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secret, filepath.Join(allowed, "link")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if err := os.Symlink(filepath.Join(allowed, "sub"), filepath.Join(secret, "back")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		within bool
+	}{
+		{allowed, true},
+		{filepath.Join(allowed, "file.txt"), true},
+		{filepath.Join(allowed, "sub", "new", "file.txt"), true}, // ainda não existe
+		{filepath.Join(allowed, "sub", "..", "file.txt"), true},
+		{filepath.Join(allowed, "..", "secret", "file.txt"), false},
+		{allowed + "/../secret/file.txt", false},
+		{allowed + "-other/file.txt", false}, // prefixo do nome não basta
+		{root, false},
+		{filepath.Join(allowed, "link", "file.txt"), false}, // link para fora
+		{filepath.Join(allowed, "link"), false},
+		{filepath.Join(secret, "back", "file.txt"), true}, // link para dentro
+	}
+	for _, tt := range tests {
+		if got := withinDirs(tt.path, []string{allowed}); got != tt.within {
+			t.Errorf("withinDirs(%s) = %v, want %v", tt.path, got, tt.within)
+		}
+	}
+
+	// a pasta permitida também pode ser um link
+	linkedDir := filepath.Join(root, "linked")
+	if err := os.Symlink(allowed, linkedDir); err != nil {
+		t.Fatal(err)
+	}
+	if !withinDirs(filepath.Join(allowed, "file.txt"), []string{linkedDir}) {
+		t.Error("a path inside the target of a linked directory was rejected")
+	}
+	if got := resolvePath(filepath.Join(linkedDir, "sub", "missing", "f")); got != filepath.Join(resolvePath(allowed), "sub", "missing", "f") {
+		t.Errorf("resolvePath through a link = %s", got)
+	}
+
+	s := &Sandbox{ReadDirs: []string{allowed}}
+	message := s.checkPath("os.listdir", []any{filepath.Join(allowed, "link")}, 0, false)
+	if !strings.Contains(message, "os.listdir cannot read") {
+		t.Errorf("checkPath through a link = %q, want a denial", message)
+	}
+	if message := s.checkPath("os.listdir", []any{1.0}, 0, false); message != "" {
+		t.Errorf("checkPath with a non-string argument = %q, want it left to the builtin", message)
+	}
+}
+
+func TestSandboxDisable(t *testing.T) {
+	t.Setenv("NOX_SANDBOX_TEST", "")
+	s := &Sandbox{Env: true, Disable: []string{"math", "os.getenv", "len"}}
+	dir := t.TempDir()
+	tests := []struct {
+		call string
+		want string // mensagem do erro; vazio quando a chamada funciona
+	}{
+		{`math.sqrt(4)`, "Permission denied: math.sqrt is disabled in the sandbox."},
+		{`os.getenv("HOME")`, "Permission denied: os.getenv is disabled in the sandbox."},
+		{`len("abc")`, "Permission denied: len is disabled in the sandbox."},
+		{`os.setenv("NOX_SANDBOX_TEST", "1")`, ""},
+		{`range(2)`, ""},
+	}
+	for _, tt := range tests {
+		_, err := runSandboxed(t, s, dir, tt.call+";")
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s = %v, want it allowed", tt.call, err)
+			}
+			continue
+		}
+		if got := permissionMessage(t, err); got != tt.want {
+			t.Errorf("%s error = %q, want %q", tt.call, got, tt.want)
+		}
+	}
+
+	// um módulo desativado continua existindo, mas sem valores
+	if out, err := runSandboxed(t, s, dir, `print ?math.pi;`); err != nil || out != "<nil>" {
+		t.Errorf("math.pi = %q, %v; want nothing", out, err)
+	}
+}
+
+func TestSandboxDisableUnknown(t *testing.T) {
+	s := &Sandbox{Disable: []string{"nope", "os.nope", "len.x", "math"}}
+	_, err := runSandboxed(t, s, t.TempDir(), `print 1;`)
+	want := "sandbox: unknown builtin(s) to disable: len.x, nope, os.nope"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
+
+func TestSandboxModuleAccess(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	other := filepath.Join(root, "other")
+	for _, dir := range []string{project, filepath.Join(project, "lib"), other} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{filepath.Join(project, "lib", "inside.nox"), filepath.Join(other, "outside.nox")} {
+		if err := os.WriteFile(path, []byte("export let value = 1;\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := runSandboxed(t, &Sandbox{}, project, `import "lib/inside.nox" as m;
+print m.value;`)
+	if err != nil || out != "1" {
+		t.Errorf("import inside the project = %q, %v", out, err)
+	}
+
+	_, err = runSandboxed(t, &Sandbox{}, project, `import "../other/outside.nox" as m;`)
+	want := "Permission denied: import cannot read '" + filepath.Join(other, "outside.nox") + "': outside the module and sandbox directories."
+	if got := permissionMessage(t, err); got != want {
+		t.Errorf("import outside = %q, want %q", got, want)
+	}
+
+	out, err = runSandboxed(t, &Sandbox{ReadDirs: []string{other}}, project, `import "../other/outside.nox" as m;
+print m.value;`)
+	if err != nil || out != "1" {
+		t.Errorf("import from a read directory = %q, %v", out, err)
+	}
+
+	// sem sandbox, nada é verificado
+	n := NewNox()
+	n.WorkingDir = project
+	if err := NewInterpreter(n, false).checkModuleAccess(filepath.Join(other, "outside.nox")); err != nil {
+		t.Errorf("checkModuleAccess without a sandbox = %v", err)
+	}
+}
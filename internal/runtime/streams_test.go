@@ -0,0 +1,143 @@
+package runtime
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureProcess troca os.Stdout e os.Stderr por pipes até a função
+// devolvida ser chamada, que devolve o que foi escrito neles. Com os
+// streams de Nox configurados, nada deve chegar ao processo.
+func captureProcess(t *testing.T) func() (stdout, stderr string) {
+	t.Helper()
+	capture := func(target **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		previous := *target
+		*target = w
+		data := make(chan string)
+		go func() {
+			b, _ := io.ReadAll(r)
+			r.Close()
+			data <- string(b)
+		}()
+		return func() string {
+			*target = previous
+			w.Close()
+			return <-data
+		}
+	}
+	stdout, stderr := capture(&os.Stdout), capture(&os.Stderr)
+	return func() (string, string) { return stdout(), stderr() }
+}
+
+// streams são os buffers configurados como Stdout e Stderr de um Nox.
+type streams struct {
+	out, err strings.Builder
+}
+
+func newStreamsNox(in string) (*Nox, *streams) {
+	n := NewNox()
+	s := &streams{}
+	n.Stdout, n.Stderr, n.Stdin = &s.out, &s.err, strings.NewReader(in)
+	return n, s
+}
+
+func TestExecFileStreams(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		stdout string
+		stderr []string // trechos esperados em Stderr
+	}{
+		{"print and eprint", "print \"out\";\neprint(\"err\");\n", "out\n", []string{"Running file:", "err\n"}},
+		{"traceback", "print \"before\";\nlet x = nil;\nprint x.y;\n", "before\n",
+			[]string{"Traceback (most recent call last):", "streams.nox:3 in <script>", "RuntimeError"}},
+		{"syntax error", "print (1;\n", "", []string{"Expect ')' after expression."}},
+		{"resolver error", "return 1;\n", "", []string{"Cannot return from top-level code."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "streams.nox")
+			if err := os.WriteFile(path, []byte(tt.source), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			n, s := newStreamsNox("")
+			process := captureProcess(t)
+			n.ExecFile(path)
+			if stdout, stderr := process(); stdout != "" || stderr != "" {
+				t.Errorf("written to the process: stdout %q, stderr %q", stdout, stderr)
+			}
+			if s.out.String() != tt.stdout {
+				t.Errorf("Stdout = %q, want %q", s.out.String(), tt.stdout)
+			}
+			for _, want := range tt.stderr {
+				if !strings.Contains(s.err.String(), want) {
+					t.Errorf("Stderr = %q, want %q", s.err.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestPromptStreams(t *testing.T) {
+	n, s := newStreamsNox("print 1 + 1;\n\nlet x = ;\n\nprint missing;\n\nquit\n")
+	process := captureProcess(t)
+	err := n.RunPrompt()
+	if stdout, stderr := process(); stdout != "" || stderr != "" {
+		t.Errorf("written to the process: stdout %q, stderr %q", stdout, stderr)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := s.out.String()
+	for _, want := range []string{"Welcome to Nox!", "2\x1b[0m\n", "Exiting Nox.\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Stdout = %q, want %q", out, want)
+		}
+	}
+	errOut := s.err.String()
+	for _, want := range []string{"Expect expression.", "Undefined variable"} {
+		if !strings.Contains(errOut, want) {
+			t.Errorf("Stderr = %q, want %q", errOut, want)
+		}
+		if strings.Contains(out, want) {
+			t.Errorf("error %q written to Stdout", want)
+		}
+	}
+}
+
+func TestPromptEndOfInputAndExit(t *testing.T) {
+	n, s := newStreamsNox("print 1;\n")
+	if err := n.RunPrompt(); err != nil || !strings.HasSuffix(s.out.String(), ">> Exiting Nox.\n") {
+		t.Errorf("end of input = %v, Stdout %q", err, s.out.String())
+	}
+
+	n, _ = newStreamsNox("os.exit(3);\n\nprint 1;\n\n")
+	var exit *ExitError
+	if err := n.RunPrompt(); !errors.As(err, &exit) || exit.Code != 3 {
+		t.Errorf("os.exit in the REPL = %v, want *ExitError with code 3", err)
+	}
+}
+
+// Streams nil usam os do processo.
+func TestNilStreams(t *testing.T) {
+	n := NewNox()
+	n.Stdout, n.Stderr = nil, nil
+	process := captureProcess(t)
+	n.Run("print \"out\";\neprint(\"err\");\nlet x = nil;\nprint x.y;", NewInterpreter(n, false))
+	stdout, stderr := process()
+	if stdout != "out\n" {
+		t.Errorf("stdout = %q, want the print", stdout)
+	}
+	if !strings.HasPrefix(stderr, "err\n") || !strings.Contains(stderr, "Traceback") {
+		t.Errorf("stderr = %q, want eprint and the traceback", stderr)
+	}
+}
@@ -0,0 +1,352 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/MichelLacerda/nox/internal/ast"
+	"github.com/MichelLacerda/nox/internal/diagnostic"
+	"github.com/MichelLacerda/nox/internal/signal"
+)
+
+// testFailed interrompe o teste com uma falha de asserção. O último
+// argumento opcional das asserções é uma mensagem, que precede o detalhe.
+func testFailed(i *Interpreter, args []any, messageIndex int, detail string) {
+	if messageIndex < len(args) && args[messageIndex] != nil {
+		detail = i.stringify(args[messageIndex]) + ": " + detail
+	}
+	panic(&RuntimeError{Message: detail, Kind: ErrorAssertion})
+}
+
+func testArgs(i *Interpreter, name string, args []any, min, max int) {
+	if len(args) < min || len(args) > max {
+		i.Runtime.ReportRuntimeError(nil, fmt.Sprintf("test.%s expects %d to %d arguments, got %d.", name, min, max, len(args)))
+	}
+}
+
+// NewTestModule cria o módulo "test", com as asserções usadas pelo "nox test".
+func NewTestModule() *MapInstance {
+	return NewMapInstance(map[string]any{
+		"equal": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				testArgs(i, "equal(actual, expected, message?)", args, 2, 3)
+				if !i.isEqual(args[0], args[1]) {
+					testFailed(i, args, 2, fmt.Sprintf("expected %s, got %s", Repr(args[1]), Repr(args[0])))
+				}
+				return nil
+			},
+		},
+		"not_equal": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				testArgs(i, "not_equal(actual, unexpected, message?)", args, 2, 3)
+				if i.isEqual(args[0], args[1]) {
+					testFailed(i, args, 2, fmt.Sprintf("expected a value other than %s", Repr(args[1])))
+				}
+				return nil
+			},
+		},
+		"ok": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				testArgs(i, "ok(condition, message?)", args, 1, 2)
+				if !i.isTruthy(args[0]) {
+					testFailed(i, args, 1, fmt.Sprintf("expected a truthy value, got %s", Repr(args[0])))
+				}
+				return nil
+			},
+		},
+		"approx": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				testArgs(i, "approx(actual, expected, tolerance?, message?)", args, 2, 4)
+				actual, ok1 := args[0].(float64)
+				expected, ok2 := args[1].(float64)
+				if !ok1 || !ok2 {
+					i.Runtime.ReportRuntimeError(nil, "test.approx(actual, expected) expects numbers.")
+				}
+				tolerance := 1e-9
+				if len(args) > 2 && args[2] != nil {
+					t, ok := args[2].(float64)
+					if !ok {
+						i.Runtime.ReportRuntimeError(nil, "test.approx tolerance must be a number.")
+					}
+					tolerance = t
+				}
+				if math.Abs(actual-expected) > tolerance {
+					testFailed(i, args, 3, fmt.Sprintf("expected %v ± %v, got %v", expected, tolerance, actual))
+				}
+				return nil
+			},
+		},
+		"raises": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				testArgs(i, "raises(fn, contains?)", args, 1, 2)
+				fn, ok := args[0].(Callable)
+				if !ok {
+					i.Runtime.ReportRuntimeError(nil, "test.raises(fn) expects a function.")
+				}
+				err := i.catchRuntimeError(func() { fn.Call(i, []any{}) })
+				if err == nil {
+					testFailed(i, nil, 0, "expected an error, but none was raised")
+				}
+				if len(args) > 1 {
+					contains, ok := args[1].(string)
+					if !ok {
+						i.Runtime.ReportRuntimeError(nil, "test.raises(fn, contains) expects a string.")
+					}
+					if !strings.Contains(err.Message, contains) {
+						testFailed(i, nil, 0, fmt.Sprintf("expected an error containing %q, got %q", contains, err.Message))
+					}
+				}
+				return err.Message
+			},
+		},
+		"fail": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				testArgs(i, "fail(message?)", args, 0, 1)
+				testFailed(i, args, 0, "failed")
+				return nil
+			},
+		},
+		"skip": &BuiltinFunction{
+			ArityValue: -1,
+			CallFunc: func(i *Interpreter, args []any) any {
+				testArgs(i, "skip(reason?)", args, 0, 1)
+				reason := "skipped"
+				if len(args) > 0 {
+					reason = i.stringify(args[0])
+				}
+				panic(&RuntimeError{Message: reason, Kind: ErrorSkip})
+			},
+		},
+	})
+}
+
+// catchRuntimeError executa fn e devolve o erro de execução que ela gerar.
+// Pulos de teste e erros de limite não são capturados.
+func (i *Interpreter) catchRuntimeError(fn func()) (err *RuntimeError) {
+	environment := i.environment
+	frames := len(i.frames)
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeErr, ok := r.(*RuntimeError)
+			if !ok || runtimeErr.Kind == ErrorSkip || runtimeErr.Kind.IsLimit() {
+				panic(r)
+			}
+			i.captureStack(runtimeErr)
+			i.environment = environment
+			i.frames = i.frames[:frames]
+			i.endCall() // a chamada que falhou não está mais em andamento
+			err = runtimeErr
+		}
+	}()
+	fn()
+	return nil
+}
+
+// TestStatus é o resultado de um teste.
+type TestStatus int
+
+const (
+	TestPassed TestStatus = iota
+	TestFailed
+	TestErrored
+	TestSkipped
+)
+
+func (s TestStatus) String() string {
+	switch s {
+	case TestPassed:
+		return "PASS"
+	case TestFailed:
+		return "FAIL"
+	case TestErrored:
+		return "ERROR"
+	}
+	return "SKIP"
+}
+
+// TestResult é o resultado de um teste. Erros do próprio arquivo (sintaxe
+// ou código de topo) aparecem como um resultado sem nome.
+type TestResult struct {
+	File      string
+	Name      string
+	Status    TestStatus
+	Message   string // motivo da falha, do erro ou do skip
+	Location  string // arquivo:linha onde o teste parou
+	Traceback string
+	Duration  time.Duration
+}
+
+// TestOptions configura RunTests.
+type TestOptions struct {
+	Filter   *regexp.Regexp // só executa testes cujo nome casa; nil executa todos
+	Coverage *Coverage      // quando definido, soma a cobertura dos testes
+}
+
+// Funções especiais dos arquivos de teste
+const (
+	testPrefix   = "test_"
+	testSetup    = "setup"
+	testTeardown = "teardown"
+)
+
+// RunTests executa as funções test_* de um arquivo, na ordem em que foram
+// declaradas. Cada teste roda isolado: o arquivo é carregado de novo em um
+// interpretador próprio, então variáveis globais e módulos importados não
+// passam de um teste para outro. Se o arquivo declara setup e teardown,
+// elas rodam antes e depois de cada teste; teardown roda mesmo quando o
+// teste falha.
+func RunTests(path string, options TestOptions) []TestResult {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return []TestResult{{File: path, Status: TestErrored, Message: err.Error()}}
+	}
+
+	statements, diags := ParseSource(path, string(source))
+	if len(diags) > 0 {
+		return []TestResult{{File: path, Status: TestErrored, Message: diags.Render(string(source))}}
+	}
+
+	var results []TestResult
+	for _, name := range testNames(statements) {
+		if options.Filter != nil && !options.Filter.MatchString(name) {
+			continue
+		}
+		result := runTest(path, string(source), name, options)
+		results = append(results, result)
+		if result.Name == "" {
+			break // o arquivo não carrega: os outros testes falhariam igual
+		}
+	}
+	return results
+}
+
+// testNames lista as funções de teste declaradas no topo do arquivo.
+func testNames(statements []ast.Stmt) []string {
+	var names []string
+	for _, stmt := range statements {
+		if export, ok := stmt.(*ast.ExportStmt); ok {
+			stmt = export.Declaration
+		}
+		if fn, ok := stmt.(*ast.FunctionStmt); ok && strings.HasPrefix(fn.Name.Lexeme, testPrefix) {
+			names = append(names, fn.Name.Lexeme)
+		}
+	}
+	return names
+}
+
+func runTest(path, source, name string, options TestOptions) (result TestResult) {
+	start := time.Now()
+	result = TestResult{File: path, Name: name}
+	defer func() { result.Duration = time.Since(start) }()
+
+	n := NewNox()
+	n.Args = []string{path}
+	n.Coverage = options.Coverage
+	if absDir, err := filepath.Abs(filepath.Dir(path)); err == nil {
+		n.WorkingDir = absDir
+	}
+
+	interpreter := NewInterpreter(n, false)
+	interpreter.resetStack("<script>", path)
+	if n.Coverage != nil {
+		interpreter.AttachCoverage(n.Coverage)
+	}
+
+	defer n.enterScript(path)()
+	if err := n.run(source, interpreter); err != nil {
+		result.Name = ""
+		result.Status = TestErrored
+		switch err := err.(type) {
+		case diagnostic.List:
+			result.Message = err.Render(source)
+		case *RuntimeError:
+			result.fromError(err)
+			result.Status = TestErrored
+		default:
+			result.Message = err.Error()
+		}
+		return result
+	}
+
+	call := func(name string) *RuntimeError {
+		fn, ok := interpreter.globals.Values[name].(*Function)
+		if !ok {
+			return nil
+		}
+		if fn.Arity() != 0 {
+			return &RuntimeError{Token: fn.Declaration.Name, Message: fmt.Sprintf("%s must not take parameters.", name)}
+		}
+		return interpreter.callTest(fn)
+	}
+
+	if err := call(testSetup); err != nil {
+		result.fromError(err)
+		if result.Status == TestFailed {
+			result.Status = TestErrored // falhas no setup não são do teste
+		}
+		return result
+	}
+	if err := call(name); err != nil {
+		result.fromError(err)
+	}
+	if err := call(testTeardown); err != nil && result.Status == TestPassed {
+		result.fromError(err)
+		result.Status = TestErrored
+	}
+	return result
+}
+
+// callTest chama uma função sem argumentos, devolvendo o erro que a
+// interrompeu. break e continue soltos apenas encerram a função.
+func (i *Interpreter) callTest(fn *Function) (err *RuntimeError) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case *RuntimeError:
+				i.captureStack(r)
+				if len(r.Stack) > 1 {
+					r.Stack = r.Stack[1:] // o frame raiz não chamou o teste
+				}
+				err = r
+			case *ExitError:
+				// o teste não pode encerrar o "nox test"
+				err = &RuntimeError{Message: fmt.Sprintf("%s called os.exit(%d).", fn.Declaration.Name.Lexeme, r.Code)}
+			case signal.BreakSignal, signal.ContinueSignal:
+			default:
+				panic(r)
+			}
+		}
+	}()
+	fn.Call(i, []any{})
+	return nil
+}
+
+func (r *TestResult) fromError(err *RuntimeError) {
+	switch err.Kind {
+	case ErrorAssertion:
+		r.Status = TestFailed
+	case ErrorSkip:
+		r.Status = TestSkipped
+	default:
+		r.Status = TestErrored
+	}
+	r.Message = err.Message
+	if len(err.Stack) > 0 {
+		frame := err.Stack[len(err.Stack)-1]
+		r.Location = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+	}
+	if r.Status != TestSkipped {
+		r.Traceback = err.Traceback()
+	}
+}
@@ -0,0 +1,240 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// writeTestFile grava source como um arquivo de teste numa pasta temporária.
+// "LOG" na fonte vira o caminho de um arquivo de log na mesma pasta, que os
+// testes usam para registrar a ordem das chamadas.
+func writeTestFile(t *testing.T, source string) (path, log string) {
+	t.Helper()
+	dir := t.TempDir()
+	path = filepath.Join(dir, "example_test.nox")
+	log = filepath.Join(dir, "calls.log")
+	source = strings.ReplaceAll(source, "LOG", filepath.ToSlash(log))
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, log
+}
+
+const statusTests = `import "test" as test;
+
+func test_pass() {
+    test.equal(1 + 1, 2);
+}
+
+func test_fail() {
+    test.equal(1 + 1, 3, "sum");
+}
+
+func test_error() {
+    let x = nil;
+    return x.field;
+}
+
+func test_skip() {
+    test.skip("not ready");
+}
+
+func test_exit() {
+    os.exit(3);
+}
+
+func helper() {
+    test.fail();
+}
+`
+
+func TestRunTestsStatus(t *testing.T) {
+	path, _ := writeTestFile(t, statusTests)
+	results := RunTests(path, TestOptions{})
+
+	want := []struct {
+		name     string
+		status   TestStatus
+		message  string
+		location string
+	}{
+		{"test_pass", TestPassed, "", ""},
+		{"test_fail", TestFailed, "sum: expected 3, got 2", path + ":8"},
+		{"test_error", TestErrored, "Only instances, lists, dicts, or modules have properties.", path + ":13"},
+		{"test_skip", TestSkipped, "not ready", path + ":17"},
+		{"test_exit", TestErrored, "test_exit called os.exit(3).", ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for k, w := range want {
+		got := results[k]
+		if got.Name != w.name || got.Status != w.status {
+			t.Errorf("result %d = %s %s, want %s %s", k, got.Name, got.Status, w.name, w.status)
+			continue
+		}
+		if got.Message != w.message {
+			t.Errorf("%s message = %q, want %q", w.name, got.Message, w.message)
+		}
+		if got.Location != w.location {
+			t.Errorf("%s location = %q, want %q", w.name, got.Location, w.location)
+		}
+		if (got.Traceback != "") != (w.status == TestFailed || w.status == TestErrored) {
+			t.Errorf("%s traceback = %q", w.name, got.Traceback)
+		}
+	}
+}
+
+func TestRunTestsFilter(t *testing.T) {
+	path, _ := writeTestFile(t, statusTests)
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"pass", []string{"test_pass"}},
+		{"^test_(fail|skip)$", []string{"test_fail", "test_skip"}},
+		{"e", []string{"test_pass", "test_fail", "test_error", "test_skip", "test_exit"}},
+		{"helper", nil},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, result := range RunTests(path, TestOptions{Filter: regexp.MustCompile(tt.pattern)}) {
+			got = append(got, result.Name)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("-run %s = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// setup e teardown rodam em volta de cada teste, teardown mesmo quando o
+// teste falha; cada teste recarrega o arquivo, então o topo roda de novo.
+func TestRunTestsSetupTeardown(t *testing.T) {
+	path, log := writeTestFile(t, `import "test" as test;
+
+func note(text) {
+    let f = open("LOG", "a");
+    f.write(text + " ");
+    f.close();
+}
+
+note("load");
+let counter = 0;
+
+func setup() {
+    counter = counter + 1;
+    note("setup");
+}
+
+func teardown() {
+    note("teardown");
+}
+
+func test_first() {
+    test.equal(counter, 1);
+    note("first");
+}
+
+func test_second() {
+    note("second");
+    test.equal(counter, 2);
+}
+`)
+	results := RunTests(path, TestOptions{})
+	if len(results) != 2 || results[0].Status != TestPassed || results[1].Status != TestFailed {
+		t.Fatalf("results = %+v, want first passing and second failing", results)
+	}
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "load setup first teardown load setup second teardown"
+	if got := strings.Join(strings.Fields(string(data)), " "); got != want {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestRunTestsSetupAndTeardownErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   TestStatus
+	}{
+		{"assertion in setup", `import "test" as test;
+func setup() { test.fail("setup"); }
+func test_a() {}`, TestErrored},
+		{"error in teardown", `func teardown() { let x = nil; return x.y; }
+func test_a() {}`, TestErrored},
+		{"setup with parameters", `func setup(x) {}
+func test_a() {}`, TestErrored},
+		{"skip in setup", `import "test" as test;
+func setup() { test.skip(); }
+func test_a() {}`, TestSkipped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writeTestFile(t, tt.source)
+			results := RunTests(path, TestOptions{})
+			if len(results) != 1 || results[0].Status != tt.want {
+				t.Errorf("results = %+v, want %s", results, tt.want)
+			}
+		})
+	}
+}
+
+// Erros no topo do arquivo viram um único resultado sem nome.
+func TestRunTestsFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		message string
+	}{
+		{"syntax error", "func test_a() {\n", "Expect '}' after block."},
+		{"top-level error", "let x = nil;\nprint x.y;\nfunc test_a() {}\nfunc test_b() {}", "Only instances, lists, dicts, or modules have properties."},
+		{"top-level exit", "os.exit(2);\nfunc test_a() {}", "script exited with code 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writeTestFile(t, tt.source)
+			results := RunTests(path, TestOptions{})
+			if len(results) != 1 || results[0].Name != "" || results[0].Status != TestErrored {
+				t.Fatalf("results = %+v, want one file error", results)
+			}
+			if !strings.Contains(results[0].Message, tt.message) {
+				t.Errorf("message = %q, want %q", results[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+// Cada teste carrega o arquivo num Nox próprio, então rotas registradas no
+// topo não entram em conflito entre um teste e outro.
+func TestRunTestsTopLevelRoutes(t *testing.T) {
+	path, _ := writeTestFile(t, `import "test" as test;
+func handle(req, res) {
+    return "ok";
+}
+http.route("/test/tests", handle);
+
+func test_first() {
+    test.ok(true);
+}
+
+func test_second() {
+    test.ok(true);
+}
+`)
+	results := RunTests(path, TestOptions{})
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want two tests", results)
+	}
+	for _, result := range results {
+		if result.Status != TestPassed {
+			t.Errorf("%s = %s: %s", result.Name, result.Status, result.Message)
+		}
+	}
+}
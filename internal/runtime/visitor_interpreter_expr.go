@@ -14,7 +14,7 @@ func (i *Interpreter) VisitAssignExpr(expr *ast.AssignExpr) any {
 	if d, ok := i.locals[expr]; ok {
 		i.environment.AssignAt(d, expr.Name, value)
 	} else {
-		i.globals.Assign(expr.Name, value)
+		i.environment.globals.Assign(expr.Name, value)
 	}
 
 	return nil
@@ -40,7 +40,10 @@ func (i *Interpreter) VisitCallExpr(expr *ast.CallExpr) any {
 		return nil
 	}
 
-	return callable.Call(i, arguments)
+	i.markCall(expr)
+	result := callable.Call(i, arguments)
+	i.endCall()
+	return result
 }
 
 func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) any {
@@ -84,7 +87,7 @@ func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) any {
 		)
 		return nil
 	case *EnvironmentWrapper:
-		if val, ok := obj.Env.Values[expr.Name.Lexeme]; ok {
+		if val, ok := obj.Export(expr.Name.Lexeme); ok {
 			return val
 		}
 		i.Runtime.ReportRuntimeError(
@@ -103,6 +106,76 @@ func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) any {
 		)
 		return nil
 
+	case *RegexInstance:
+		if val := obj.Get(expr.Name); val != nil {
+			return val
+		}
+		i.Runtime.ReportRuntimeError(
+			expr.Name,
+			fmt.Sprintf("Undefined property '%s' for regex object.", expr.Name.Lexeme),
+		)
+		return nil
+
+	case *TimeInstance:
+		if val := obj.Get(expr.Name); val != nil {
+			return val
+		}
+		i.Runtime.ReportRuntimeError(
+			expr.Name,
+			fmt.Sprintf("Undefined property '%s' for time object.", expr.Name.Lexeme),
+		)
+		return nil
+
+	case *DurationInstance:
+		if val := obj.Get(expr.Name); val != nil {
+			return val
+		}
+		i.Runtime.ReportRuntimeError(
+			expr.Name,
+			fmt.Sprintf("Undefined property '%s' for duration object.", expr.Name.Lexeme),
+		)
+		return nil
+
+	case *ProcessInstance:
+		if val := obj.Get(expr.Name); val != nil {
+			return val
+		}
+		i.Runtime.ReportRuntimeError(
+			expr.Name,
+			fmt.Sprintf("Undefined property '%s' for process object.", expr.Name.Lexeme),
+		)
+		return nil
+
+	case *ArgParser:
+		if val := obj.Get(expr.Name); val != nil {
+			return val
+		}
+		i.Runtime.ReportRuntimeError(
+			expr.Name,
+			fmt.Sprintf("Undefined property '%s' for argparse object.", expr.Name.Lexeme),
+		)
+		return nil
+
+	case *BytesInstance:
+		if method := obj.Get(expr.Name); method != nil {
+			return method
+		}
+		i.Runtime.ReportRuntimeError(
+			expr.Name,
+			fmt.Sprintf("Undefined property '%s' for bytes object.", expr.Name.Lexeme),
+		)
+		return nil
+
+	case *StreamObject:
+		if method := obj.Get(expr.Name); method != nil {
+			return method
+		}
+		i.Runtime.ReportRuntimeError(
+			expr.Name,
+			fmt.Sprintf("Undefined property '%s' for %s.", expr.Name.Lexeme, obj.name),
+		)
+		return nil
+
 	case *WriterInstance:
 		if method := obj.Get(expr.Name); method != nil {
 			return method
@@ -143,12 +216,12 @@ func (i *Interpreter) VisitSetIndexExpr(expr *ast.SetIndexExpr) any {
 	case []any:
 		intIndex, ok := index.(float64)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, "List index must be a number.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "List index must be a number.")
 			return nil
 		}
 		idx := int(intIndex)
 		if idx < 0 || idx >= len(obj) {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, fmt.Sprintf("List index out of range: %d", idx))
+			i.Runtime.ReportRuntimeError(expr.Bracket, fmt.Sprintf("List index out of range: %d", idx))
 			return nil
 		}
 		obj[idx] = value
@@ -156,7 +229,7 @@ func (i *Interpreter) VisitSetIndexExpr(expr *ast.SetIndexExpr) any {
 	case map[string]any: // dicionário
 		key, ok := index.(string)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "{}"}, "Dictionary keys must be strings.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "Dictionary keys must be strings.")
 			return nil
 		}
 		obj[key] = value
@@ -164,12 +237,12 @@ func (i *Interpreter) VisitSetIndexExpr(expr *ast.SetIndexExpr) any {
 	case *ListInstance:
 		intIndex, ok := index.(float64)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, "List index must be a number.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "List index must be a number.")
 			return nil
 		}
 		idx := int(intIndex)
 		if idx < 0 || idx >= len(obj.Elements) {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, fmt.Sprintf("List index out of range: %d", idx))
+			i.Runtime.ReportRuntimeError(expr.Bracket, fmt.Sprintf("List index out of range: %d", idx))
 			return nil
 		}
 		obj.Elements[idx] = value
@@ -177,13 +250,13 @@ func (i *Interpreter) VisitSetIndexExpr(expr *ast.SetIndexExpr) any {
 	case *DictInstance:
 		key, ok := index.(string)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "{}"}, "Dictionary keys must be strings.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "Dictionary keys must be strings.")
 			return nil
 		}
 		obj.Entries[key] = value
 		return value
 	default:
-		i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, "Only lists and dictionaries support indexing.")
+		i.Runtime.ReportRuntimeError(expr.Bracket, "Only lists and dictionaries support indexing.")
 		return nil
 	}
 }
@@ -191,15 +264,18 @@ func (i *Interpreter) VisitSetIndexExpr(expr *ast.SetIndexExpr) any {
 func (i *Interpreter) VisitLogicalExpr(expr *ast.LogicalExpr) any {
 	left := i.evaluate(expr.Left)
 
+	shortCircuit := false
 	switch expr.Operator.Type {
 	case token.TokenType_OR:
-		if i.isTruthy(left) {
-			return left
-		}
+		shortCircuit = i.isTruthy(left)
 	case token.TokenType_AND:
-		if !i.isTruthy(left) {
-			return left
-		}
+		shortCircuit = !i.isTruthy(left)
+	}
+	if i.coverage != nil {
+		i.coverage.logical(expr, !shortCircuit)
+	}
+	if shortCircuit {
+		return left
 	}
 
 	return i.evaluate(expr.Right)
@@ -259,36 +335,36 @@ func (i *Interpreter) VisitIndexExpr(expr *ast.IndexExpr) any {
 	case []any:
 		intIndex, ok := index.(float64)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, "List index must be a number.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "List index must be a number.")
 			return nil
 		}
 		idx := int(intIndex)
 		if idx < 0 || idx >= len(obj) {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, fmt.Sprintf("List index out of range: %d", idx))
+			i.Runtime.ReportRuntimeError(expr.Bracket, fmt.Sprintf("List index out of range: %d", idx))
 			return nil
 		}
 		return obj[idx]
 	case map[string]any: // dicionário
 		key, ok := index.(string)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "{}"}, "Dictionary keys must be strings.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "Dictionary keys must be strings.")
 			return nil
 		}
 		val, exists := obj[key]
 		if !exists {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "{}"}, fmt.Sprintf("Key '%s' not found in dictionary.", key))
+			i.Runtime.ReportRuntimeError(expr.Bracket, fmt.Sprintf("Key '%s' not found in dictionary.", key))
 			return nil
 		}
 		return val
 	case *ListInstance:
 		intIndex, ok := index.(float64)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, "List index must be a number.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "List index must be a number.")
 			return nil
 		}
 		idx := int(intIndex)
 		if idx < 0 || idx >= len(obj.Elements) {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "[]"}, fmt.Sprintf("List index out of range: %d", idx))
+			i.Runtime.ReportRuntimeError(expr.Bracket, fmt.Sprintf("List index out of range: %d", idx))
 			return nil
 		}
 		return obj.Elements[idx]
@@ -296,17 +372,30 @@ func (i *Interpreter) VisitIndexExpr(expr *ast.IndexExpr) any {
 	case *DictInstance:
 		key, ok := index.(string)
 		if !ok {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "{}"}, "Dictionary keys must be strings.")
+			i.Runtime.ReportRuntimeError(expr.Bracket, "Dictionary keys must be strings.")
 			return nil
 		}
 		val, exists := obj.Entries[key]
 		if !exists {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: "{}"}, fmt.Sprintf("Key '%s' not found in dictionary.", key))
+			i.Runtime.ReportRuntimeError(expr.Bracket, fmt.Sprintf("Key '%s' not found in dictionary.", key))
 			return nil
 		}
 		return val
+
+	case *BytesInstance:
+		intIndex, ok := index.(float64)
+		if !ok {
+			i.Runtime.ReportRuntimeError(expr.Bracket, "Bytes index must be a number.")
+			return nil
+		}
+		idx := int(intIndex)
+		if idx < 0 || idx >= len(obj.Data) {
+			i.Runtime.ReportRuntimeError(expr.Bracket, fmt.Sprintf("Bytes index out of range: %d", idx))
+			return nil
+		}
+		return float64(obj.Data[idx])
 	default:
-		i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: ""}, "Only lists and dictionaries support indexing.")
+		i.Runtime.ReportRuntimeError(expr.Bracket, "Only lists and dictionaries support indexing.")
 		return nil
 
 	}
@@ -322,7 +411,7 @@ func (i *Interpreter) VisitDictExpr(expr *ast.DictExpr) any {
 		if keyStr, ok := key.(string); ok {
 			dict[keyStr] = value
 		} else {
-			i.Runtime.ReportRuntimeError(&token.Token{Type: token.TokenType_Unknown, Lexeme: ""}, "Dictionary keys must be strings.")
+			i.Runtime.ReportRuntimeError(expr.Brace, "Dictionary keys must be strings.")
 			return nil
 		}
 	}
@@ -330,17 +419,13 @@ func (i *Interpreter) VisitDictExpr(expr *ast.DictExpr) any {
 }
 
 func (i *Interpreter) VisitSafeExpr(expr *ast.SafeExpr) any {
-	defer func() {
-		if r := recover(); r != nil {
-			if _, ok := r.(*RuntimeError); ok {
-				// erro controlado — retorna nil silenciosamente
-				return
-			}
-			panic(r) // outros panics inesperados continuam
-		}
-	}()
-
-	return i.evaluate(expr.Expr)
+	// erro controlado — retorna nil silenciosamente, com escopo e pilha
+	// restaurados; outros panics inesperados continuam
+	var result any
+	if err := i.catchRuntimeError(func() { result = i.evaluate(expr.Expr) }); err != nil {
+		return nil
+	}
+	return result
 }
 
 func (i *Interpreter) VisitVariableExpr(expr *ast.VariableExpr) any {
@@ -375,6 +460,10 @@ func (i *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) any {
 	left := i.evaluate(expr.Left)
 	right := i.evaluate(expr.Right)
 
+	if result, ok := i.timeBinary(expr.Operator, left, right); ok {
+		return result
+	}
+
 	switch expr.Operator.Type {
 	case token.TokenType_PERCENT:
 		if !i.mustBeNumbers(expr.Operator, left, right) {
@@ -4,12 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/MichelLacerda/nox/internal/ast"
-	"github.com/MichelLacerda/nox/internal/parser"
-	"github.com/MichelLacerda/nox/internal/scanner"
 	"github.com/MichelLacerda/nox/internal/signal"
+	"github.com/MichelLacerda/nox/internal/token"
 )
 
 func (i *Interpreter) VisitExpressionStmt(stmt *ast.ExpressionStmt) any {
@@ -22,7 +22,8 @@ func (i *Interpreter) VisitExpressionStmt(stmt *ast.ExpressionStmt) any {
 
 func (i *Interpreter) VisitFunctionStmt(stmt *ast.FunctionStmt) any {
 	function := NewFunction(i.Runtime, stmt, i.environment, false)
-	i.environment.Define(stmt.Name.Lexeme, function)
+	function.File = i.currentFile
+	i.environment.Define(stmt.Name, function)
 	return nil
 }
 
@@ -44,7 +45,7 @@ func (i *Interpreter) VisitPrintStmt(stmt *ast.PrintStmt) any {
 		value := i.evaluate(expr)
 		parts = append(parts, i.stringify(value))
 	}
-	fmt.Println(strings.Join(parts, " "))
+	fmt.Fprintln(i.Runtime.stdout(), strings.Join(parts, " "))
 	return nil
 }
 
@@ -70,7 +71,7 @@ func (i *Interpreter) VisitVarStmt(stmt *ast.VarStmt) any {
 			i.Runtime.HadRuntimeError = false
 		}
 	}
-	i.environment.Define(stmt.Name.Lexeme, value)
+	i.environment.Define(stmt.Name, value)
 	return nil
 }
 
@@ -92,16 +93,17 @@ func (i *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) any {
 		}
 	}
 
-	i.environment.Define(stmt.Name.Lexeme, nil) // Define a classe antes de instanciá-la
+	i.environment.Define(stmt.Name, nil) // Define a classe antes de instanciá-la
 
 	if stmt.Superclass != nil {
 		i.environment = NewEnvironment(i.Runtime, i.environment) // Cria um novo ambiente para a classe
-		i.environment.Define("super", superclass)                // Define a variável 'super' no ambiente da classe
+		i.environment.DefineByName("super", superclass)          // Define a variável 'super' no ambiente da classe
 	}
 
 	methods := MethodType{}
 	for _, method := range stmt.Methods {
 		fn := NewFunction(i.Runtime, method, i.environment, method.Name.Lexeme == "init")
+		fn.File = i.currentFile
 		methods[method.Name.Lexeme] = fn
 	}
 
@@ -123,24 +125,60 @@ func (i *Interpreter) VisitContinueStmt(stmt *ast.ContinueStmt) any {
 
 func (i *Interpreter) VisitWithStmt(stmt *ast.WithStmt) any {
 	resource := i.evaluate(stmt.Resource)
+	closeFn := i.closeMethod(stmt, resource)
 	env := NewEnvironment(i.Runtime, i.environment)
-	env.Define(stmt.Alias.Lexeme, resource)
-
-	defer func() {
-		if file, ok := resource.(*FileObject); ok {
-			if closeFn := file.GetMethod("close"); closeFn != nil {
-				if callable, ok := closeFn.(Callable); ok {
+	env.Define(stmt.Alias, resource)
+
+	if closeFn != nil {
+		defer func() {
+			r := recover()
+			switch r.(type) {
+			case *RuntimeError, *ExitError:
+				// o erro do bloco prevalece sobre um erro ao fechar
+				func() {
 					defer func() { recover() }()
-					callable.Call(i, []any{})
-				}
+					closeFn.Call(i, []any{})
+				}()
+			default: // fim normal, return, break e continue
+				closeFn.Call(i, []any{})
 			}
-		}
-	}()
+			if r != nil {
+				panic(r)
+			}
+		}()
+	}
 
 	i.ExecuteBlock([]ast.Stmt{stmt.Body}, env)
 	return nil
 }
 
+// closeMethod devolve o método close() do recurso de um "with": arquivos,
+// instâncias de classes que o declaram e objetos builtin que o tenham.
+// Recursos sem close são aceitos e não são fechados.
+func (i *Interpreter) closeMethod(stmt *ast.WithStmt, resource any) Callable {
+	var method any
+	switch r := resource.(type) {
+	case HasMethods:
+		method = r.GetMethod("close")
+	case *Instance:
+		if value, ok := r.Fields["close"]; ok {
+			method = value
+		} else if fn, ok := r.Class.FindMethod("close"); ok {
+			method = fn.Bind(r)
+		}
+	case interface{ Get(*token.Token) any }:
+		method = r.Get(&token.Token{Lexeme: "close"})
+	}
+	if method == nil {
+		return nil
+	}
+	callable, ok := method.(Callable)
+	if !ok || callable.Arity() > 0 {
+		i.Runtime.ReportRuntimeError(stmt.Alias, "The close of a 'with' resource must be a method without parameters.")
+	}
+	return callable
+}
+
 // func (i *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) any {
 // 	for i.isTruthy(i.evaluate(stmt.Condition)) {
 // 		func() {
@@ -165,14 +203,26 @@ func (i *Interpreter) VisitWithStmt(stmt *ast.WithStmt) any {
 func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 	iterable := i.evaluate(stmt.Iterable)
 
+	if i.coverage != nil {
+		defer i.coverage.loop(stmt, i.coverage.count(stmt.Body))
+	}
+	// break sai do laço; sem isso ele subiria até Run e encerraria o script
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(signal.BreakSignal); !ok {
+				panic(r)
+			}
+		}
+	}()
+
 	switch coll := iterable.(type) {
 	case *ListInstance: // lista personalizada
 		for index, value := range coll.Elements {
 			env := NewEnvironment(i.Runtime, i.environment)
 			if stmt.IndexVar != nil {
-				env.Define(stmt.IndexVar.Lexeme, float64(index))
+				env.Define(stmt.IndexVar, float64(index))
 			}
-			env.Define(stmt.ValueVar.Lexeme, value)
+			env.Define(stmt.ValueVar, value)
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
@@ -195,9 +245,9 @@ func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 		for key, value := range coll.Entries {
 			env := NewEnvironment(i.Runtime, i.environment)
 			if stmt.IndexVar != nil {
-				env.Define(stmt.IndexVar.Lexeme, key)
+				env.Define(stmt.IndexVar, key)
 			}
-			env.Define(stmt.ValueVar.Lexeme, value)
+			env.Define(stmt.ValueVar, value)
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
@@ -220,9 +270,9 @@ func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 			env := NewEnvironment(i.Runtime, i.environment)
 
 			if stmt.IndexVar != nil {
-				env.Define(stmt.IndexVar.Lexeme, float64(index))
+				env.Define(stmt.IndexVar, float64(index))
 			}
-			env.Define(stmt.ValueVar.Lexeme, value)
+			env.Define(stmt.ValueVar, value)
 
 			func() {
 				defer func() {
@@ -247,9 +297,9 @@ func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 			env := NewEnvironment(i.Runtime, i.environment)
 
 			if stmt.IndexVar != nil {
-				env.Define(stmt.IndexVar.Lexeme, key)
+				env.Define(stmt.IndexVar, key)
 			}
-			env.Define(stmt.ValueVar.Lexeme, value)
+			env.Define(stmt.ValueVar, value)
 
 			func() {
 				defer func() {
@@ -273,9 +323,9 @@ func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 		for index, char := range coll.Value {
 			env := NewEnvironment(i.Runtime, i.environment)
 			if stmt.IndexVar != nil {
-				env.Define(stmt.IndexVar.Lexeme, float64(index))
+				env.Define(stmt.IndexVar, float64(index))
 			}
-			env.Define(stmt.ValueVar.Lexeme, string(char))
+			env.Define(stmt.ValueVar, string(char))
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
@@ -297,9 +347,9 @@ func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 		for index, char := range coll {
 			env := NewEnvironment(i.Runtime, i.environment)
 			if stmt.IndexVar != nil {
-				env.Define(stmt.IndexVar.Lexeme, float64(index))
+				env.Define(stmt.IndexVar, float64(index))
 			}
-			env.Define(stmt.ValueVar.Lexeme, string(char))
+			env.Define(stmt.ValueVar, string(char))
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
@@ -317,6 +367,34 @@ func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 			}()
 		}
 
+	case LineIterator: // arquivos e io.stdin: uma linha por volta
+		for index := 0; ; index++ {
+			line, ok := coll.NextLine(i)
+			if !ok {
+				break
+			}
+			env := NewEnvironment(i.Runtime, i.environment)
+			if stmt.IndexVar != nil {
+				env.Define(stmt.IndexVar, float64(index))
+			}
+			env.Define(stmt.ValueVar, line)
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						switch r.(type) {
+						case signal.BreakSignal:
+							panic(r) // repassa pro loop pai
+						case signal.ContinueSignal:
+							// ignora, continua a próxima linha
+						default:
+							panic(r) // repassa qualquer outro erro
+						}
+					}
+				}()
+				i.ExecuteBlock([]ast.Stmt{stmt.Body}, env)
+			}()
+		}
+
 	case bool: // for { ... } → loop infinito
 		if coll {
 			for {
@@ -348,74 +426,144 @@ func (i *Interpreter) VisitForInStmt(stmt *ast.ForInStmt) any {
 }
 
 func (i *Interpreter) VisitImportStmt(stmt *ast.ImportStmt) any {
-	path := stmt.Path.Literal.(string)
-	absPath := filepath.Join(i.Runtime.WorkingDir, path)
-	if !strings.HasSuffix(absPath, ".nox") {
-		absPath += ".nox"
+	module := i.loadModule(stmt.Path)
+
+	switch {
+	case stmt.Alias != nil:
+		i.environment.Define(stmt.Alias, module)
+	case stmt.Names != nil:
+		for _, name := range stmt.Names {
+			value, ok := moduleExport(module, name.Name.Lexeme)
+			if !ok {
+				i.Runtime.ReportRuntimeError(name.Name, fmt.Sprintf("Module '%s' has no export '%s'.", stmt.Path.Literal, name.Name.Lexeme))
+			}
+			i.environment.Define(name.Binding(), value)
+		}
+	default:
+		// sem alias, os nomes exportados vão direto para o escopo atual;
+		// builtins só são acessíveis pelo próprio nome
+		if wrapper, ok := module.(*EnvironmentWrapper); ok {
+			for name := range wrapper.Exports {
+				// o nome não aparece na fonte: o erro aponta o import
+				binding := *stmt.Path
+				binding.Lexeme = name
+				i.environment.Define(&binding, wrapper.Env.Values[name])
+			}
+		}
 	}
-	absPath, err := filepath.Abs(absPath)
+	return nil
+}
+
+// loadModule devolve o módulo de um import: um builtin, um módulo já
+// carregado ou um arquivo executado agora. O código de topo do arquivo
+// roda uma única vez, num escopo próprio; só os nomes declarados com
+// "export" ficam visíveis para quem importa.
+func (i *Interpreter) loadModule(pathToken *token.Token) any {
+	path := pathToken.Literal.(string)
+	if module, ok := i.builtinModule(path); ok {
+		return module
+	}
+
+	absPath, err := FindModule(path, i.importDir(), i.Runtime.WorkingDir)
 	if err != nil {
-		i.Runtime.ReportRuntimeError(stmt.Path, "Invalid import path.")
-		return nil
+		i.Runtime.ReportRuntimeError(pathToken, err.Error())
+	}
+	if err := i.checkModuleAccess(absPath); err != nil {
+		err.Token = pathToken
+		panic(err)
+	}
+	if module, ok := i.Runtime.Modules[absPath]; ok {
+		return module
 	}
 
-	if mod, ok := i.Runtime.Modules[absPath]; ok {
-		if stmt.Alias != nil {
-			i.environment.Define(stmt.Alias.Lexeme, mod)
-		} else if wrapper, ok := mod.(*EnvironmentWrapper); ok {
-			for name, val := range wrapper.Env.Values {
-				i.environment.Define(name, val)
-			}
+	modFile := displayPath(i.Runtime.WorkingDir, absPath)
+	if idx := slices.Index(i.Runtime.loading, absPath); idx >= 0 {
+		chain := []string{}
+		for _, loading := range i.Runtime.loading[idx:] {
+			chain = append(chain, displayPath(i.Runtime.WorkingDir, loading))
 		}
-		return nil
+		chain = append(chain, modFile)
+		i.Runtime.ReportRuntimeError(pathToken, "Circular import: "+strings.Join(chain, " -> ")+".")
 	}
 
-	// carrega o módulo normalmente
 	source, err := os.ReadFile(absPath)
 	if err != nil {
-		i.Runtime.ReportRuntimeError(stmt.Path, "Failed to read module: "+err.Error())
-		return nil
+		i.Runtime.ReportRuntimeError(pathToken, "Failed to read module: "+err.Error())
 	}
 
-	tokens, err := scanner.NewScanner([]rune(string(source))).ScanTokens()
-	if err != nil {
-		i.Runtime.ReportRuntimeError(stmt.Path, "Failed to tokenize module: "+err.Error())
-		return nil
+	stmts, diags := ParseSource(modFile, string(source))
+	if len(diags) > 0 {
+		i.Runtime.ReportRuntimeError(pathToken, "Parse error in module: "+diags[0].String())
 	}
 
-	stmts, err := parser.NewParser(tokens).Parse()
-	if err != nil {
-		i.Runtime.ReportRuntimeError(stmt.Path, "Parse error in module.")
-		return nil
-	}
+	modEnv := newGlobals(i.Runtime, i.builtins)
+
+	// Executa no escopo isolado, com um frame próprio para o traceback
+	func() {
+		prevEnv := i.environment
+		prevFile, prevDir := i.currentFile, i.currentDir
+		i.Runtime.loading = append(i.Runtime.loading, absPath)
+		i.markLine(pathToken.Line)
+		i.pushFrame("<module>", modFile, 0)
+		i.environment = modEnv
+		i.currentFile, i.currentDir = modFile, filepath.Dir(absPath)
+		defer func() {
+			i.popFrame()
+			i.environment = prevEnv
+			i.currentFile, i.currentDir = prevFile, prevDir
+			i.Runtime.loading = i.Runtime.loading[:len(i.Runtime.loading)-1]
+		}()
+		defer i.captureOnPanic()
+
+		resolver := NewResolver(i)
+		resolver.ResolveStatements(stmts)
+		if len(resolver.Errors) > 0 {
+			diags := syntaxDiagnostics(modFile, resolver.Errors)
+			i.Runtime.ReportRuntimeError(pathToken, "Resolve error in module: "+diags[0].String())
+		}
+		if i.coverage != nil {
+			i.coverage.register(absPath, stmts)
+		}
+		for _, stmt := range stmts {
+			i.execute(stmt)
+		}
+	}()
 
-	modEnv := NewEnvironment(i.Runtime, nil)
+	module := &EnvironmentWrapper{Env: modEnv, Exports: exportedNames(stmts)}
+	i.Runtime.Modules[absPath] = module
+	return module
+}
 
-	// Executa no escopo isolado
-	prevEnv := i.environment
-	i.environment = modEnv
-	resolver := NewResolver(i)
-	resolver.ResolveStatements(stmts)
+// exportedNames lista os nomes declarados com "export" no topo do módulo.
+func exportedNames(stmts []ast.Stmt) map[string]bool {
+	names := map[string]bool{}
 	for _, stmt := range stmts {
-		if export, ok := stmt.(*ast.ExportStmt); ok {
-			i.execute(export)
+		export, ok := stmt.(*ast.ExportStmt)
+		if !ok {
+			continue
 		}
-	}
-	i.environment = prevEnv
-
-	moduleObj := &EnvironmentWrapper{Env: modEnv}
-	i.Runtime.Modules[absPath] = moduleObj
-
-	// define no escopo original
-	if stmt.Alias != nil {
-		i.environment.Define(stmt.Alias.Lexeme, moduleObj)
-	} else {
-		for name, val := range modEnv.Values {
-			i.environment.Define(name, val)
+		switch decl := export.Declaration.(type) {
+		case *ast.FunctionStmt:
+			names[decl.Name.Lexeme] = true
+		case *ast.ClassStmt:
+			names[decl.Name.Lexeme] = true
+		case *ast.VarStmt:
+			names[decl.Name.Lexeme] = true
 		}
 	}
+	return names
+}
 
-	return nil
+// moduleExport busca um nome exportado por um módulo builtin ou de arquivo.
+func moduleExport(module any, name string) (any, bool) {
+	switch module := module.(type) {
+	case *EnvironmentWrapper:
+		return module.Export(name)
+	case *MapInstance:
+		value, ok := module.Entries[name]
+		return value, ok
+	}
+	return nil, false
 }
 
 func (i *Interpreter) VisitExportStmt(stmt *ast.ExportStmt) any {
@@ -14,7 +14,7 @@ func (r *Resolver) VisitVariableExpr(expr *ast.VariableExpr) any {
 	if !r.scopes.IsEmpty() {
 		scope, _ := r.scopes.Peek()
 		if declared, exists := scope[expr.Name.Lexeme]; exists && !declared {
-			r.interpreter.Runtime.ReportRuntimeError(expr.Name, "Cannot read local variable in its own initializer.")
+			r.errorToken(expr.Name, "Cannot read local variable in its own initializer.")
 		}
 	}
 
@@ -88,9 +88,9 @@ func (r *Resolver) VisitSetIndexExpr(expr *ast.SetIndexExpr) any {
 
 func (r *Resolver) VisitSuperExpr(expr *ast.SuperExpr) any {
 	if r.currentClass == ClassTypeNone {
-		r.interpreter.Runtime.ReportRuntimeError(expr.Keyword, "Cannot use 'super' outside of a class.")
+		r.errorToken(expr.Keyword, "Cannot use 'super' outside of a class.")
 	} else if r.currentClass != ClassTypeSubclass {
-		r.interpreter.Runtime.ReportRuntimeError(expr.Keyword, "Cannot use 'super' in a class with no superclass.")
+		r.errorToken(expr.Keyword, "Cannot use 'super' in a class with no superclass.")
 	}
 	r.ResolveLocalExpr(expr, expr.Keyword)
 	return nil
@@ -98,7 +98,7 @@ func (r *Resolver) VisitSuperExpr(expr *ast.SuperExpr) any {
 
 func (r *Resolver) VisitSelfExpr(expr *ast.SelfExpr) any {
 	if r.currentClass == ClassTypeNone {
-		r.interpreter.Runtime.ReportRuntimeError(expr.Keyword, "Cannot use 'self' outside of a class.")
+		r.errorToken(expr.Keyword, "Cannot use 'self' outside of a class.")
 		return nil
 	}
 
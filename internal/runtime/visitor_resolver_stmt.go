@@ -19,7 +19,7 @@ func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) any {
 	if stmt.Superclass != nil {
 		if variable, ok := stmt.Superclass.(*ast.VariableExpr); ok {
 			if stmt.Name.Lexeme == variable.Name.Lexeme {
-				r.interpreter.Runtime.ReportRuntimeError(variable.Name, "A class cannot inherit from itself.")
+				r.errorToken(variable.Name, "A class cannot inherit from itself.")
 			}
 		}
 
@@ -103,13 +103,13 @@ func (r *Resolver) VisitPrintStmt(stmt *ast.PrintStmt) any {
 
 func (r *Resolver) VisitReturnStmt(stmt *ast.ReturnStmt) any {
 	if r.currentFunction == FunctionTypeNone {
-		r.interpreter.Runtime.ReportRuntimeError(stmt.Keyword, "Cannot return from top-level code.")
+		r.errorToken(stmt.Keyword, "Cannot return from top-level code.")
 		return nil
 	}
 
 	if stmt.Value != nil {
 		if r.currentFunction == FunctionTypeInitializer {
-			r.interpreter.Runtime.ReportRuntimeError(stmt.Keyword, "Cannot return a value from an initializer.")
+			r.errorToken(stmt.Keyword, "Cannot return a value from an initializer.")
 		}
 		r.ResolveExpr(stmt.Value)
 	}
@@ -176,6 +176,16 @@ func (r *Resolver) VisitWithStmt(stmt *ast.WithStmt) any {
 }
 
 func (r *Resolver) VisitImportStmt(stmt *ast.ImportStmt) any {
+	// no topo os nomes ficam no escopo global ou do módulo; dentro de um
+	// bloco são locais como um "let"
+	if stmt.Alias != nil {
+		r.Declare(stmt.Alias)
+		r.Define(stmt.Alias)
+	}
+	for _, name := range stmt.Names {
+		r.Declare(name.Binding())
+		r.Define(name.Binding())
+	}
 	return nil
 }
 
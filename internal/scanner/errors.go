@@ -1,13 +1,20 @@
 package scanner
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type ScannerError struct {
 	Message string
 	Line    int
+	Column  int
 }
 
 func (e ScannerError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("[line %d:%d] Error: %s", e.Line, e.Column, e.Message)
+	}
 	return fmt.Sprintf("[line %d] Error: %s", e.Line, e.Message)
 }
 
@@ -17,3 +24,22 @@ func NewScannerError(line int, message string) ScannerError {
 		Line:    line,
 	}
 }
+
+func NewScannerErrorAt(line, column int, message string) ScannerError {
+	return ScannerError{
+		Message: message,
+		Line:    line,
+		Column:  column,
+	}
+}
+
+// ScannerErrors reúne os erros encontrados ao varrer uma fonte.
+type ScannerErrors []ScannerError
+
+func (e ScannerErrors) Error() string {
+	messages := make([]string, len(e))
+	for idx, err := range e {
+		messages[idx] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
@@ -3,17 +3,31 @@ package scanner
 import (
 	"fmt"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/MichelLacerda/nox/internal/keywords"
 	"github.com/MichelLacerda/nox/internal/token"
 )
 
 type Scanner struct {
-	source  []rune
-	tokens  []*token.Token
-	start   int
-	current int
-	line    int
+	source    []rune
+	tokens    []*token.Token
+	start     int
+	current   int
+	line      int
+	lineStart int // índice (em runas) do início da linha atual
+	offset    int // deslocamento em bytes de current
+
+	// Posição de start, registrada antes de consumir cada token
+	startLine   int
+	startColumn int
+	startOffset int
+
+	// KeepComments faz os comentários "//" e "/* */" serem emitidos como
+	// tokens TokenType_COMMENT, com o texto completo no lexema, para
+	// ferramentas que precisam reproduzir a fonte (formatador, geradores de
+	// documentação). O parser não aceita esses tokens: desligado por padrão.
+	KeepComments bool
 }
 
 func NewScanner(source []rune) *Scanner {
@@ -26,17 +40,49 @@ func NewScanner(source []rune) *Scanner {
 	}
 }
 
+// ScanTokens percorre toda a fonte. Erros não interrompem a varredura: o
+// trecho inválido vira um token TokenType_Unknown e os erros são devolvidos
+// juntos como ScannerErrors, acompanhados dos tokens. O parser não reporta
+// erros nos tokens Unknown, que já têm o erro do scanner: uma string sem
+// fim não gera também um "Expect expression" no fim do arquivo.
 func (s *Scanner) ScanTokens() ([]*token.Token, error) {
+	var errs ScannerErrors
 	for !s.IsAtEnd() {
-		s.start = s.current
+		s.markStart()
 		if err := s.ScanToken(); err != nil {
-			return nil, fmt.Errorf("error scanning token at line %d: %w", s.line, err)
+			scanErr, ok := err.(ScannerError)
+			if !ok {
+				scanErr = s.errorHere(err.Error())
+			}
+			errs = append(errs, scanErr)
+			s.AddToken(token.TokenType_Unknown)
 		}
 	}
-	s.tokens = append(s.tokens, token.NewToken(token.TokenType_EOF, "", nil, 0))
+	s.markStart()
+	s.AddToken(token.TokenType_EOF)
+	if len(errs) > 0 {
+		return s.tokens, errs
+	}
 	return s.tokens, nil
 }
 
+func (s *Scanner) markStart() {
+	s.start = s.current
+	s.startLine = s.line
+	s.startColumn = s.current - s.lineStart + 1
+	s.startOffset = s.offset
+}
+
+// newline deve ser chamado logo após consumir um '\n'.
+func (s *Scanner) newline() {
+	s.line++
+	s.lineStart = s.current
+}
+
+func (s *Scanner) errorHere(message string) ScannerError {
+	return NewScannerErrorAt(s.startLine, s.startColumn, message)
+}
+
 func (s *Scanner) ScanToken() error {
 	c := s.Advance()
 
@@ -105,11 +151,12 @@ func (s *Scanner) ScanToken() error {
 			for s.Peek() != '\n' && !s.IsAtEnd() {
 				s.Advance()
 			}
+			s.addComment()
 		} else if s.Match('*') {
 			// A block comment starts with /* and ends with */.
 			for {
 				if s.IsAtEnd() {
-					return NewScannerError(s.line, "Unterminated block comment.")
+					return s.errorHere("Unterminated block comment.")
 				}
 
 				if s.Peek() == '*' {
@@ -118,34 +165,38 @@ func (s *Scanner) ScanToken() error {
 						// End of block comment.
 						break
 					}
-				} else {
-					if s.Peek() == '\n' {
-						s.line++
-					}
-					s.Advance()
+				} else if s.Advance() == '\n' {
+					s.newline()
 				}
 			}
+			s.addComment()
 		} else {
 			s.AddToken(token.TokenType_SLASH)
 		}
 	case ' ', '\r', '\t':
 		// Ignore whitespace.
 	case '\n':
-		s.line++
+		s.newline()
 	case '"':
-		s.ConsumeString()
+		return s.ConsumeString()
 	default:
 		if s.IsDigit(c) {
-			s.ConsumeNumber()
+			return s.ConsumeNumber()
 		} else if s.IsAlpha(c) {
 			s.ConsumeIdentifier()
 		} else {
-			panic(NewScannerError(s.line, fmt.Sprintf("Unexpected character '%c'", c)))
+			return s.errorHere(fmt.Sprintf("Unexpected character '%c'", c))
 		}
 	}
 	return nil
 }
 
+func (s *Scanner) addComment() {
+	if s.KeepComments {
+		s.AddToken(token.TokenType_COMMENT)
+	}
+}
+
 func (s *Scanner) ConsumeIdentifier() {
 	for s.IsAlphaNumeric(s.Peek()) {
 		s.Advance()
@@ -177,7 +228,7 @@ func (s *Scanner) ConsumeNumber() error {
 	value, err := strconv.ParseFloat(lexema, 64)
 
 	if err != nil {
-		return NewScannerError(s.line, fmt.Sprintf("Invalid number format: %s", lexema))
+		return s.errorHere(fmt.Sprintf("Invalid number format: %s", lexema))
 	}
 
 	s.AddTokenWithLiteral(token.TokenType_NUMBER, value)
@@ -187,14 +238,13 @@ func (s *Scanner) ConsumeNumber() error {
 
 func (s *Scanner) ConsumeString() error {
 	for !s.IsAtEnd() && s.Peek() != '"' {
-		if s.Peek() == '\n' {
-			s.line++
+		if s.Advance() == '\n' {
+			s.newline()
 		}
-		s.Advance()
 	}
 
 	if s.IsAtEnd() {
-		return NewScannerError(s.line, "Unterminated string.")
+		return s.errorHere("Unterminated string.")
 	}
 
 	// Consume the closing '"'.
@@ -212,7 +262,7 @@ func (s *Scanner) Match(expected rune) bool {
 	if s.IsAtEnd() || s.source[s.current] != expected {
 		return false
 	}
-	s.current = s.current + 1
+	s.Advance()
 	return true
 }
 
@@ -236,15 +286,20 @@ func (s *Scanner) AddToken(tokenType token.TokenType) {
 
 func (s *Scanner) AddTokenWithLiteral(tokenType token.TokenType, literal any) {
 	lexeme := string(s.source[s.start:s.current])
-	s.tokens = append(s.tokens, token.NewToken(tokenType, lexeme, literal, s.line))
+	t := token.NewToken(tokenType, lexeme, literal, s.startLine)
+	t.Column = s.startColumn
+	t.Offset = s.startOffset
+	s.tokens = append(s.tokens, t)
 }
 
 func (s *Scanner) Advance() rune {
 	if s.IsAtEnd() {
 		return 0
 	}
+	c := s.source[s.current]
 	s.current = s.current + 1
-	return s.source[s.current-1]
+	s.offset += utf8.RuneLen(c)
+	return c
 }
 
 func (s *Scanner) IsDigit(c rune) bool {
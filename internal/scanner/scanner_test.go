@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MichelLacerda/nox/internal/token"
+)
+
+const commentedSource = `// cabeçalho
+let x = 1; // no fim
+/* bloco
+   de várias linhas */ print x;
+let y = 4 / 2;
+`
+
+// scan devolve os tokens de source como "linha:coluna@offset TIPO lexema",
+// sem o EOF.
+func scan(t *testing.T, source string, keepComments bool) []string {
+	t.Helper()
+	s := NewScanner([]rune(source))
+	s.KeepComments = keepComments
+	tokens, err := s.ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tok := range tokens {
+		if tok.Type == token.TokenType_EOF {
+			break
+		}
+		got = append(got, fmt.Sprintf("%d:%d@%d %s %s", tok.Line, tok.Column, tok.Offset, tok.Type, tok.Lexeme))
+	}
+	return got
+}
+
+func TestKeepComments(t *testing.T) {
+	got := scan(t, commentedSource, true)
+	want := []string{
+		"1:1@0 COMMENT // cabeçalho",
+		"2:1@14 LET let",
+		"2:5@18 IDENTIFIER x",
+		"2:7@20 EQUAL =",
+		"2:9@22 NUMBER 1",
+		"2:10@23 SEMICOLON ;",
+		"2:12@25 COMMENT // no fim",
+		"3:1@35 COMMENT /* bloco\n   de várias linhas */",
+		"4:24@68 PRINT print",
+		"4:30@74 IDENTIFIER x",
+		"4:31@75 SEMICOLON ;",
+		"5:1@77 LET let",
+		"5:5@81 IDENTIFIER y",
+		"5:7@83 EQUAL =",
+		"5:9@85 NUMBER 4",
+		"5:11@87 SLASH /",
+		"5:13@89 NUMBER 2",
+		"5:14@90 SEMICOLON ;",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+// Sem KeepComments os comentários somem e os outros tokens não mudam.
+func TestDropComments(t *testing.T) {
+	var want []string
+	for _, tok := range scan(t, commentedSource, true) {
+		if strings.Fields(tok)[1] != "COMMENT" {
+			want = append(want, tok)
+		}
+	}
+	if got := scan(t, commentedSource, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		s := NewScanner([]rune("let a = 1;\n/* aberto\nprint a;\n"))
+		s.KeepComments = keep
+		_, err := s.ScanTokens()
+		errs, ok := err.(ScannerErrors)
+		if !ok || len(errs) != 1 || errs[0].Line != 2 || errs[0].Message != "Unterminated block comment." {
+			t.Errorf("KeepComments=%v: err = %v, want one unterminated comment error on line 2", keep, err)
+		}
+	}
+}
@@ -67,6 +67,9 @@ const (
 	TokenType_IMPORT
 	TokenType_EXPORT
 
+	// Trivia: só é emitido pelo scanner com KeepComments.
+	TokenType_COMMENT
+
 	// Unknown or reserved keywords.
 	TokenType_Unknown
 )
@@ -124,6 +127,7 @@ var TokenTypeNames = map[TokenType]string{
 	TokenType_AS:            "AS",
 	TokenType_IMPORT:        "IMPORT",
 	TokenType_EXPORT:        "EXPORT",
+	TokenType_COMMENT:       "COMMENT",
 	TokenType_Unknown:       "UNKNOWN",
 }
 
@@ -139,6 +143,8 @@ type Token struct {
 	Lexeme  string
 	Literal any
 	Line    int
+	Column  int // coluna (em runas, a partir de 1) onde o token começa
+	Offset  int // deslocamento em bytes do início do token na fonte
 }
 
 func (t Token) String() string {
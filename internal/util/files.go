@@ -0,0 +1,42 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MichelLacerda/nox/internal/manifest"
+)
+
+// NoxFiles expande diretórios nos arquivos terminados em suffix contidos
+// neles, ignorando pastas ocultas e as dependências instaladas em
+// nox_modules. Arquivos passados diretamente são usados como estão.
+func NoxFiles(paths []string, suffix string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != path && (strings.HasPrefix(d.Name(), ".") || d.Name() == manifest.VendorDir) {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && strings.HasSuffix(p, suffix) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}